@@ -9,7 +9,6 @@ import (
 	db "github.com/adedaryorh/ecommerceapi/db/sqlc"
 	"github.com/adedaryorh/ecommerceapi/utils"
 	"github.com/gin-gonic/gin"
-	"github.com/lib/pq"
 )
 
 type Auth struct {
@@ -141,17 +140,7 @@ func (a *Auth) register(c *gin.Context) {
 
 	newUser, err := a.server.queries.CreateUser(context.Background(), arg)
 	if err != nil {
-		if pgErr, ok := err.(*pq.Error); ok {
-			switch pgErr.Constraint {
-			case "users_email_key":
-				c.JSON(http.StatusBadRequest, gin.H{"error": "Email already exists"})
-				return
-			case "users_username_key":
-				c.JSON(http.StatusBadRequest, gin.H{"error": "Username already exists"})
-				return
-			}
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		dbError(c, err)
 		return
 	}
 
@@ -217,17 +206,7 @@ func (a *Auth) register(c *gin.Context) {
 	}
 	newUser, err := a.server.queries.CreateUser(context.Background(), arg)
 	if err != nil {
-		if pgErr, ok := err.(*pq.Error); ok {
-			switch pgErr.Constraint {
-			case "users_email_key":
-				c.JSON(http.StatusBadRequest, gin.H{"error": "Email already exists"})
-				return
-			case "users_username_key":
-				c.JSON(http.StatusBadRequest, gin.H{"error": "Username already exists"})
-				return
-			}
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		dbError(c, err)
 		return
 	}
 
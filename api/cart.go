@@ -0,0 +1,374 @@
+package api_errors
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	db "github.com/adedaryorh/ecommerceapi/db/sqlc"
+	"github.com/adedaryorh/ecommerceapi/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type Cart struct {
+	server *Server
+}
+
+// Set up routes for the authenticated user's shopping cart.
+func (ct *Cart) router(server *Server) {
+	ct.server = server
+
+	serverGroup := server.router.Group("/cart", server.AuthenticatedMiddleware())
+	serverGroup.GET("", ct.getCart)
+	serverGroup.DELETE("", ct.clearCart)
+	serverGroup.PUT("/items/:product_id", ct.setCartItem)
+	serverGroup.DELETE("/items/:product_id", ct.removeCartItem)
+	serverGroup.POST("/checkout", server.IdempotencyMiddleware(), ct.checkout)
+}
+
+// CartItemParams defines the expected input when setting a cart line.
+// VariantID selects one of the product's variants; each variant is its own
+// line.
+type CartItemParams struct {
+	VariantID *int64 `json:"variant_id"`
+	Quantity  int32  `json:"quantity" binding:"required,gt=0"`
+}
+
+// CheckoutParams defines the optional input when checking out the cart.
+type CheckoutParams struct {
+	CouponCode string        `json:"coupon_code"`
+	Address    AddressParams `json:"address"`
+}
+
+// CartItemResponse defines a single cart line priced at the current catalog price.
+type CartItemResponse struct {
+	ProductID   int64  `json:"product_id"`
+	VariantID   *int64 `json:"variant_id,omitempty"`
+	VariantSku  string `json:"variant_sku,omitempty"`
+	ProductName string `json:"product_name"`
+	Price       string `json:"price"`
+	Quantity    int32  `json:"quantity"`
+	LineTotal   string `json:"line_total"`
+	InStock     bool   `json:"in_stock"`
+}
+
+// CartResponse defines the response structure for a cart.
+type CartResponse struct {
+	ID        int64              `json:"id"`
+	Items     []CartItemResponse `json:"items"`
+	Subtotal  string             `json:"subtotal"`
+	UpdatedAt time.Time          `json:"updated_at"`
+}
+
+func (ct *Cart) loadCart(userID int64) (*CartResponse, error) {
+	cart, err := ct.server.queries.GetOrCreateCart(context.Background(), userID)
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := ct.server.queries.ListCartItems(context.Background(), cart.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &CartResponse{
+		ID:        cart.ID,
+		Items:     []CartItemResponse{},
+		UpdatedAt: cart.UpdatedAt,
+	}
+	var subtotal int64
+	for _, item := range items {
+		price, err := utils.ParseCents(item.Price)
+		if err != nil {
+			return nil, err
+		}
+		lineTotal := price * int64(item.Quantity)
+		subtotal += lineTotal
+
+		line := CartItemResponse{
+			ProductID:   item.ProductID,
+			VariantSku:  item.VariantSku.String,
+			ProductName: item.ProductName,
+			Price:       item.Price,
+			Quantity:    item.Quantity,
+			LineTotal:   utils.FormatCents(lineTotal),
+			InStock:     item.Stock >= item.Quantity,
+		}
+		if item.VariantID.Valid {
+			line.VariantID = &item.VariantID.Int64
+		}
+		response.Items = append(response.Items, line)
+	}
+	response.Subtotal = utils.FormatCents(subtotal)
+
+	return response, nil
+}
+
+// @Summary Get Cart
+// @Description Retrieve the authenticated user's cart with current prices
+// @Tags Cart
+// @Produce json
+// @Success 200 {object} CartResponse
+// @Failure 401 {object} api_errors.ApiError
+// @Failure 500 {object} api_errors.ApiError
+// @Security BearerAuth
+// @Router /cart [get]
+func (ct *Cart) getCart(c *gin.Context) {
+	userID, ok := authenticatedUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	cart, err := ct.loadCart(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, cart)
+}
+
+// @Summary Set Cart Item
+// @Description Add a product, or one of its variants, to the cart or change its quantity
+// @Tags Cart
+// @Accept json
+// @Produce json
+// @Param product_id path string true "Product ID"
+// @Param item body CartItemParams true "Cart item quantity"
+// @Success 200 {object} CartResponse
+// @Failure 400 {object} api_errors.ApiError
+// @Failure 401 {object} api_errors.ApiError
+// @Failure 404 {object} api_errors.ApiError "Product or variant not found"
+// @Failure 500 {object} api_errors.ApiError
+// @Security BearerAuth
+// @Router /cart/items/{product_id} [put]
+func (ct *Cart) setCartItem(c *gin.Context) {
+	userID, ok := authenticatedUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	productID, err := strconv.ParseInt(c.Param("product_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+
+	var params CartItemParams
+	if err := c.ShouldBindJSON(&params); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	_, err = ct.server.queries.GetProductByID(context.Background(), productID)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var variantID sql.NullInt64
+	if params.VariantID != nil {
+		_, err = ct.server.queries.GetProductVariant(context.Background(), db.GetProductVariantParams{
+			ID:        *params.VariantID,
+			ProductID: productID,
+		})
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Variant not found"})
+			return
+		} else if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		variantID = sql.NullInt64{Int64: *params.VariantID, Valid: true}
+	}
+
+	cart, err := ct.server.queries.GetOrCreateCart(context.Background(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	_, err = ct.server.queries.UpsertCartItem(context.Background(), db.UpsertCartItemParams{
+		CartID:    cart.ID,
+		ProductID: productID,
+		VariantID: variantID,
+		Quantity:  params.Quantity,
+	})
+	if err != nil {
+		dbError(c, err)
+		return
+	}
+
+	response, err := ct.loadCart(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// @Summary Remove Cart Item
+// @Description Remove a product, or one of its variants, from the cart
+// @Tags Cart
+// @Produce json
+// @Param product_id path string true "Product ID"
+// @Param variant_id query string false "Variant ID"
+// @Success 200 {object} CartResponse
+// @Failure 400 {object} api_errors.ApiError
+// @Failure 401 {object} api_errors.ApiError
+// @Failure 404 {object} api_errors.ApiError
+// @Failure 500 {object} api_errors.ApiError
+// @Security BearerAuth
+// @Router /cart/items/{product_id} [delete]
+func (ct *Cart) removeCartItem(c *gin.Context) {
+	userID, ok := authenticatedUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	productID, err := strconv.ParseInt(c.Param("product_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+
+	var variantID sql.NullInt64
+	if value := c.Query("variant_id"); value != "" {
+		id, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid variant ID"})
+			return
+		}
+		variantID = sql.NullInt64{Int64: id, Valid: true}
+	}
+
+	cart, err := ct.server.queries.GetOrCreateCart(context.Background(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	removed, err := ct.server.queries.RemoveCartItem(context.Background(), db.RemoveCartItemParams{
+		CartID:    cart.ID,
+		ProductID: productID,
+		VariantID: variantID,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if removed == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Product is not in the cart"})
+		return
+	}
+
+	response, err := ct.loadCart(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// @Summary Clear Cart
+// @Description Remove every item from the cart
+// @Tags Cart
+// @Produce json
+// @Success 200 {object} CartResponse
+// @Failure 401 {object} api_errors.ApiError
+// @Failure 500 {object} api_errors.ApiError
+// @Security BearerAuth
+// @Router /cart [delete]
+func (ct *Cart) clearCart(c *gin.Context) {
+	userID, ok := authenticatedUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	cart, err := ct.server.queries.GetOrCreateCart(context.Background(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := ct.server.queries.ClearCart(context.Background(), cart.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	response, err := ct.loadCart(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// @Summary Checkout Cart
+// @Description Convert the cart into an order using current product prices and empty the cart
+// @Tags Cart
+// @Accept json
+// @Produce json
+// @Param checkout body CheckoutParams false "Optional coupon to apply and address to tax the order at"
+// @Param Idempotency-Key header string false "Key that makes retries of this request safe"
+// @Success 201 {object} OrderResponse
+// @Failure 400 {object} api_errors.ApiError "Cart is empty"
+// @Failure 401 {object} api_errors.ApiError
+// @Failure 404 {object} api_errors.ApiError "Product or coupon not found"
+// @Failure 409 {object} api_errors.ApiError "Insufficient stock"
+// @Failure 422 {object} api_errors.ApiError "Coupon does not apply, or Idempotency-Key reused for a different request"
+// @Failure 500 {object} api_errors.ApiError
+// @Security BearerAuth
+// @Router /cart/checkout [post]
+func (ct *Cart) checkout(c *gin.Context) {
+	userID, ok := authenticatedUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	// The body is optional; an empty one checks out without a coupon.
+	var params CheckoutParams
+	if err := c.ShouldBindJSON(&params); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := ct.server.store.CheckoutCartTx(context.Background(), db.CheckoutCartTxParams{
+		UserID:         userID,
+		ReservationTTL: ct.server.config.Reservation_ttl,
+		CouponCode:     params.CouponCode,
+		Tax:            ct.server.taxes,
+		TaxAddress:     params.Address.taxAddress(),
+	})
+	if errors.Is(err, db.ErrEmptyCart) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	} else if err != nil {
+		ct.server.placeOrderError(c, err)
+		return
+	}
+
+	response, err := OrderResponse{}.toOrderResponse(&result.Order, result.OrderItems)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	response.TaxLines = toTaxLineResponses(result.TaxLines)
+
+	c.JSON(http.StatusCreated, response)
+}
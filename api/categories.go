@@ -0,0 +1,316 @@
+package api_errors
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode"
+
+	db "github.com/adedaryorh/ecommerceapi/db/sqlc"
+	"github.com/gin-gonic/gin"
+)
+
+// CategoryParams defines the expected input for category operations.
+type CategoryParams struct {
+	Name     string `json:"name" binding:"required"`
+	Slug     string `json:"slug"`
+	ParentID *int64 `json:"parent_id"`
+}
+
+// ProductCategoriesParams defines the full set of categories for a product.
+type ProductCategoriesParams struct {
+	CategoryIDs []int64 `json:"category_ids" binding:"required"`
+}
+
+// CategoryResponse defines the response structure for a category. Children
+// is only populated by the category tree endpoint.
+type CategoryResponse struct {
+	ID       int64              `json:"id"`
+	Name     string             `json:"name"`
+	Slug     string             `json:"slug"`
+	ParentID *int64             `json:"parent_id"`
+	Children []CategoryResponse `json:"children,omitempty"`
+}
+
+// Converts a db.Category to a CategoryResponse.
+func (cr CategoryResponse) toCategoryResponse(category *db.Category) CategoryResponse {
+	var parentID *int64
+	if category.ParentID.Valid {
+		parentID = &category.ParentID.Int64
+	}
+	return CategoryResponse{
+		ID:       category.ID,
+		Name:     category.Name,
+		Slug:     category.Slug,
+		ParentID: parentID,
+	}
+}
+
+// buildCategoryTree nests categories under their parents, keeping the
+// order of the input slice at every level.
+func buildCategoryTree(categories []db.Category) []CategoryResponse {
+	children := map[int64][]db.Category{}
+	roots := []db.Category{}
+	for _, category := range categories {
+		if category.ParentID.Valid {
+			children[category.ParentID.Int64] = append(children[category.ParentID.Int64], category)
+		} else {
+			roots = append(roots, category)
+		}
+	}
+
+	var build func(nodes []db.Category) []CategoryResponse
+	build = func(nodes []db.Category) []CategoryResponse {
+		response := []CategoryResponse{}
+		for _, node := range nodes {
+			item := CategoryResponse{}.toCategoryResponse(&node)
+			item.Children = build(children[node.ID])
+			response = append(response, item)
+		}
+		return response
+	}
+	return build(roots)
+}
+
+// slugify turns a category name into a URL-friendly slug.
+func slugify(name string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(strings.TrimSpace(name)) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+			dash = false
+		} else if !dash && b.Len() > 0 {
+			b.WriteRune('-')
+			dash = true
+		}
+	}
+	return strings.TrimSuffix(b.String(), "-")
+}
+
+// @Summary List Categories
+// @Description Retrieve the category tree used for storefront navigation
+// @Tags Categories
+// @Produce json
+// @Success 200 {array} api_errors.CategoryResponse
+// @Failure 500 {object} api_errors.ApiError
+// @Router /products/categories [get]
+func (p *Product) listCategories(c *gin.Context) {
+	categories, err := p.server.queries.ListCategories(context.Background())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list categories: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, buildCategoryTree(categories))
+}
+
+// @Summary Create Category
+// @Description Create a category, optionally nested under a parent (admin only)
+// @Tags Categories
+// @Accept json
+// @Produce json
+// @Param category body CategoryParams true "Category Details"
+// @Success 201 {object} api_errors.CategoryResponse
+// @Failure 400 {object} api_errors.ApiError
+// @Failure 409 {object} api_errors.ApiError
+// @Failure 500 {object} api_errors.ApiError
+// @Security BearerAuth
+// @Router /products/categories [post]
+func (p *Product) createCategory(c *gin.Context) {
+	var params CategoryParams
+	if err := c.ShouldBindJSON(&params); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	arg := db.CreateCategoryParams{
+		Name: params.Name,
+		Slug: params.Slug,
+	}
+	if arg.Slug == "" {
+		arg.Slug = slugify(params.Name)
+	}
+	if arg.Slug == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Category slug cannot be empty"})
+		return
+	}
+
+	if params.ParentID != nil {
+		_, err := p.server.queries.GetCategoryByID(context.Background(), *params.ParentID)
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Parent category not found"})
+			return
+		} else if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		arg.ParentID = sql.NullInt64{Int64: *params.ParentID, Valid: true}
+	}
+
+	category, err := p.server.queries.CreateCategory(context.Background(), arg)
+	if err != nil {
+		dbError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, CategoryResponse{}.toCategoryResponse(&category))
+}
+
+// @Summary Update Category
+// @Description Rename or move a category (admin only)
+// @Tags Categories
+// @Accept json
+// @Produce json
+// @Param category_id path string true "Category ID"
+// @Param category body CategoryParams true "Category Details"
+// @Success 200 {object} api_errors.CategoryResponse
+// @Failure 400 {object} api_errors.ApiError
+// @Failure 404 {object} api_errors.ApiError
+// @Failure 409 {object} api_errors.ApiError
+// @Failure 500 {object} api_errors.ApiError
+// @Security BearerAuth
+// @Router /products/categories/{category_id} [put]
+func (p *Product) updateCategory(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("category_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid category ID"})
+		return
+	}
+
+	var params CategoryParams
+	if err := c.ShouldBindJSON(&params); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	arg := db.UpdateCategoryParams{
+		Name: params.Name,
+		Slug: params.Slug,
+		ID:   id,
+	}
+	if arg.Slug == "" {
+		arg.Slug = slugify(params.Name)
+	}
+
+	if params.ParentID != nil {
+		// Moving a category under itself or one of its descendants would create a cycle.
+		descendant, err := p.server.queries.IsCategoryDescendant(context.Background(), db.IsCategoryDescendantParams{
+			AncestorID: id,
+			CategoryID: *params.ParentID,
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if descendant {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "A category cannot be moved under itself or its descendants"})
+			return
+		}
+
+		_, err = p.server.queries.GetCategoryByID(context.Background(), *params.ParentID)
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Parent category not found"})
+			return
+		} else if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		arg.ParentID = sql.NullInt64{Int64: *params.ParentID, Valid: true}
+	}
+
+	category, err := p.server.queries.UpdateCategory(context.Background(), arg)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Category not found"})
+		return
+	} else if err != nil {
+		dbError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, CategoryResponse{}.toCategoryResponse(&category))
+}
+
+// @Summary Delete Category
+// @Description Delete a category without subcategories (admin only)
+// @Tags Categories
+// @Param category_id path string true "Category ID"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} api_errors.ApiError
+// @Failure 404 {object} api_errors.ApiError
+// @Failure 409 {object} api_errors.ApiError
+// @Failure 500 {object} api_errors.ApiError
+// @Security BearerAuth
+// @Router /products/categories/{category_id} [delete]
+func (p *Product) deleteCategory(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("category_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid category ID"})
+		return
+	}
+
+	deleted, err := p.server.queries.DeleteCategory(context.Background(), id)
+	if err != nil {
+		dbError(c, err)
+		return
+	}
+	if deleted == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Category not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Category deleted successfully"})
+}
+
+// @Summary Set Product Categories
+// @Description Replace the categories a product belongs to (admin only)
+// @Tags Categories
+// @Accept json
+// @Produce json
+// @Param id path string true "Product ID"
+// @Param categories body ProductCategoriesParams true "Category IDs"
+// @Success 200 {array} api_errors.CategoryResponse
+// @Failure 400 {object} api_errors.ApiError
+// @Failure 404 {object} api_errors.ApiError
+// @Failure 500 {object} api_errors.ApiError
+// @Security BearerAuth
+// @Router /products/{id}/categories [put]
+func (p *Product) setProductCategories(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+
+	var params ProductCategoriesParams
+	if err := c.ShouldBindJSON(&params); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	categories, err := p.server.store.SetProductCategoriesTx(context.Background(), db.SetProductCategoriesTxParams{
+		ProductID:   id,
+		CategoryIDs: params.CategoryIDs,
+	})
+	var notFound *db.ProductNotFoundError
+	if errors.As(err, &notFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+		return
+	} else if errors.Is(err, db.ErrCategoryNotFound) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	response := []CategoryResponse{}
+	for _, category := range categories {
+		response = append(response, CategoryResponse{}.toCategoryResponse(&category))
+	}
+
+	c.JSON(http.StatusOK, response)
+}
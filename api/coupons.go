@@ -0,0 +1,347 @@
+package api_errors
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	db "github.com/adedaryorh/ecommerceapi/db/sqlc"
+	"github.com/adedaryorh/ecommerceapi/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// CouponParams defines the expected input for creating or replacing a
+// coupon. Value is a percentage for percentage coupons and an amount for
+// fixed ones. Leaving both ProductIDs and CategoryIDs empty makes the
+// coupon apply to every product.
+type CouponParams struct {
+	Code         string     `json:"code" binding:"required,max=64"`
+	Description  string     `json:"description"`
+	Kind         string     `json:"kind" binding:"required,oneof=percentage fixed"`
+	Value        string     `json:"value"`
+	MinSpend     string     `json:"min_spend"`
+	UsageLimit   *int32     `json:"usage_limit" binding:"omitempty,gt=0"`
+	PerUserLimit *int32     `json:"per_user_limit" binding:"omitempty,gt=0"`
+	StartsAt     *time.Time `json:"starts_at"`
+	EndsAt       *time.Time `json:"ends_at"`
+	Active       *bool      `json:"active"`
+	ProductIDs   []int64    `json:"product_ids"`
+	CategoryIDs  []int64    `json:"category_ids"`
+}
+
+// CouponResponse defines the admin view of a coupon. The scope is left out
+// of listings.
+type CouponResponse struct {
+	ID           int64      `json:"id"`
+	Code         string     `json:"code"`
+	Description  string     `json:"description"`
+	Kind         string     `json:"kind"`
+	Value        string     `json:"value"`
+	MinSpend     string     `json:"min_spend"`
+	UsageLimit   *int32     `json:"usage_limit"`
+	PerUserLimit *int32     `json:"per_user_limit"`
+	StartsAt     *time.Time `json:"starts_at"`
+	EndsAt       *time.Time `json:"ends_at"`
+	Active       bool       `json:"active"`
+	ProductIDs   []int64    `json:"product_ids,omitempty"`
+	CategoryIDs  []int64    `json:"category_ids,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+// Converts a db.Coupon and, optionally, its scope to a CouponResponse.
+func (cr CouponResponse) toCouponResponse(coupon *db.Coupon, scope *db.CouponScope) CouponResponse {
+	response := CouponResponse{
+		ID:          coupon.ID,
+		Code:        coupon.Code,
+		Description: coupon.Description,
+		Kind:        coupon.Kind,
+		Value:       coupon.Value,
+		MinSpend:    coupon.MinSpend,
+		Active:      coupon.Active,
+		CreatedAt:   coupon.CreatedAt,
+		UpdatedAt:   coupon.UpdatedAt,
+	}
+	if coupon.UsageLimit.Valid {
+		response.UsageLimit = &coupon.UsageLimit.Int32
+	}
+	if coupon.PerUserLimit.Valid {
+		response.PerUserLimit = &coupon.PerUserLimit.Int32
+	}
+	if coupon.StartsAt.Valid {
+		response.StartsAt = &coupon.StartsAt.Time
+	}
+	if coupon.EndsAt.Valid {
+		response.EndsAt = &coupon.EndsAt.Time
+	}
+	if scope != nil {
+		response.ProductIDs = scope.ProductIDs
+		response.CategoryIDs = scope.CategoryIDs
+	}
+	return response
+}
+
+// couponArgs validates coupon input and converts it to column values.
+func (params CouponParams) couponArgs() (db.CreateCouponParams, error) {
+	arg := db.CreateCouponParams{
+		Code:        db.NormalizeCouponCode(params.Code),
+		Description: params.Description,
+		Kind:        params.Kind,
+		Value:       utils.FormatCents(0),
+		MinSpend:    utils.FormatCents(0),
+		Active:      params.Active == nil || *params.Active,
+	}
+	if arg.Code == "" {
+		return arg, errors.New("code cannot be empty")
+	}
+
+	switch db.CouponKind(params.Kind) {
+	case db.CouponPercentage, db.CouponFixed:
+		value, err := utils.ParseCents(params.Value)
+		if err != nil || value <= 0 {
+			return arg, errors.New("value must be a positive amount")
+		}
+		if db.CouponKind(params.Kind) == db.CouponPercentage && value > 100*100 {
+			return arg, errors.New("percentage discount cannot exceed 100")
+		}
+		arg.Value = utils.FormatCents(value)
+	}
+
+	if params.MinSpend != "" {
+		minSpend, err := utils.ParseCents(params.MinSpend)
+		if err != nil || minSpend < 0 {
+			return arg, errors.New("min_spend must be a non-negative amount")
+		}
+		arg.MinSpend = utils.FormatCents(minSpend)
+	}
+
+	if params.UsageLimit != nil {
+		arg.UsageLimit = sql.NullInt32{Int32: *params.UsageLimit, Valid: true}
+	}
+	if params.PerUserLimit != nil {
+		arg.PerUserLimit = sql.NullInt32{Int32: *params.PerUserLimit, Valid: true}
+	}
+	if params.StartsAt != nil {
+		arg.StartsAt = sql.NullTime{Time: *params.StartsAt, Valid: true}
+	}
+	if params.EndsAt != nil {
+		arg.EndsAt = sql.NullTime{Time: *params.EndsAt, Valid: true}
+	}
+	if arg.StartsAt.Valid && arg.EndsAt.Valid && !arg.EndsAt.Time.After(arg.StartsAt.Time) {
+		return arg, errors.New("ends_at must be after starts_at")
+	}
+	return arg, nil
+}
+
+func (params CouponParams) scope() db.CouponScope {
+	return db.CouponScope{ProductIDs: params.ProductIDs, CategoryIDs: params.CategoryIDs}
+}
+
+// couponError maps coupon write failures to HTTP responses.
+func couponError(c *gin.Context, err error) {
+	var notFound *db.ProductNotFoundError
+	switch {
+	case errors.Is(err, db.ErrCouponNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Coupon not found"})
+	case errors.As(err, &notFound), errors.Is(err, db.ErrCategoryNotFound):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		dbError(c, err)
+	}
+}
+
+// @Summary Create Coupon
+// @Description Create a discount code, optionally limited to some products and categories (admin only)
+// @Tags Coupons
+// @Accept json
+// @Produce json
+// @Param coupon body CouponParams true "Coupon Details"
+// @Success 201 {object} CouponResponse
+// @Failure 400 {object} api_errors.ApiError
+// @Failure 409 {object} api_errors.ApiError "Coupon code already exists"
+// @Failure 500 {object} api_errors.ApiError
+// @Security BearerAuth
+// @Router /admin/coupons [post]
+func (s *Server) CreateCoupon(c *gin.Context) {
+	var params CouponParams
+	if err := c.ShouldBindJSON(&params); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	arg, err := params.couponArgs()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := s.store.CreateCouponTx(context.Background(), db.CreateCouponTxParams{
+		CreateCouponParams: arg,
+		Scope:              params.scope(),
+	})
+	if err != nil {
+		couponError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, CouponResponse{}.toCouponResponse(&result.Coupon, &result.Scope))
+}
+
+// @Summary List Coupons
+// @Description List coupons, newest first (admin only)
+// @Tags Coupons
+// @Produce json
+// @Param limit query int false "Number of coupons to retrieve" default(50)
+// @Param offset query int false "Offset for pagination" default(0)
+// @Success 200 {array} CouponResponse
+// @Failure 500 {object} api_errors.ApiError
+// @Security BearerAuth
+// @Router /admin/coupons [get]
+func (s *Server) ListCoupons(c *gin.Context) {
+	arg := db.ListCouponsParams{
+		PageLimit:  50,
+		PageOffset: 0,
+	}
+	if l, err := strconv.Atoi(c.Query("limit")); err == nil && l > 0 {
+		arg.PageLimit = int32(l)
+	}
+	if o, err := strconv.Atoi(c.Query("offset")); err == nil && o >= 0 {
+		arg.PageOffset = int32(o)
+	}
+
+	coupons, err := s.queries.ListCoupons(context.Background(), arg)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	response := []CouponResponse{}
+	for _, coupon := range coupons {
+		response = append(response, CouponResponse{}.toCouponResponse(&coupon, nil))
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// @Summary Get Coupon
+// @Description Retrieve a coupon with the products and categories it is limited to (admin only)
+// @Tags Coupons
+// @Produce json
+// @Param id path string true "Coupon ID"
+// @Success 200 {object} CouponResponse
+// @Failure 400 {object} api_errors.ApiError
+// @Failure 404 {object} api_errors.ApiError
+// @Failure 500 {object} api_errors.ApiError
+// @Security BearerAuth
+// @Router /admin/coupons/{id} [get]
+func (s *Server) GetCoupon(c *gin.Context) {
+	couponID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid coupon ID"})
+		return
+	}
+
+	coupon, err := s.queries.GetCouponByID(context.Background(), couponID)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Coupon not found"})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	scope, err := s.store.GetCouponScope(context.Background(), coupon.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, CouponResponse{}.toCouponResponse(&coupon, &scope))
+}
+
+// @Summary Update Coupon
+// @Description Replace a coupon and its scope (admin only). Orders that already used it keep their discount.
+// @Tags Coupons
+// @Accept json
+// @Produce json
+// @Param id path string true "Coupon ID"
+// @Param coupon body CouponParams true "Coupon Details"
+// @Success 200 {object} CouponResponse
+// @Failure 400 {object} api_errors.ApiError
+// @Failure 404 {object} api_errors.ApiError
+// @Failure 409 {object} api_errors.ApiError "Coupon code already exists"
+// @Failure 500 {object} api_errors.ApiError
+// @Security BearerAuth
+// @Router /admin/coupons/{id} [put]
+func (s *Server) UpdateCoupon(c *gin.Context) {
+	couponID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid coupon ID"})
+		return
+	}
+
+	var params CouponParams
+	if err := c.ShouldBindJSON(&params); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	arg, err := params.couponArgs()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := s.store.UpdateCouponTx(context.Background(), db.UpdateCouponTxParams{
+		UpdateCouponParams: db.UpdateCouponParams{
+			Code:         arg.Code,
+			Description:  arg.Description,
+			Kind:         arg.Kind,
+			Value:        arg.Value,
+			MinSpend:     arg.MinSpend,
+			UsageLimit:   arg.UsageLimit,
+			PerUserLimit: arg.PerUserLimit,
+			StartsAt:     arg.StartsAt,
+			EndsAt:       arg.EndsAt,
+			Active:       arg.Active,
+			ID:           couponID,
+		},
+		Scope: params.scope(),
+	})
+	if err != nil {
+		couponError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, CouponResponse{}.toCouponResponse(&result.Coupon, &result.Scope))
+}
+
+// @Summary Delete Coupon
+// @Description Delete a coupon that has never been used (admin only). Used coupons can be deactivated instead.
+// @Tags Coupons
+// @Param id path string true "Coupon ID"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} api_errors.ApiError
+// @Failure 404 {object} api_errors.ApiError
+// @Failure 409 {object} api_errors.ApiError "Coupon has been used"
+// @Failure 500 {object} api_errors.ApiError
+// @Security BearerAuth
+// @Router /admin/coupons/{id} [delete]
+func (s *Server) DeleteCoupon(c *gin.Context) {
+	couponID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid coupon ID"})
+		return
+	}
+
+	deleted, err := s.queries.DeleteCoupon(context.Background(), couponID)
+	if err != nil {
+		dbError(c, err)
+		return
+	}
+	if deleted == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Coupon not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Coupon deleted successfully"})
+}
@@ -0,0 +1,87 @@
+package api_errors
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+)
+
+// constraintViolation is the client-facing response for a violated
+// database constraint.
+type constraintViolation struct {
+	status  int
+	message string
+}
+
+// constraintViolations maps schema constraint names to responses. Foreign
+// keys are described from the inserting side; deletes blocked by a
+// reference are reported generically by dbErrorResponse.
+var constraintViolations = map[string]constraintViolation{
+	"users_email_key":                   {http.StatusBadRequest, "Email already exists"},
+	"users_username_key":                {http.StatusBadRequest, "Username already exists"},
+	"products_sku_key":                  {http.StatusConflict, "Product SKU already exists"},
+	"products_stock_check":              {http.StatusBadRequest, "Stock cannot be negative"},
+	"categories_slug_key":               {http.StatusConflict, "Category slug already exists"},
+	"categories_parent_id_fkey":         {http.StatusConflict, "Category still has subcategories"},
+	"product_variants_sku_key":          {http.StatusConflict, "Variant SKU already exists"},
+	"product_variants_stock_check":      {http.StatusBadRequest, "Stock cannot be negative"},
+	"order_items_variant_id_fkey":       {http.StatusConflict, "Variant has been ordered and cannot be deleted"},
+	"order_items_product_id_fkey":       {http.StatusConflict, "Product has been ordered and cannot be deleted"},
+	"order_items_order_id_fkey":         {http.StatusNotFound, "Order not found"},
+	"order_items_quantity_check":        {http.StatusBadRequest, "Quantity must be greater than zero"},
+	"orders_user_id_fkey":               {http.StatusNotFound, "User not found"},
+	"sessions_user_id_fkey":             {http.StatusNotFound, "User not found"},
+	"sessions_token_key":                {http.StatusConflict, "Session token already exists"},
+	"cart_items_product_id_fkey":        {http.StatusNotFound, "Product not found"},
+	"cart_items_quantity_check":         {http.StatusBadRequest, "Quantity must be greater than zero"},
+	"carts_user_id_fkey":                {http.StatusNotFound, "User not found"},
+	"payments_order_id_fkey":            {http.StatusConflict, "Order has payments and cannot be deleted"},
+	"payments_provider_transaction_key": {http.StatusConflict, "Payment transaction already recorded"},
+	"return_items_order_item_id_fkey":   {http.StatusBadRequest, "Order item not found"},
+	"returns_order_id_fkey":             {http.StatusConflict, "Order has returns and cannot be deleted"},
+	"refunds_payment_id_fkey":           {http.StatusConflict, "Payment has refunds and cannot be deleted"},
+	"coupons_code_key":                  {http.StatusConflict, "Coupon code already exists"},
+	"coupons_percentage_check":          {http.StatusBadRequest, "Percentage discount cannot exceed 100"},
+	"coupons_validity_check":            {http.StatusBadRequest, "Coupon must end after it starts"},
+	"coupon_redemptions_coupon_id_fkey": {http.StatusConflict, "Coupon has been used and cannot be deleted; deactivate it instead"},
+}
+
+// dbErrorResponse returns the status and message for a constraint
+// violation, and false for any other error.
+func dbErrorResponse(err error) (int, string, bool) {
+	var pgErr *pq.Error
+	if !errors.As(err, &pgErr) {
+		return 0, "", false
+	}
+	if violation, ok := constraintViolations[pgErr.Constraint]; ok {
+		return violation.status, violation.message, true
+	}
+
+	switch pgErr.Code.Name() {
+	case "unique_violation":
+		return http.StatusConflict, "Resource already exists", true
+	case "foreign_key_violation":
+		if strings.HasPrefix(pgErr.Message, "update or delete") {
+			return http.StatusConflict, "Resource is still referenced and cannot be deleted", true
+		}
+		return http.StatusBadRequest, "Referenced resource does not exist", true
+	case "check_violation":
+		return http.StatusBadRequest, "Invalid value", true
+	case "not_null_violation":
+		return http.StatusBadRequest, pgErr.Column + " is required", true
+	}
+	return 0, "", false
+}
+
+// dbError writes the response for a failed database call, turning
+// constraint violations into client errors and anything else into a 500.
+func dbError(c *gin.Context, err error) {
+	if status, message, ok := dbErrorResponse(err); ok {
+		c.JSON(status, gin.H{"error": message})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
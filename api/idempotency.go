@@ -0,0 +1,154 @@
+package api_errors
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"time"
+
+	db "github.com/adedaryorh/ecommerceapi/db/sqlc"
+	"github.com/adedaryorh/ecommerceapi/payment"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	IdempotencyKeyHeader      = "Idempotency-Key"
+	IdempotencyReplayedHeader = "Idempotent-Replayed"
+
+	maxIdempotencyKeyLength = 255
+	// idempotencyClaimTimeout is how long a request may hold its key before
+	// a retry with the same body may take it over, e.g. after a crash. A
+	// payment makes up to three provider calls (authorize, capture and a
+	// void), so a request still waiting on them must keep its key.
+	idempotencyClaimTimeout = 3*payment.RequestTimeout + time.Minute
+)
+
+// idempotencyWriter keeps a copy of the response body so it can be stored
+// for replays.
+type idempotencyWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *idempotencyWriter) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *idempotencyWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// IdempotencyMiddleware makes a request safe to retry when the client sends
+// an Idempotency-Key header. The first response for a key is stored and
+// replayed for later requests with the same key and body; reusing the key
+// with a different request is rejected. Server errors are not stored, so
+// the request can be retried. Requests without the header pass through.
+// It must run after AuthenticatedMiddleware, as keys are scoped per user.
+func (s *Server) IdempotencyMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		if key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Idempotency-Key must be at most 255 characters"})
+			c.Abort()
+			return
+		}
+		userID, ok := authenticatedUserID(c)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			c.Abort()
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Could not read request body"})
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		hash := requestHash(c.Request.Method, c.Request.URL.Path, body)
+
+		ctx := context.Background()
+		claimed, err := s.queries.ClaimIdempotencyKey(ctx, db.ClaimIdempotencyKeyParams{
+			UserID:      userID,
+			Key:         key,
+			RequestHash: hash,
+			StaleBefore: time.Now().Add(-idempotencyClaimTimeout),
+		})
+		if err != nil {
+			dbError(c, err)
+			c.Abort()
+			return
+		}
+		if claimed == 0 {
+			s.replayIdempotentRequest(c, userID, key, hash)
+			c.Abort()
+			return
+		}
+
+		writer := &idempotencyWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+		c.Next()
+
+		status := writer.Status()
+		if status >= http.StatusInternalServerError {
+			err = s.queries.DeleteIdempotencyKey(ctx, db.DeleteIdempotencyKeyParams{UserID: userID, Key: key})
+		} else {
+			err = s.queries.CompleteIdempotencyKey(ctx, db.CompleteIdempotencyKeyParams{
+				StatusCode:   sql.NullInt32{Int32: int32(status), Valid: true},
+				ResponseBody: writer.body.Bytes(),
+				UserID:       userID,
+				Key:          key,
+			})
+		}
+		if err != nil {
+			// The response has already been sent; a retry will get 409 until
+			// the claim times out.
+			c.Error(err)
+		}
+	}
+}
+
+// replayIdempotentRequest answers a request whose key was already claimed.
+func (s *Server) replayIdempotentRequest(c *gin.Context, userID int64, key, hash string) {
+	stored, err := s.queries.GetIdempotencyKey(context.Background(), db.GetIdempotencyKeyParams{UserID: userID, Key: key})
+	if err == sql.ErrNoRows {
+		// The first request failed and released the key in the meantime.
+		c.JSON(http.StatusConflict, gin.H{"error": "A request with this Idempotency-Key is being processed, please retry"})
+		return
+	} else if err != nil {
+		dbError(c, err)
+		return
+	}
+
+	switch {
+	case stored.RequestHash != hash:
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Idempotency-Key was already used for a different request"})
+	case !stored.StatusCode.Valid:
+		c.JSON(http.StatusConflict, gin.H{"error": "A request with this Idempotency-Key is being processed, please retry"})
+	default:
+		c.Header(IdempotencyReplayedHeader, "true")
+		c.Data(int(stored.StatusCode.Int32), "application/json; charset=utf-8", stored.ResponseBody)
+	}
+}
+
+// requestHash identifies a request by its method, path and body.
+func requestHash(method, path string, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(method))
+	h.Write([]byte{0})
+	h.Write([]byte(path))
+	h.Write([]byte{0})
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,130 @@
+package api_errors
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	db "github.com/adedaryorh/ecommerceapi/db/sqlc"
+	"github.com/adedaryorh/ecommerceapi/utils"
+	"github.com/gin-gonic/gin"
+	_ "github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+var testQueries *db.Queries
+
+func TestMain(m *testing.M) {
+	config, err := utils.LoadConfig("..")
+	if err != nil {
+		log.Fatal("cannot load config file")
+	}
+	conn, err := sql.Open(config.DBdriver, config.DB_source)
+	if err != nil {
+		log.Fatal("error connecting to postgres:", err)
+	}
+
+	testQueries = db.New(conn)
+	gin.SetMode(gin.TestMode)
+	os.Exit(m.Run())
+}
+
+// idempotentRouter serves handler at POST /things behind the idempotency
+// middleware, as user.
+func idempotentRouter(user db.User, handler gin.HandlerFunc) *gin.Engine {
+	s := &Server{queries: testQueries}
+	router := gin.New()
+	router.POST("/things", func(c *gin.Context) {
+		c.Set("user_id", user.ID)
+	}, s.IdempotencyMiddleware(), handler)
+	return router
+}
+
+func sendIdempotent(router http.Handler, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/things", strings.NewReader(body))
+	req.Header.Set(IdempotencyKeyHeader, key)
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	return recorder
+}
+
+func TestIdempotencyMiddleware(t *testing.T) {
+	user, err := testQueries.CreateUser(context.Background(), db.CreateUserParams{
+		Email:          utils.RandomEmail(),
+		HashedPassword: "secret",
+		Username:       utils.RandomString(8),
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer testQueries.DeleteUsers(context.Background(), []int64{user.ID})
+
+	calls := 0
+	status := http.StatusCreated
+	started := make(chan struct{})
+	release := make(chan struct{})
+	router := idempotentRouter(user, func(c *gin.Context) {
+		calls++
+		if c.Query("block") != "" {
+			close(started)
+			<-release
+		}
+		c.JSON(status, gin.H{"call": calls})
+	})
+
+	key := utils.RandomString(16)
+	first := sendIdempotent(router, key, `{"n":1}`)
+	assert.Equal(t, http.StatusCreated, first.Code)
+	assert.Empty(t, first.Header().Get(IdempotencyReplayedHeader))
+
+	// The same request is answered with the stored status and body.
+	replay := sendIdempotent(router, key, `{"n":1}`)
+	assert.Equal(t, http.StatusCreated, replay.Code)
+	assert.Equal(t, "true", replay.Header().Get(IdempotencyReplayedHeader))
+	assert.JSONEq(t, first.Body.String(), replay.Body.String())
+	assert.Equal(t, 1, calls)
+
+	// Reusing the key for another request is refused.
+	other := sendIdempotent(router, key, `{"n":2}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, other.Code)
+	assert.Equal(t, 1, calls)
+
+	// A server error releases the key so the request can be retried.
+	status = http.StatusInternalServerError
+	failedKey := utils.RandomString(16)
+	failed := sendIdempotent(router, failedKey, `{"n":3}`)
+	assert.Equal(t, http.StatusInternalServerError, failed.Code)
+	status = http.StatusCreated
+	retried := sendIdempotent(router, failedKey, `{"n":3}`)
+	assert.Equal(t, http.StatusCreated, retried.Code)
+	assert.Empty(t, retried.Header().Get(IdempotencyReplayedHeader))
+	assert.Equal(t, 3, calls)
+
+	// While the first request with a key is running, a retry must wait.
+	blockedKey := utils.RandomString(16)
+	done := make(chan *httptest.ResponseRecorder)
+	go func() {
+		req := httptest.NewRequest(http.MethodPost, "/things?block=1", strings.NewReader(`{"n":4}`))
+		req.Header.Set(IdempotencyKeyHeader, blockedKey)
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, req)
+		done <- recorder
+	}()
+	select {
+	case <-started:
+	case recorder := <-done:
+		t.Fatalf("blocking request finished early with status %d", recorder.Code)
+	}
+	inFlight := httptest.NewRequest(http.MethodPost, "/things?block=1", strings.NewReader(`{"n":4}`))
+	inFlight.Header.Set(IdempotencyKeyHeader, blockedKey)
+	waiting := httptest.NewRecorder()
+	router.ServeHTTP(waiting, inFlight)
+	assert.Equal(t, http.StatusConflict, waiting.Code)
+	close(release)
+	assert.Equal(t, http.StatusCreated, (<-done).Code)
+}
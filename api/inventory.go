@@ -0,0 +1,188 @@
+package api_errors
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	db "github.com/adedaryorh/ecommerceapi/db/sqlc"
+	"github.com/gin-gonic/gin"
+)
+
+// StockAdjustmentParams defines a manual stock change. Quantity is signed:
+// positive values add stock, negative values remove it.
+type StockAdjustmentParams struct {
+	Quantity  int32  `json:"quantity" binding:"required"`
+	Kind      string `json:"kind" binding:"omitempty,oneof=receipt adjustment"`
+	Reason    string `json:"reason" binding:"required"`
+	VariantID *int64 `json:"variant_id"`
+}
+
+// InventoryMovementResponse defines one entry of a product's stock ledger.
+// Balance is the stock of the product, or of the variant, after the movement.
+type InventoryMovementResponse struct {
+	ID        int64     `json:"id"`
+	ProductID int64     `json:"product_id"`
+	VariantID *int64    `json:"variant_id,omitempty"`
+	Kind      string    `json:"kind"`
+	Quantity  int32     `json:"quantity"`
+	Balance   int32     `json:"balance"`
+	Reason    string    `json:"reason,omitempty"`
+	OrderID   *int64    `json:"order_id,omitempty"`
+	CreatedBy *int64    `json:"created_by,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (m InventoryMovementResponse) toInventoryMovementResponse(movement *db.InventoryMovement) InventoryMovementResponse {
+	response := InventoryMovementResponse{
+		ID:        movement.ID,
+		ProductID: movement.ProductID,
+		Kind:      movement.Kind,
+		Quantity:  movement.Quantity,
+		Balance:   movement.Balance,
+		Reason:    movement.Reason.String,
+		CreatedAt: movement.CreatedAt,
+	}
+	if movement.VariantID.Valid {
+		response.VariantID = &movement.VariantID.Int64
+	}
+	if movement.OrderID.Valid {
+		response.OrderID = &movement.OrderID.Int64
+	}
+	if movement.CreatedBy.Valid {
+		response.CreatedBy = &movement.CreatedBy.Int64
+	}
+	return response
+}
+
+// @Summary Adjust Stock
+// @Description Add or remove product or variant stock with a reason, recorded in the inventory ledger (admin only)
+// @Tags Inventory
+// @Accept json
+// @Produce json
+// @Param id path string true "Product ID"
+// @Param adjustment body StockAdjustmentParams true "Stock change"
+// @Success 201 {object} api_errors.InventoryMovementResponse
+// @Failure 400 {object} api_errors.ApiError
+// @Failure 404 {object} api_errors.ApiError
+// @Failure 409 {object} api_errors.ApiError "Stock would become negative"
+// @Failure 500 {object} api_errors.ApiError
+// @Security BearerAuth
+// @Router /products/{id}/inventory [post]
+func (p *Product) adjustStock(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+
+	var params StockAdjustmentParams
+	if err := c.ShouldBindJSON(&params); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	kind := db.MovementAdjustment
+	if params.Kind != "" {
+		kind = db.MovementKind(params.Kind)
+	}
+	if kind == db.MovementReceipt && params.Quantity < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A receipt must add stock"})
+		return
+	}
+
+	arg := db.AdjustStockTxParams{
+		ProductID: id,
+		Kind:      kind,
+		Quantity:  params.Quantity,
+		Reason:    sql.NullString{String: params.Reason, Valid: true},
+		CreatedBy: actingUser(c),
+	}
+	if params.VariantID != nil {
+		arg.VariantID = sql.NullInt64{Int64: *params.VariantID, Valid: true}
+	}
+
+	movement, err := p.server.store.AdjustStockTx(context.Background(), arg)
+	if err != nil {
+		var notFound *db.ProductNotFoundError
+		var variantNotFound *db.VariantNotFoundError
+		var noStock *db.InsufficientStockError
+		switch {
+		case errors.As(err, &notFound), errors.As(err, &variantNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.As(err, &noStock):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			dbError(c, err)
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, InventoryMovementResponse{}.toInventoryMovementResponse(&movement))
+}
+
+// @Summary List Inventory Movements
+// @Description Retrieve the stock movement history of a product, newest first (admin only)
+// @Tags Inventory
+// @Produce json
+// @Param id path string true "Product ID"
+// @Param variant_id query int false "Only movements of this variant"
+// @Param limit query int false "Number of movements to retrieve" default(50)
+// @Param offset query int false "Offset for pagination" default(0)
+// @Success 200 {array} api_errors.InventoryMovementResponse
+// @Failure 400 {object} api_errors.ApiError
+// @Failure 404 {object} api_errors.ApiError
+// @Failure 500 {object} api_errors.ApiError
+// @Security BearerAuth
+// @Router /products/{id}/inventory [get]
+func (p *Product) listInventoryMovements(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+
+	arg := db.ListInventoryMovementsParams{
+		ProductID:  id,
+		PageLimit:  50,
+		PageOffset: 0,
+	}
+	if l, err := strconv.Atoi(c.Query("limit")); err == nil && l > 0 {
+		arg.PageLimit = int32(l)
+	}
+	if o, err := strconv.Atoi(c.Query("offset")); err == nil && o >= 0 {
+		arg.PageOffset = int32(o)
+	}
+	if v := c.Query("variant_id"); v != "" {
+		variantID, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid variant ID"})
+			return
+		}
+		arg.VariantID = sql.NullInt64{Int64: variantID, Valid: true}
+	}
+
+	// Archived products keep their history.
+	_, err = p.server.queries.GetProductByIDWithArchived(context.Background(), id)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	movements, err := p.server.queries.ListInventoryMovements(context.Background(), arg)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	response := []InventoryMovementResponse{}
+	for _, movement := range movements {
+		response = append(response, InventoryMovementResponse{}.toInventoryMovementResponse(&movement))
+	}
+	c.JSON(http.StatusOK, response)
+}
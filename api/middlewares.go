@@ -2,6 +2,7 @@ package api_errors
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 	"net/http"
 	"strings"
@@ -40,6 +41,19 @@ func (s *Server) AuthenticatedMiddleware() gin.HandlerFunc {
 
 		fmt.Println("User ID from token:", userId)
 		fmt.Println("User Role from token:", role)
+
+		// Tokens outlive archiving, so check the account is still active.
+		_, err = s.queries.GetUserByID(context.Background(), userId)
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusUnauthorized, gin.H{"message": "account is no longer active"})
+			c.Abort()
+			return
+		} else if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+			c.Abort()
+			return
+		}
+
 		c.Set("user_id", userId)
 		c.Set("role", role)
 
@@ -78,3 +92,31 @@ func RoleBasedMiddleware(server *Server, role string) gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// authenticatedUserID returns the user ID set by AuthenticatedMiddleware.
+func authenticatedUserID(c *gin.Context) (int64, bool) {
+	value, exists := c.Get("user_id")
+	if !exists {
+		return 0, false
+	}
+	userID, ok := value.(int64)
+	return userID, ok
+}
+
+// actingUser returns the authenticated user for audit columns such as
+// created_by, or an invalid value for anonymous requests.
+func actingUser(c *gin.Context) sql.NullInt64 {
+	userID, ok := authenticatedUserID(c)
+	return sql.NullInt64{Int64: userID, Valid: ok}
+}
+
+// isAdmin reports whether the given user currently holds the admin role.
+func (s *Server) isAdmin(userID int64) (bool, error) {
+	user, err := s.queries.GetUserByID(context.Background(), userID)
+	if err == sql.ErrNoRows {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	return user.Role == "admin", nil
+}
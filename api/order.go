@@ -2,65 +2,298 @@ package api_errors
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+	"fmt"
 	db "github.com/adedaryorh/ecommerceapi/db/sqlc"
+	"github.com/adedaryorh/ecommerceapi/tax"
+	"github.com/adedaryorh/ecommerceapi/utils"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
+// OrderItemParams defines a single line of an order request.
+type OrderItemParams struct {
+	ProductID int64  `json:"product_id" binding:"required"`
+	VariantID *int64 `json:"variant_id"`
+	Quantity  int32  `json:"quantity" binding:"required,gt=0"`
+}
+
+// AddressParams defines where an order is taxed, as ISO country and
+// subdivision codes such as "US" and "CA".
+type AddressParams struct {
+	Country string `json:"country" binding:"omitempty,len=2"`
+	State   string `json:"state" binding:"omitempty,max=10"`
+}
+
+func (a AddressParams) taxAddress() tax.Address {
+	return tax.Address{Country: a.Country, State: a.State}.Normalize()
+}
+
+// OrderParams defines the expected input for placing an order.
+// Prices and totals are always computed from the catalog.
+type OrderParams struct {
+	OrderItems []OrderItemParams `json:"order_items" binding:"required,min=1,dive"`
+	CouponCode string            `json:"coupon_code"`
+	Address    AddressParams     `json:"address"`
+}
+
+// Converts OrderParams to the arguments for placing the order.
+func (s *Server) placeOrderArgs(userID int64, orderParams OrderParams) db.PlaceOrderTxParams {
+	arg := db.PlaceOrderTxParams{
+		UserID:         userID,
+		Items:          []db.PlaceOrderItem{},
+		ReservationTTL: s.config.Reservation_ttl,
+		CouponCode:     orderParams.CouponCode,
+		Tax:            s.taxes,
+		TaxAddress:     orderParams.Address.taxAddress(),
+	}
+	for _, item := range orderParams.OrderItems {
+		orderItem := db.PlaceOrderItem{
+			ProductID: item.ProductID,
+			Quantity:  item.Quantity,
+		}
+		if item.VariantID != nil {
+			orderItem.VariantID = sql.NullInt64{Int64: *item.VariantID, Valid: true}
+		}
+		arg.Items = append(arg.Items, orderItem)
+	}
+	return arg
+}
+
+// OrderItemResponse defines a purchased line with the product snapshot taken at order time.
+type OrderItemResponse struct {
+	ID          int64  `json:"id"`
+	ProductID   int64  `json:"product_id"`
+	VariantID   *int64 `json:"variant_id,omitempty"`
+	ProductName string `json:"product_name"`
+	UnitPrice   string `json:"unit_price"`
+	Quantity    int32  `json:"quantity"`
+	LineTotal   string `json:"line_total"`
+}
+
+// TaxLineResponse defines the tax charged on one product of an order.
+type TaxLineResponse struct {
+	ProductID    int64  `json:"product_id"`
+	TaxClass     string `json:"tax_class"`
+	Jurisdiction string `json:"jurisdiction"`
+	Rate         string `json:"rate"`
+	Taxable      string `json:"taxable"`
+	Amount       string `json:"amount"`
+}
+
+func toTaxLineResponses(lines []db.OrderTaxLine) []TaxLineResponse {
+	responses := []TaxLineResponse{}
+	for _, line := range lines {
+		responses = append(responses, TaxLineResponse{
+			ProductID:    line.ProductID,
+			TaxClass:     line.TaxClass,
+			Jurisdiction: line.Jurisdiction,
+			Rate:         line.Rate,
+			Taxable:      line.TaxableAmount,
+			Amount:       line.Amount,
+		})
+	}
+	return responses
+}
+
+// OrderResponse defines the response structure for order data.
+// Items and Subtotal are only populated when the line items were loaded,
+// and TaxLines when the tax lines were.
+type OrderResponse struct {
+	ID         int64               `json:"id"`
+	UserID     int64               `json:"user_id"`
+	Status     string              `json:"status"`
+	Items      []OrderItemResponse `json:"items,omitempty"`
+	Subtotal   string              `json:"subtotal,omitempty"`
+	CouponCode *string             `json:"coupon_code,omitempty"`
+	Discount   string              `json:"discount"`
+	Tax        string              `json:"tax"`
+	TaxLines   []TaxLineResponse   `json:"tax_lines,omitempty"`
+	Total      string              `json:"total"`
+	CreatedAt  time.Time           `json:"created_at"`
+	UpdatedAt  time.Time           `json:"updated_at"`
+}
+
+// Converts a db.Order and, optionally, its items to an OrderResponse.
+func (o OrderResponse) toOrderResponse(order *db.Order, items []db.OrderItem) (OrderResponse, error) {
+	response := OrderResponse{
+		ID:        order.ID,
+		UserID:    order.UserID,
+		Status:    order.Status,
+		Discount:  order.DiscountAmount,
+		Tax:       order.TaxAmount,
+		Total:     order.TotalAmount,
+		CreatedAt: order.CreatedAt,
+		UpdatedAt: order.UpdatedAt,
+	}
+	if order.CouponCode.Valid {
+		response.CouponCode = &order.CouponCode.String
+	}
+	if items == nil {
+		return response, nil
+	}
+
+	var subtotal int64
+	response.Items = []OrderItemResponse{}
+	for _, item := range items {
+		price, err := utils.ParseCents(item.Price)
+		if err != nil {
+			return response, err
+		}
+		lineTotal := price * int64(item.Quantity)
+		subtotal += lineTotal
+
+		itemResponse := OrderItemResponse{
+			ID:          item.ID,
+			ProductID:   item.ProductID,
+			ProductName: item.ProductName,
+			UnitPrice:   item.Price,
+			Quantity:    item.Quantity,
+			LineTotal:   utils.FormatCents(lineTotal),
+		}
+		if item.VariantID.Valid {
+			itemResponse.VariantID = &item.VariantID.Int64
+		}
+		response.Items = append(response.Items, itemResponse)
+	}
+	response.Subtotal = utils.FormatCents(subtotal)
+
+	return response, nil
+}
+
 // @Summary Create Order
-// @Description Create a new order with order items
+// @Description Place an order for the authenticated user. Prices come from the catalog and stock is reserved atomically.
 // @Tags Orders
 // @Accept json
 // @Produce json
-// @Param order body object true "Order Creation Details"
-// @Success 201 {object} db.Order "Successfully created order" // Corrected reference
+// @Param order body OrderParams true "Order Creation Details"
+// @Param Idempotency-Key header string false "Key that makes retries of this request safe"
+// @Success 201 {object} OrderResponse "Successfully created order"
 // @Failure 400 {object} api_errors.ApiError "Bad Request"
+// @Failure 401 {object} api_errors.ApiError "Unauthorized"
+// @Failure 404 {object} api_errors.ApiError "Product, variant or coupon not found"
+// @Failure 409 {object} api_errors.ApiError "Insufficient stock"
+// @Failure 422 {object} api_errors.ApiError "Coupon does not apply, or Idempotency-Key reused for a different request"
 // @Failure 500 {object} api_errors.ApiError "Internal Server Error"
 // @Security BearerAuth
 // @Router /orders [post]
 func (s *Server) CreateOrder(c *gin.Context) {
-	var orderParams struct {
-		UserID      int64  `json:"user_id"`
-		TotalAmount string `json:"total_amount"`
-		Status      string `json:"status"`
-		OrderItems  []struct {
-			ProductID int64  `json:"product_id"`
-			Quantity  int32  `json:"quantity"`
-			Price     string `json:"price"`
-		} `json:"order_items"`
+	userID, ok := authenticatedUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
 	}
 
+	var orderParams OrderParams
 	if err := c.ShouldBindJSON(&orderParams); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	order, err := s.queries.CreateOrder(context.Background(), db.CreateOrderParams{
-		UserID:      orderParams.UserID,
-		TotalAmount: orderParams.TotalAmount,
-		Status:      orderParams.Status,
-	})
+	result, err := s.store.PlaceOrderTx(context.Background(), s.placeOrderArgs(userID, orderParams))
+	if err != nil {
+		s.placeOrderError(c, err)
+		return
+	}
+
+	response, err := OrderResponse{}.toOrderResponse(&result.Order, result.OrderItems)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	response.TaxLines = toTaxLineResponses(result.TaxLines)
 
-	for _, item := range orderParams.OrderItems {
-		_, err := s.queries.AddOrderItem(context.Background(), db.AddOrderItemParams{
-			OrderID:   order.ID,
-			ProductID: item.ProductID,
-			Quantity:  item.Quantity,
-			Price:     item.Price,
-		})
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			return
-		}
+	c.JSON(http.StatusCreated, response)
+}
+
+// QuoteResponse defines what a prospective order would cost.
+type QuoteResponse struct {
+	Items      []OrderItemResponse `json:"items"`
+	Subtotal   string              `json:"subtotal"`
+	CouponCode *string             `json:"coupon_code,omitempty"`
+	Discount   string              `json:"discount"`
+	Tax        string              `json:"tax"`
+	TaxLines   []TaxLineResponse   `json:"tax_lines"`
+	Total      string              `json:"total"`
+}
+
+// @Summary Quote Order
+// @Description Price a prospective order for the authenticated user, including coupon discount and tax, without creating it. The same stock and coupon checks as placing the order apply.
+// @Tags Orders
+// @Accept json
+// @Produce json
+// @Param order body OrderParams true "Prospective Order Details"
+// @Success 200 {object} QuoteResponse "Order quote"
+// @Failure 400 {object} api_errors.ApiError "Bad Request or missing tax address"
+// @Failure 401 {object} api_errors.ApiError "Unauthorized"
+// @Failure 404 {object} api_errors.ApiError "Product, variant or coupon not found"
+// @Failure 409 {object} api_errors.ApiError "Insufficient stock"
+// @Failure 422 {object} api_errors.ApiError "Coupon does not apply"
+// @Failure 500 {object} api_errors.ApiError "Internal Server Error"
+// @Security BearerAuth
+// @Router /orders/quote [post]
+func (s *Server) QuoteOrder(c *gin.Context) {
+	userID, ok := authenticatedUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var orderParams OrderParams
+	if err := c.ShouldBindJSON(&orderParams); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := s.store.QuoteOrderTx(context.Background(), s.placeOrderArgs(userID, orderParams))
+	if err != nil {
+		s.placeOrderError(c, err)
+		return
 	}
 
-	c.JSON(http.StatusCreated, order)
+	order, err := OrderResponse{}.toOrderResponse(&result.Order, result.OrderItems)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	// The quoted order was rolled back, so its item IDs were never kept.
+	for i := range order.Items {
+		order.Items[i].ID = 0
+	}
+
+	c.JSON(http.StatusOK, QuoteResponse{
+		Items:      order.Items,
+		Subtotal:   order.Subtotal,
+		CouponCode: order.CouponCode,
+		Discount:   order.Discount,
+		Tax:        order.Tax,
+		TaxLines:   toTaxLineResponses(result.TaxLines),
+		Total:      order.Total,
+	})
+}
+
+// placeOrderError maps order placement failures to HTTP responses.
+func (s *Server) placeOrderError(c *gin.Context, err error) {
+	var notFound *db.ProductNotFoundError
+	var variantNotFound *db.VariantNotFoundError
+	var noStock *db.InsufficientStockError
+	var couponNotApplicable *db.CouponNotApplicableError
+	switch {
+	case errors.As(err, &notFound), errors.As(err, &variantNotFound), errors.Is(err, db.ErrCouponNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.As(err, &noStock):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.As(err, &couponNotApplicable):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+	case errors.Is(err, db.ErrEmptyOrder), errors.Is(err, tax.ErrAddressRequired):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		dbError(c, err)
+	}
 }
 
 // @Summary List User Orders
@@ -69,7 +302,7 @@ func (s *Server) CreateOrder(c *gin.Context) {
 // @Produce json
 // @Param limit query int false "Number of orders to retrieve" default(10)
 // @Param offset query int false "Offset for pagination" default(0)
-// @Success 200 {array} db.Order "List of orders" // Corrected reference for array of db.Order
+// @Success 200 {array} OrderResponse "List of orders"
 // @Failure 401 {object} api_errors.ApiError "Unauthorized"
 // @Failure 500 {object} api_errors.ApiError "Internal Server Error"
 // @Security BearerAuth
@@ -104,17 +337,112 @@ func (s *Server) ListUserOrders(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, orders)
+	response := []OrderResponse{}
+	for _, order := range orders {
+		o, _ := OrderResponse{}.toOrderResponse(&order, nil)
+		response = append(response, o)
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// @Summary Get Order
+// @Description Retrieve an order with its line items and totals (owner or admin)
+// @Tags Orders
+// @Produce json
+// @Param id path string true "Order ID"
+// @Success 200 {object} OrderResponse
+// @Failure 400 {object} api_errors.ApiError "Bad Request"
+// @Failure 401 {object} api_errors.ApiError "Unauthorized"
+// @Failure 404 {object} api_errors.ApiError "Order not found"
+// @Failure 500 {object} api_errors.ApiError "Internal Server Error"
+// @Security BearerAuth
+// @Router /orders/{id} [get]
+func (s *Server) GetOrder(c *gin.Context) {
+	userID, ok := authenticatedUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	orderID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid order ID"})
+		return
+	}
+
+	order, err := s.queries.GetOrderByID(context.Background(), orderID)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if order.UserID != userID {
+		admin, err := s.isAdmin(userID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		// Other users' orders are reported as missing rather than forbidden.
+		if !admin {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
+			return
+		}
+	}
+
+	items, err := s.queries.ListOrderItems(context.Background(), order.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	taxLines, err := s.queries.ListOrderTaxLines(context.Background(), order.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	response, err := OrderResponse{}.toOrderResponse(&order, items)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	response.TaxLines = toTaxLineResponses(taxLines)
+
+	c.JSON(http.StatusOK, response)
+}
+
+// orderTransitionError maps order status change failures to HTTP responses.
+func (s *Server) orderTransitionError(c *gin.Context, err error) {
+	var transitionErr *db.InvalidTransitionError
+	var gatewayErr *gatewayError
+	switch {
+	case errors.Is(err, db.ErrOrderNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.As(err, &transitionErr), errors.Is(err, db.ErrOrderPaymentCaptured):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.As(err, &gatewayErr):
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+	default:
+		// Paying an order takes its reserved stock, which fails the stock
+		// check if stock was adjusted below the reservation in the meantime.
+		dbError(c, err)
+	}
 }
 
 // @Summary Cancel Order
-// @Description Cancel an order (admin only)
+// @Description Cancel an order (admin only) and return its items to stock. Only orders whose status allows cancellation can be cancelled. A paid order's payment is refunded through the payment provider first.
 // @Tags Orders
 // @Param id path string true "Order ID"
-// @Success 200 {object} api_errors.ProductResponse "Success response" // No need to change this if ProductResponse is correctly defined
+// @Success 200 {object} OrderResponse "Cancelled order"
 // @Failure 400 {object} api_errors.ApiError "Bad Request"
 // @Failure 403 {object} api_errors.ApiError "Forbidden"
+// @Failure 404 {object} api_errors.ApiError "Order not found"
+// @Failure 409 {object} api_errors.ApiError "Order cannot be cancelled"
 // @Failure 500 {object} api_errors.ApiError "Internal Server Error"
+// @Failure 502 {object} api_errors.ApiError "Payment provider failed to refund"
 // @Security BearerAuth
 // @Router /admin/orders/{id}/cancel [post]
 func (s *Server) CancelOrder(c *gin.Context) {
@@ -135,41 +463,108 @@ func (s *Server) CancelOrder(c *gin.Context) {
 		return
 	}
 
-	// Cancel the order
-	err = s.queries.CancelOrder(context.Background(), orderIDInt64)
+	// Cancel the order and return its items to stock
+	result, err := s.store.CancelOrderTx(context.Background(), db.CancelOrderTxParams{
+		OrderID:   orderIDInt64,
+		ChangedBy: sql.NullInt64{Int64: user.ID, Valid: true},
+		Refund:    s.refundThroughProvider(fmt.Sprintf("order-%d", orderIDInt64)),
+	})
+	if err != nil {
+		s.orderTransitionError(c, err)
+		return
+	}
+
+	response, err := OrderResponse{}.toOrderResponse(&result.Order, result.OrderItems)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Order cancelled successfully"})
+	c.JSON(http.StatusOK, response)
+}
+
+// @Summary Cancel My Order
+// @Description Cancel one of the authenticated user's pending orders and return its items to stock
+// @Tags Orders
+// @Produce json
+// @Param id path string true "Order ID"
+// @Success 200 {object} OrderResponse "Cancelled order"
+// @Failure 400 {object} api_errors.ApiError "Bad Request"
+// @Failure 401 {object} api_errors.ApiError "Unauthorized"
+// @Failure 404 {object} api_errors.ApiError "Order not found"
+// @Failure 409 {object} api_errors.ApiError "Order is no longer pending"
+// @Failure 500 {object} api_errors.ApiError "Internal Server Error"
+// @Security BearerAuth
+// @Router /orders/{id}/cancel [post]
+func (s *Server) CancelUserOrder(c *gin.Context) {
+	userID, ok := authenticatedUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	orderID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid order ID"})
+		return
+	}
+
+	result, err := s.store.CancelOrderTx(context.Background(), db.CancelOrderTxParams{
+		OrderID:   orderID,
+		OwnerID:   sql.NullInt64{Int64: userID, Valid: true},
+		ChangedBy: sql.NullInt64{Int64: userID, Valid: true},
+	})
+	if err != nil {
+		s.orderTransitionError(c, err)
+		return
+	}
+
+	response, err := OrderResponse{}.toOrderResponse(&result.Order, result.OrderItems)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// OrderStatusParams defines the expected input for changing an order's status.
+type OrderStatusParams struct {
+	Status string  `json:"status" binding:"required"`
+	Note   *string `json:"note"`
 }
 
 // @Summary Update Order Status
-// @Description Update the status of an order (admin only)
+// @Description Move an order to a new status (admin only). Allowed: Pending→Paid|Cancelled|Expired, Paid→Shipped|Cancelled|Refunded, Shipped→Delivered, Delivered→Refunded. Cancelling or refunding refunds whatever the order's payment still holds through the payment provider.
 // @Tags Orders
 // @Accept json
 // @Produce json
 // @Param id path string true "Order ID"
-// @Param status body object true "New Order Status"
-// @Success 200 {object} db.Order "Updated order status" // Corrected reference
+// @Param status body OrderStatusParams true "New Order Status"
+// @Success 200 {object} OrderResponse "Updated order"
 // @Failure 400 {object} api_errors.ApiError "Bad Request"
 // @Failure 403 {object} api_errors.ApiError "Forbidden"
+// @Failure 404 {object} api_errors.ApiError "Order not found"
+// @Failure 409 {object} api_errors.ApiError "Illegal status transition"
 // @Failure 500 {object} api_errors.ApiError "Internal Server Error"
+// @Failure 502 {object} api_errors.ApiError "Payment provider failed to refund"
 // @Security BearerAuth
 // @Router /admin/orders/{id}/status [patch]
 func (s *Server) UpdateOrderStatus(c *gin.Context) {
 	orderID := c.Param("id")
 
-	var statusUpdate struct {
-		Status string `json:"status"`
-	}
-
+	var statusUpdate OrderStatusParams
 	if err := c.ShouldBindJSON(&statusUpdate); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
+	status := db.OrderStatus(statusUpdate.Status)
+	if !status.Valid() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid order status"})
+		return
+	}
+
 	userID, _ := c.Get("user_id")
 	user, err := s.queries.GetUserByID(context.Background(), userID.(int64))
 	if err != nil || user.Role != "admin" {
@@ -183,13 +578,69 @@ func (s *Server) UpdateOrderStatus(c *gin.Context) {
 		return
 	}
 
-	order, err := s.queries.UpdateOrderStatus(context.Background(), db.UpdateOrderStatusParams{
-		Status: statusUpdate.Status,
-		ID:     orderIDInt64,
-	})
+	arg := db.TransitionOrderTxParams{
+		OrderID:   orderIDInt64,
+		Status:    status,
+		ChangedBy: sql.NullInt64{Int64: user.ID, Valid: true},
+		Refund:    s.refundThroughProvider(fmt.Sprintf("order-%d", orderIDInt64)),
+	}
+	if statusUpdate.Note != nil {
+		arg.Note = sql.NullString{String: *statusUpdate.Note, Valid: true}
+	}
+
+	result, err := s.store.TransitionOrderTx(context.Background(), arg)
+	if err != nil {
+		s.orderTransitionError(c, err)
+		return
+	}
+
+	items, err := s.queries.ListOrderItems(context.Background(), result.Order.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	response, err := OrderResponse{}.toOrderResponse(&result.Order, items)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
-	c.JSON(http.StatusOK, order)
+
+	c.JSON(http.StatusOK, response)
+}
+
+// @Summary Get Order Status History
+// @Description List every status change recorded for an order (admin only)
+// @Tags Orders
+// @Produce json
+// @Param id path string true "Order ID"
+// @Success 200 {array} db.OrderStatusHistory
+// @Failure 400 {object} api_errors.ApiError "Bad Request"
+// @Failure 404 {object} api_errors.ApiError "Order not found"
+// @Failure 500 {object} api_errors.ApiError "Internal Server Error"
+// @Security BearerAuth
+// @Router /admin/orders/{id}/history [get]
+func (s *Server) ListOrderStatusHistory(c *gin.Context) {
+	orderID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid order ID"})
+		return
+	}
+
+	_, err = s.queries.GetOrderByID(context.Background(), orderID)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	history, err := s.queries.ListOrderStatusHistory(context.Background(), orderID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, history)
 }
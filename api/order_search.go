@@ -0,0 +1,309 @@
+package api_errors
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	db "github.com/adedaryorh/ecommerceapi/db/sqlc"
+	"github.com/adedaryorh/ecommerceapi/utils"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultOrderPageSize = 20
+	maxOrderPageSize     = 100
+)
+
+// orderCursor is the keyset position of the last order on a page. It is
+// handed to clients as an opaque base64 string.
+type orderCursor struct {
+	SortBy      string    `json:"s"`
+	Descending  bool      `json:"d"`
+	ID          int64     `json:"id"`
+	CreatedAt   time.Time `json:"c,omitempty"`
+	TotalAmount string    `json:"t,omitempty"`
+}
+
+func (oc orderCursor) encode() string {
+	data, _ := json.Marshal(oc)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeOrderCursor(value string) (orderCursor, error) {
+	var oc orderCursor
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return oc, err
+	}
+	err = json.Unmarshal(data, &oc)
+	return oc, err
+}
+
+// OrderSearchResponse defines one page of admin order search results.
+type OrderSearchResponse struct {
+	Orders     []OrderResponse `json:"orders"`
+	NextCursor string          `json:"next_cursor,omitempty"`
+}
+
+// parseDateParam accepts either an RFC 3339 timestamp or a plain YYYY-MM-DD date.
+func parseDateParam(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", value)
+}
+
+// parseDateRange reads the optional from/to query parameters shared by the
+// search and summary endpoints. A date-only "to" includes that whole day.
+func parseDateRange(c *gin.Context) (from, to sql.NullTime, err error) {
+	if v := c.Query("from"); v != "" {
+		t, err := parseDateParam(v)
+		if err != nil {
+			return from, to, fmt.Errorf("invalid from date")
+		}
+		from = sql.NullTime{Time: t, Valid: true}
+	}
+	if v := c.Query("to"); v != "" {
+		t, err := parseDateParam(v)
+		if err != nil {
+			return from, to, fmt.Errorf("invalid to date")
+		}
+		if len(v) == len("2006-01-02") {
+			t = t.AddDate(0, 0, 1)
+		}
+		to = sql.NullTime{Time: t, Valid: true}
+	}
+	return from, to, nil
+}
+
+func parseAmountParam(c *gin.Context, name string) (sql.NullString, error) {
+	v := c.Query(name)
+	if v == "" {
+		return sql.NullString{}, nil
+	}
+	cents, err := utils.ParseCents(v)
+	if err != nil {
+		return sql.NullString{}, fmt.Errorf("invalid %s", name)
+	}
+	return sql.NullString{String: utils.FormatCents(cents), Valid: true}, nil
+}
+
+// @Summary Search Orders
+// @Description Search all orders with filters and keyset pagination (admin only)
+// @Tags Orders
+// @Produce json
+// @Param status query string false "Order status"
+// @Param user_id query int false "User ID"
+// @Param from query string false "Created at or after (RFC 3339 or YYYY-MM-DD)"
+// @Param to query string false "Created before (RFC 3339, or YYYY-MM-DD inclusive)"
+// @Param min_amount query string false "Minimum total amount"
+// @Param max_amount query string false "Maximum total amount"
+// @Param sort query string false "Sort field: created_at or total_amount" default(created_at)
+// @Param order query string false "Sort direction: asc or desc" default(desc)
+// @Param limit query int false "Page size (max 100)" default(20)
+// @Param cursor query string false "Cursor returned as next_cursor by the previous page"
+// @Success 200 {object} OrderSearchResponse
+// @Failure 400 {object} api_errors.ApiError
+// @Failure 403 {object} api_errors.ApiError
+// @Failure 500 {object} api_errors.ApiError
+// @Security BearerAuth
+// @Router /admin/orders [get]
+func (s *Server) SearchOrders(c *gin.Context) {
+	arg := db.SearchOrdersParams{
+		SortBy:     c.DefaultQuery("sort", "created_at"),
+		Descending: c.DefaultQuery("order", "desc") == "desc",
+		PageSize:   defaultOrderPageSize,
+	}
+	if arg.SortBy != "created_at" && arg.SortBy != "total_amount" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "sort must be created_at or total_amount"})
+		return
+	}
+	if o := c.DefaultQuery("order", "desc"); o != "asc" && o != "desc" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "order must be asc or desc"})
+		return
+	}
+
+	if v := c.Query("status"); v != "" {
+		if !db.OrderStatus(v).Valid() {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid order status"})
+			return
+		}
+		arg.Status = sql.NullString{String: v, Valid: true}
+	}
+	if v := c.Query("user_id"); v != "" {
+		userID, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+			return
+		}
+		arg.UserID = sql.NullInt64{Int64: userID, Valid: true}
+	}
+
+	var err error
+	arg.CreatedFrom, arg.CreatedTo, err = parseDateRange(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if arg.MinAmount, err = parseAmountParam(c, "min_amount"); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if arg.MaxAmount, err = parseAmountParam(c, "max_amount"); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if v := c.Query("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit <= 0 || limit > maxOrderPageSize {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("limit must be between 1 and %d", maxOrderPageSize)})
+			return
+		}
+		arg.PageSize = int32(limit)
+	}
+
+	if v := c.Query("cursor"); v != "" {
+		cursor, err := decodeOrderCursor(v)
+		if err != nil || cursor.SortBy != arg.SortBy || cursor.Descending != arg.Descending {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor"})
+			return
+		}
+		arg.CursorID = sql.NullInt64{Int64: cursor.ID, Valid: true}
+		arg.CursorCreatedAt = sql.NullTime{Time: cursor.CreatedAt, Valid: true}
+		arg.CursorAmount = sql.NullString{String: cursor.TotalAmount, Valid: cursor.TotalAmount != ""}
+	}
+
+	orders, err := s.queries.SearchOrders(context.Background(), arg)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	response := OrderSearchResponse{Orders: []OrderResponse{}}
+	for _, order := range orders {
+		o, _ := OrderResponse{}.toOrderResponse(&order, nil)
+		response.Orders = append(response.Orders, o)
+	}
+	if len(orders) == int(arg.PageSize) {
+		last := orders[len(orders)-1]
+		response.NextCursor = orderCursor{
+			SortBy:      arg.SortBy,
+			Descending:  arg.Descending,
+			ID:          last.ID,
+			CreatedAt:   last.CreatedAt,
+			TotalAmount: last.TotalAmount,
+		}.encode()
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// OrderStatusSummary aggregates orders sharing a status.
+type OrderStatusSummary struct {
+	Status     string `json:"status"`
+	OrderCount int64  `json:"order_count"`
+	Revenue    string `json:"revenue"`
+}
+
+// OrderDaySummary aggregates orders placed on one day, broken down by status.
+type OrderDaySummary struct {
+	Day        string               `json:"day"`
+	OrderCount int64                `json:"order_count"`
+	Revenue    string               `json:"revenue"`
+	ByStatus   []OrderStatusSummary `json:"by_status"`
+}
+
+// OrderSummaryResponse defines order counts and revenue grouped by status and day.
+type OrderSummaryResponse struct {
+	ByStatus []OrderStatusSummary `json:"by_status"`
+	ByDay    []OrderDaySummary    `json:"by_day"`
+}
+
+// @Summary Order Summary
+// @Description Order counts and revenue grouped by status and by day (admin only)
+// @Tags Orders
+// @Produce json
+// @Param from query string false "Created at or after (RFC 3339 or YYYY-MM-DD)"
+// @Param to query string false "Created before (RFC 3339, or YYYY-MM-DD inclusive)"
+// @Success 200 {object} OrderSummaryResponse
+// @Failure 400 {object} api_errors.ApiError
+// @Failure 403 {object} api_errors.ApiError
+// @Failure 500 {object} api_errors.ApiError
+// @Security BearerAuth
+// @Router /admin/orders/summary [get]
+func (s *Server) OrderSummary(c *gin.Context) {
+	from, to, err := parseDateRange(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rows, err := s.queries.OrderSummary(context.Background(), db.OrderSummaryParams{
+		CreatedFrom: from,
+		CreatedTo:   to,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	type totals struct {
+		count   int64
+		revenue int64
+	}
+	statusTotals := map[string]*totals{}
+	statuses := []string{}
+	var dayRevenue int64
+	response := OrderSummaryResponse{
+		ByStatus: []OrderStatusSummary{},
+		ByDay:    []OrderDaySummary{},
+	}
+
+	for _, row := range rows {
+		revenue, err := utils.ParseCents(row.Revenue)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		// Rows arrive ordered by day, so a new day always starts a new entry.
+		day := row.Day.Format("2006-01-02")
+		if n := len(response.ByDay); n == 0 || response.ByDay[n-1].Day != day {
+			response.ByDay = append(response.ByDay, OrderDaySummary{Day: day, ByStatus: []OrderStatusSummary{}})
+			dayRevenue = 0
+		}
+		dayRevenue += revenue
+		current := &response.ByDay[len(response.ByDay)-1]
+		current.OrderCount += row.OrderCount
+		current.Revenue = utils.FormatCents(dayRevenue)
+		current.ByStatus = append(current.ByStatus, OrderStatusSummary{
+			Status:     row.Status,
+			OrderCount: row.OrderCount,
+			Revenue:    row.Revenue,
+		})
+
+		if _, ok := statusTotals[row.Status]; !ok {
+			statusTotals[row.Status] = &totals{}
+			statuses = append(statuses, row.Status)
+		}
+		statusTotals[row.Status].count += row.OrderCount
+		statusTotals[row.Status].revenue += revenue
+	}
+
+	for _, status := range statuses {
+		response.ByStatus = append(response.ByStatus, OrderStatusSummary{
+			Status:     status,
+			OrderCount: statusTotals[status].count,
+			Revenue:    utils.FormatCents(statusTotals[status].revenue),
+		})
+	}
+
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,264 @@
+package api_errors
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	db "github.com/adedaryorh/ecommerceapi/db/sqlc"
+	"github.com/adedaryorh/ecommerceapi/payment"
+	"github.com/adedaryorh/ecommerceapi/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// PayOrderParams defines the payment method to charge. Token is the
+// provider's reference for a card or wallet collected by the client.
+type PayOrderParams struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// PaymentResponse defines a payment attempt recorded against an order.
+type PaymentResponse struct {
+	ID            int64     `json:"id"`
+	OrderID       int64     `json:"order_id"`
+	Provider      string    `json:"provider"`
+	TransactionID string    `json:"transaction_id"`
+	Amount        string    `json:"amount"`
+	Status        string    `json:"status"`
+	FailureReason *string   `json:"failure_reason,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+func (p PaymentResponse) toPaymentResponse(payment *db.Payment) PaymentResponse {
+	response := PaymentResponse{
+		ID:            payment.ID,
+		OrderID:       payment.OrderID,
+		Provider:      payment.Provider,
+		TransactionID: payment.ProviderTransactionID,
+		Amount:        payment.Amount,
+		Status:        payment.Status,
+		CreatedAt:     payment.CreatedAt,
+	}
+	if payment.FailureReason.Valid {
+		response.FailureReason = &payment.FailureReason.String
+	}
+	return response
+}
+
+// PayOrderResponse defines the paid order together with its payment.
+type PayOrderResponse struct {
+	Order OrderResponse `json:"order"`
+	// Payment is left out for an order with nothing to pay.
+	Payment *PaymentResponse `json:"payment,omitempty"`
+}
+
+// @Summary Pay Order
+// @Description Charge one of the authenticated user's pending orders and mark it Paid. Paying again with the same token returns the recorded payment without charging twice. An order whose discount leaves nothing to pay is marked Paid without charging the token.
+// @Tags Orders
+// @Accept json
+// @Produce json
+// @Param id path string true "Order ID"
+// @Param payment body PayOrderParams true "Payment method"
+// @Param Idempotency-Key header string false "Key that makes retries of this request safe"
+// @Success 201 {object} PayOrderResponse "Order paid"
+// @Success 200 {object} PayOrderResponse "Payment already recorded"
+// @Failure 400 {object} api_errors.ApiError
+// @Failure 402 {object} api_errors.ApiError "Payment declined"
+// @Failure 404 {object} api_errors.ApiError
+// @Failure 409 {object} api_errors.ApiError "Order cannot be paid"
+// @Failure 422 {object} api_errors.ApiError "Idempotency-Key reused for a different request"
+// @Failure 502 {object} api_errors.ApiError "Payment provider error"
+// @Failure 500 {object} api_errors.ApiError
+// @Security BearerAuth
+// @Router /orders/{id}/pay [post]
+func (s *Server) PayOrder(c *gin.Context) {
+	userID, ok := authenticatedUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	orderID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid order ID"})
+		return
+	}
+
+	var params PayOrderParams
+	if err := c.ShouldBindJSON(&params); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx := context.Background()
+	order, err := s.queries.GetOrderByID(ctx, orderID)
+	if err == sql.ErrNoRows || (err == nil && order.UserID != userID) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	// A paid order is let through so a retried payment is answered with
+	// the recorded one; anything else that is not pending cannot be paid.
+	status := db.OrderStatus(order.Status)
+	if status != db.OrderStatusPending && status != db.OrderStatusPaid {
+		c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("cannot pay an order that is %s", order.Status)})
+		return
+	}
+
+	amount, err := utils.ParseCents(order.TotalAmount)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if amount == 0 {
+		s.payFreeOrder(c, order, userID)
+		return
+	}
+
+	transaction, err := s.payments.Authorize(ctx, payment.AuthorizeRequest{
+		OrderID: order.ID,
+		Amount:  amount,
+		Token:   params.Token,
+	})
+	var declined *payment.DeclinedError
+	if errors.As(err, &declined) {
+		_, recordErr := s.queries.RecordPayment(ctx, db.RecordPaymentParams{
+			OrderID:               order.ID,
+			Provider:              s.payments.Name(),
+			ProviderTransactionID: declined.TransactionID,
+			Amount:                order.TotalAmount,
+			Status:                payment.StatusFailed,
+			FailureReason:         sql.NullString{String: declined.Reason, Valid: true},
+		})
+		if recordErr != nil {
+			log.Printf("failed to record declined payment for order %d: %v", order.ID, recordErr)
+		}
+		c.JSON(http.StatusPaymentRequired, gin.H{"error": err.Error()})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	}
+
+	transaction, err = s.payments.Capture(ctx, transaction.ID, amount)
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := s.store.PayOrderTx(ctx, db.PayOrderTxParams{
+		OrderID:               order.ID,
+		OwnerID:               sql.NullInt64{Int64: userID, Valid: true},
+		Provider:              s.payments.Name(),
+		ProviderTransactionID: transaction.ID,
+		Amount:                utils.FormatCents(transaction.Amount),
+		ChangedBy:             sql.NullInt64{Int64: userID, Valid: true},
+	})
+	if err != nil {
+		// The money was taken but the order could not be marked paid, for
+		// example because it expired in the meantime, so hand it back.
+		if s.paymentUnrecorded(ctx, transaction.ID, err) {
+			_, refundErr := s.payments.Refund(ctx, payment.RefundRequest{
+				TransactionID: transaction.ID,
+				Amount:        transaction.Amount,
+				Reference:     fmt.Sprintf("order-%d-void", order.ID),
+			})
+			if refundErr != nil {
+				log.Printf("failed to refund payment %s for order %d: %v", transaction.ID, order.ID, refundErr)
+			}
+		}
+		s.paymentError(c, err)
+		return
+	}
+
+	items, err := s.queries.ListOrderItems(ctx, order.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	orderResponse, err := OrderResponse{}.toOrderResponse(&result.Order, items)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	code := http.StatusCreated
+	if result.Replayed {
+		code = http.StatusOK
+	}
+	paymentResponse := PaymentResponse{}.toPaymentResponse(&result.Payment)
+	c.JSON(code, PayOrderResponse{
+		Order:   orderResponse,
+		Payment: &paymentResponse,
+	})
+}
+
+// payFreeOrder marks an order with nothing to pay as Paid without going to
+// the payment provider, which refuses zero amounts.
+func (s *Server) payFreeOrder(c *gin.Context, order db.Order, userID int64) {
+	ctx := context.Background()
+	result, err := s.store.PayOrderTx(ctx, db.PayOrderTxParams{
+		OrderID:   order.ID,
+		OwnerID:   sql.NullInt64{Int64: userID, Valid: true},
+		Provider:  s.payments.Name(),
+		Amount:    utils.FormatCents(0),
+		ChangedBy: sql.NullInt64{Int64: userID, Valid: true},
+	})
+	if err != nil {
+		s.paymentError(c, err)
+		return
+	}
+
+	items, err := s.queries.ListOrderItems(ctx, order.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	orderResponse, err := OrderResponse{}.toOrderResponse(&result.Order, items)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	code := http.StatusCreated
+	if result.Replayed {
+		code = http.StatusOK
+	}
+	c.JSON(code, PayOrderResponse{Order: orderResponse})
+}
+
+// paymentUnrecorded reports whether a captured transaction is known not to
+// be recorded after PayOrderTx failed with err. Only rejections of the
+// payment itself qualify; any other failure, such as a dropped connection
+// during commit, may have recorded it, and it must not be voided then.
+func (s *Server) paymentUnrecorded(ctx context.Context, transactionID string, err error) bool {
+	var transitionErr *db.InvalidTransitionError
+	if !errors.As(err, &transitionErr) && !errors.Is(err, db.ErrPaymentAmountMismatch) {
+		return false
+	}
+	_, err = s.queries.GetPaymentByProviderTransaction(ctx, db.GetPaymentByProviderTransactionParams{
+		Provider:              s.payments.Name(),
+		ProviderTransactionID: transactionID,
+	})
+	return errors.Is(err, sql.ErrNoRows)
+}
+
+// paymentError maps failures to record a payment to HTTP responses.
+func (s *Server) paymentError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, db.ErrPaymentAmountMismatch),
+		errors.Is(err, db.ErrPaymentOrderMismatch),
+		errors.Is(err, db.ErrPaymentRecorded):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		s.orderTransitionError(c, err)
+	}
+}
@@ -0,0 +1,267 @@
+package api_errors
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+
+	db "github.com/adedaryorh/ecommerceapi/db/sqlc"
+	"github.com/adedaryorh/ecommerceapi/utils"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	maxImageSize = 10 << 20
+	// maxImagePixels bounds the decoded size, since a small compressed file
+	// can expand to far more memory than its upload size.
+	maxImagePixels = 40_000_000
+	thumbnailSize  = 300
+)
+
+// imageExtensions lists the accepted upload types, detected from file content.
+var imageExtensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+}
+
+// ProductImageResponse defines the URLs of a product image and its thumbnail.
+type ProductImageResponse struct {
+	ID           int64  `json:"id"`
+	URL          string `json:"url"`
+	ThumbnailURL string `json:"thumbnail_url"`
+	Position     int32  `json:"position"`
+}
+
+func (p *Product) toProductImageResponse(image *db.ProductImage) ProductImageResponse {
+	return ProductImageResponse{
+		ID:           image.ID,
+		URL:          p.server.storage.URL(image.StorageKey),
+		ThumbnailURL: p.server.storage.URL(image.ThumbnailKey),
+		Position:     image.Position,
+	}
+}
+
+// productImages loads the ordered images of the given products. Every
+// requested ID has an entry, empty when the product has no images.
+func (p *Product) productImages(productIDs ...int64) (map[int64][]ProductImageResponse, error) {
+	images := make(map[int64][]ProductImageResponse, len(productIDs))
+	for _, id := range productIDs {
+		images[id] = []ProductImageResponse{}
+	}
+	if len(productIDs) == 0 {
+		return images, nil
+	}
+
+	rows, err := p.server.queries.ListProductImages(context.Background(), productIDs)
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		images[row.ProductID] = append(images[row.ProductID], p.toProductImageResponse(&row))
+	}
+	return images, nil
+}
+
+func newStorageKey(productID int64) (string, error) {
+	token := make([]byte, 16)
+	if _, err := rand.Read(token); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("products/%d/%s", productID, hex.EncodeToString(token)), nil
+}
+
+// encodeThumbnail writes a thumbnail in the format of the original, except
+// that GIFs become PNGs so only their first frame is kept.
+func encodeThumbnail(img image.Image, contentType string) (*bytes.Buffer, string, error) {
+	var buf bytes.Buffer
+	thumb := utils.Thumbnail(img, thumbnailSize)
+	if contentType == "image/jpeg" {
+		err := jpeg.Encode(&buf, thumb, &jpeg.Options{Quality: 85})
+		return &buf, ".jpg", err
+	}
+	err := png.Encode(&buf, thumb)
+	return &buf, ".png", err
+}
+
+// @Summary Upload Product Image
+// @Description Upload a JPEG, PNG or GIF image for a product and generate its thumbnail (admin only)
+// @Tags Products
+// @Accept multipart/form-data
+// @Produce json
+// @Param id path string true "Product ID"
+// @Param image formData file true "Image file (max 10 MB)"
+// @Success 201 {object} api_errors.ProductImageResponse
+// @Failure 400 {object} api_errors.ApiError
+// @Failure 404 {object} api_errors.ApiError
+// @Failure 413 {object} api_errors.ApiError
+// @Failure 415 {object} api_errors.ApiError
+// @Failure 500 {object} api_errors.ApiError
+// @Security BearerAuth
+// @Router /products/{id}/images [post]
+func (p *Product) uploadProductImage(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+
+	_, err = p.server.queries.GetProductByID(context.Background(), id)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImageSize+(1<<20))
+	fileHeader, err := c.FormFile("image")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "An image file is required"})
+		return
+	}
+	if fileHeader.Size > maxImageSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Image must be 10 MB or smaller"})
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Trust the file content rather than the client-supplied Content-Type.
+	contentType := http.DetectContentType(data)
+	ext, ok := imageExtensions[contentType]
+	if !ok {
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": "Image must be a JPEG, PNG or GIF"})
+		return
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Image could not be decoded"})
+		return
+	}
+	if int64(config.Width)*int64(config.Height) > maxImagePixels {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Image must be 40 megapixels or smaller"})
+		return
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Image could not be decoded"})
+		return
+	}
+	thumb, thumbExt, err := encodeThumbnail(img, contentType)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	key, err := newStorageKey(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	storageKey := key + ext
+	thumbnailKey := key + "_thumb" + thumbExt
+
+	ctx := context.Background()
+	if err := p.server.storage.Save(ctx, storageKey, bytes.NewReader(data)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store image: " + err.Error()})
+		return
+	}
+	if err := p.server.storage.Save(ctx, thumbnailKey, thumb); err != nil {
+		p.server.storage.Delete(ctx, storageKey)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store thumbnail: " + err.Error()})
+		return
+	}
+
+	productImage, err := p.server.store.CreateProductImageTx(ctx, db.CreateProductImageParams{
+		ProductID:    id,
+		StorageKey:   storageKey,
+		ThumbnailKey: thumbnailKey,
+		ContentType:  contentType,
+	})
+	if err != nil {
+		p.server.storage.Delete(ctx, storageKey)
+		p.server.storage.Delete(ctx, thumbnailKey)
+		var notFoundErr *db.ProductNotFoundError
+		if errors.As(err, &notFoundErr) {
+			// The product was archived while the image was being processed.
+			c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, p.toProductImageResponse(&productImage))
+}
+
+// @Summary Delete Product Image
+// @Description Remove a product image and its stored files (admin only)
+// @Tags Products
+// @Param id path string true "Product ID"
+// @Param image_id path string true "Image ID"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} api_errors.ApiError
+// @Failure 404 {object} api_errors.ApiError
+// @Failure 500 {object} api_errors.ApiError
+// @Security BearerAuth
+// @Router /products/{id}/images/{image_id} [delete]
+func (p *Product) deleteProductImage(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+	imageID, err := strconv.ParseInt(c.Param("image_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid image ID"})
+		return
+	}
+
+	ctx := context.Background()
+	productImage, err := p.server.queries.DeleteProductImage(ctx, db.DeleteProductImageParams{
+		ID:        imageID,
+		ProductID: id,
+	})
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Image not found"})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// The row is gone either way; a leftover file is only wasted space.
+	for _, key := range []string{productImage.StorageKey, productImage.ThumbnailKey} {
+		if err := p.server.storage.Delete(ctx, key); err != nil {
+			log.Printf("failed to delete stored image %s: %v", key, err)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Image deleted successfully"})
+}
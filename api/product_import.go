@@ -0,0 +1,337 @@
+package api_errors
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	db "github.com/adedaryorh/ecommerceapi/db/sqlc"
+	"github.com/adedaryorh/ecommerceapi/utils"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	maxImportSize   = 20 << 20
+	exportPageSize  = 500
+	importBatchSize = 100
+)
+
+// ProductImportResponse reports the outcome of a product import. In a dry
+// run the counts describe what would have happened and nothing is saved.
+type ProductImportResponse struct {
+	DryRun  bool                `json:"dry_run"`
+	Created int                 `json:"created"`
+	Updated int                 `json:"updated"`
+	Errors  []db.ImportRowError `json:"errors"`
+}
+
+// importRecord is one product as read from a CSV or NDJSON import.
+type importRecord struct {
+	line        int
+	sku         string
+	name        string
+	description string
+	price       string
+	stock       string
+}
+
+// importFormat picks the input format from the format query parameter,
+// falling back to the request Content-Type.
+func importFormat(c *gin.Context) (string, error) {
+	format := c.Query("format")
+	if format == "" {
+		switch c.ContentType() {
+		case "text/csv":
+			format = "csv"
+		case "application/x-ndjson", "application/json":
+			format = "ndjson"
+		}
+	}
+	if format != "csv" && format != "ndjson" {
+		return "", errors.New("format must be csv or ndjson")
+	}
+	return format, nil
+}
+
+func readCSVRecords(r io.Reader) ([]importRecord, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV header: %v", err)
+	}
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, required := range []string{"name", "price"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("CSV header is missing the %s column", required)
+		}
+	}
+	field := func(record []string, name string) string {
+		if i, ok := columns[name]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	// Rows may have fewer fields than the header; missing ones are empty.
+	reader.FieldsPerRecord = -1
+	records := []importRecord{}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("invalid CSV: %v", err)
+		}
+		line, _ := reader.FieldPos(0)
+		records = append(records, importRecord{
+			line:        line,
+			sku:         field(record, "sku"),
+			name:        field(record, "name"),
+			description: field(record, "description"),
+			price:       field(record, "price"),
+			stock:       field(record, "stock"),
+		})
+	}
+	return records, nil
+}
+
+func readNDJSONRecords(r io.Reader) ([]importRecord, []db.ImportRowError, error) {
+	type ndjsonProduct struct {
+		Sku         string      `json:"sku"`
+		Name        string      `json:"name"`
+		Description string      `json:"description"`
+		Price       json.Number `json:"price"`
+		Stock       json.Number `json:"stock"`
+	}
+
+	records := []importRecord{}
+	rowErrors := []db.ImportRowError{}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1<<20)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		var product ndjsonProduct
+		if err := json.Unmarshal([]byte(text), &product); err != nil {
+			rowErrors = append(rowErrors, db.ImportRowError{Line: line, Error: "invalid JSON: " + err.Error()})
+			continue
+		}
+		records = append(records, importRecord{
+			line:        line,
+			sku:         strings.TrimSpace(product.Sku),
+			name:        strings.TrimSpace(product.Name),
+			description: product.Description,
+			price:       product.Price.String(),
+			stock:       product.Stock.String(),
+		})
+	}
+	return records, rowErrors, scanner.Err()
+}
+
+// toImportRow validates a record and converts it to column values.
+func (r importRecord) toImportRow() (db.ImportProductRow, error) {
+	// Blank or missing description and stock leave an existing product's
+	// values as they are.
+	row := db.ImportProductRow{
+		Line:           r.line,
+		Sku:            sql.NullString{String: r.sku, Valid: r.sku != ""},
+		Name:           r.name,
+		SetDescription: r.description != "",
+		Description:    sql.NullString{String: r.description, Valid: r.description != ""},
+		SetStock:       r.stock != "",
+	}
+	if row.Name == "" {
+		return row, errors.New("name is required")
+	}
+
+	cents, err := utils.ParseCents(r.price)
+	if err != nil || cents <= 0 {
+		return row, errors.New("price must be a positive amount")
+	}
+	row.Price = float64(cents) / 100
+
+	if r.stock != "" {
+		stock, err := strconv.ParseInt(r.stock, 10, 32)
+		if err != nil || stock < 0 {
+			return row, errors.New("stock must be a non-negative integer")
+		}
+		row.Stock = int32(stock)
+	}
+	return row, nil
+}
+
+// @Summary Import Products
+// @Description Create or update products from CSV or NDJSON (admin only). Rows with a sku are matched on it, others on name; a blank description or stock leaves a matched product's value unchanged. Invalid rows are reported and skipped.
+// @Tags Products
+// @Accept text/csv
+// @Accept application/x-ndjson
+// @Produce json
+// @Param format query string false "Input format: csv or ndjson (defaults to the Content-Type)"
+// @Param dry_run query bool false "Validate and report without saving"
+// @Success 200 {object} api_errors.ProductImportResponse
+// @Failure 400 {object} api_errors.ApiError
+// @Failure 500 {object} api_errors.ApiError
+// @Security BearerAuth
+// @Router /products/import [post]
+func (p *Product) importProducts(c *gin.Context) {
+	format, err := importFormat(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	dryRun, _ := strconv.ParseBool(c.DefaultQuery("dry_run", "false"))
+
+	body := http.MaxBytesReader(c.Writer, c.Request.Body, maxImportSize)
+	var records []importRecord
+	rowErrors := []db.ImportRowError{}
+	if format == "csv" {
+		records, err = readCSVRecords(body)
+	} else {
+		records, rowErrors, err = readNDJSONRecords(body)
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Each product may appear only once, otherwise later rows would silently
+	// overwrite earlier ones.
+	seen := map[string]int{}
+	rows := []db.ImportProductRow{}
+	for _, record := range records {
+		row, err := record.toImportRow()
+		if err != nil {
+			rowErrors = append(rowErrors, db.ImportRowError{Line: record.line, Error: err.Error()})
+			continue
+		}
+		key := "name:" + row.Name
+		if row.Sku.Valid {
+			key = "sku:" + row.Sku.String
+		}
+		if first, ok := seen[key]; ok {
+			rowErrors = append(rowErrors, db.ImportRowError{Line: record.line, Error: fmt.Sprintf("duplicate of line %d", first)})
+			continue
+		}
+		seen[key] = record.line
+		rows = append(rows, row)
+	}
+
+	result, err := p.server.store.ImportProductsTx(context.Background(), db.ImportProductsTxParams{
+		Rows:      rows,
+		DryRun:    dryRun,
+		BatchSize: importBatchSize,
+		CreatedBy: actingUser(c),
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to import products: " + err.Error()})
+		return
+	}
+
+	rowErrors = append(rowErrors, result.Errors...)
+	sort.SliceStable(rowErrors, func(i, j int) bool { return rowErrors[i].Line < rowErrors[j].Line })
+
+	c.JSON(http.StatusOK, ProductImportResponse{
+		DryRun:  dryRun,
+		Created: result.Created,
+		Updated: result.Updated,
+		Errors:  rowErrors,
+	})
+}
+
+// @Summary Export Products
+// @Description Stream the full catalog as CSV or NDJSON (admin only). The output can be imported again.
+// @Tags Products
+// @Produce text/csv
+// @Produce application/x-ndjson
+// @Param format query string false "Output format: csv or ndjson" default(csv)
+// @Success 200 {string} string
+// @Failure 400 {object} api_errors.ApiError
+// @Security BearerAuth
+// @Router /products/export [get]
+func (p *Product) exportProducts(c *gin.Context) {
+	format := c.DefaultQuery("format", "csv")
+	if format != "csv" && format != "ndjson" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be csv or ndjson"})
+		return
+	}
+
+	if format == "csv" {
+		c.Header("Content-Type", "text/csv")
+	} else {
+		c.Header("Content-Type", "application/x-ndjson")
+	}
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=products.%s", format))
+	c.Status(http.StatusOK)
+
+	csvWriter := csv.NewWriter(c.Writer)
+	encoder := json.NewEncoder(c.Writer)
+	if format == "csv" {
+		csvWriter.Write([]string{"id", "sku", "name", "description", "price", "stock"})
+	}
+
+	// Headers are already sent, so failures can only be logged and the
+	// stream cut short.
+	var afterID int64
+	for {
+		products, err := p.server.queries.ListProductsAfter(context.Background(), db.ListProductsAfterParams{
+			AfterID:   afterID,
+			PageLimit: exportPageSize,
+		})
+		if err != nil {
+			log.Printf("product export failed after id %d: %v", afterID, err)
+			return
+		}
+
+		for _, product := range products {
+			if format == "csv" {
+				err = csvWriter.Write([]string{
+					strconv.FormatInt(product.ID, 10),
+					product.Sku.String,
+					product.Name,
+					product.Description.String,
+					product.Price,
+					strconv.FormatInt(int64(product.Stock), 10),
+				})
+			} else {
+				err = encoder.Encode(map[string]interface{}{
+					"id":          product.ID,
+					"sku":         product.Sku.String,
+					"name":        product.Name,
+					"description": product.Description.String,
+					"price":       product.Price,
+					"stock":       product.Stock,
+				})
+			}
+			if err != nil {
+				log.Printf("product export failed at id %d: %v", product.ID, err)
+				return
+			}
+		}
+
+		csvWriter.Flush()
+		c.Writer.Flush()
+		if len(products) < exportPageSize {
+			return
+		}
+		afterID = products[len(products)-1].ID
+	}
+}
@@ -0,0 +1,174 @@
+package api_errors
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	db "github.com/adedaryorh/ecommerceapi/db/sqlc"
+	"github.com/adedaryorh/ecommerceapi/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// ProductPatchParams defines a partial product update. Omitted fields are
+// left unchanged; an empty description or sku clears it. A reorder threshold
+// of 0 turns off low-stock alerts for the product. The tax class picks the
+// rates charged on the product, such as "standard" or "reduced".
+type ProductPatchParams struct {
+	Name             *string `json:"name" binding:"omitempty,min=1"`
+	Description      *string `json:"description"`
+	Price            *string `json:"price"`
+	Stock            *int32  `json:"stock" binding:"omitempty,gte=0"`
+	SKU              *string `json:"sku"`
+	ReorderThreshold *int32  `json:"reorder_threshold" binding:"omitempty,gte=0"`
+	TaxClass         *string `json:"tax_class" binding:"omitempty,min=1,max=32"`
+}
+
+// productETag identifies a product revision. The version column is bumped
+// on every write, so the tag changes whenever the product does.
+func productETag(product *db.Product) string {
+	return fmt.Sprintf(`"%d"`, product.Version)
+}
+
+// ifMatchVersion reads the optional If-Match header. It returns an invalid
+// NullInt32 when the header is absent or "*", meaning any version matches.
+func ifMatchVersion(c *gin.Context) (sql.NullInt32, error) {
+	header := strings.TrimSpace(c.GetHeader("If-Match"))
+	if header == "" || header == "*" {
+		return sql.NullInt32{}, nil
+	}
+	if strings.Contains(header, ",") {
+		return sql.NullInt32{}, errors.New("If-Match must contain a single ETag")
+	}
+	// Weak tags never match under the strong comparison If-Match requires,
+	// so they are reported as a failed precondition like any stale tag.
+	version, err := strconv.ParseInt(strings.Trim(header, `"`), 10, 32)
+	if err != nil || strings.HasPrefix(header, "W/") {
+		return sql.NullInt32{Int32: -1, Valid: true}, nil
+	}
+	return sql.NullInt32{Int32: int32(version), Valid: true}, nil
+}
+
+// productWriteError maps failed conditional product writes to HTTP
+// responses. An update matching no row either hit a missing product or a
+// stale If-Match version; constraint violations go through dbError.
+func (p *Product) productWriteError(c *gin.Context, id int64, err error) {
+	if err == sql.ErrNoRows {
+		current, getErr := p.server.queries.GetProductByID(context.Background(), id)
+		if getErr == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+			return
+		} else if getErr != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": getErr.Error()})
+			return
+		}
+		c.Header("ETag", productETag(&current))
+		c.JSON(http.StatusPreconditionFailed, gin.H{"error": "Product was modified by another request; reload it and retry"})
+		return
+	}
+	dbError(c, err)
+}
+
+// @Summary Patch Product
+// @Description Update only the given fields of a product (admin only). Send the ETag from a previous response in If-Match to avoid overwriting concurrent changes.
+// @Tags Products
+// @Accept json
+// @Produce json
+// @Param id path string true "Product ID"
+// @Param If-Match header string false "ETag of the product version being edited"
+// @Param product body ProductPatchParams true "Fields to update"
+// @Success 200 {object} api_errors.ProductResponse
+// @Failure 400 {object} api_errors.ApiError
+// @Failure 404 {object} api_errors.ApiError
+// @Failure 409 {object} api_errors.ApiError
+// @Failure 412 {object} api_errors.ApiError
+// @Failure 500 {object} api_errors.ApiError
+// @Security BearerAuth
+// @Router /products/{id} [patch]
+func (p *Product) patchProduct(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+
+	var params ProductPatchParams
+	if err := c.ShouldBindJSON(&params); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if params.Name == nil && params.Description == nil && params.Price == nil && params.Stock == nil && params.SKU == nil && params.ReorderThreshold == nil && params.TaxClass == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No fields to update"})
+		return
+	}
+
+	expectedVersion, err := ifMatchVersion(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	arg := db.PatchProductParams{
+		ID:              id,
+		ExpectedVersion: expectedVersion,
+	}
+	if params.Name != nil {
+		arg.Name = sql.NullString{String: *params.Name, Valid: true}
+	}
+	if params.Description != nil {
+		arg.SetDescription = true
+		arg.Description = sql.NullString{String: *params.Description, Valid: *params.Description != ""}
+	}
+	if params.Price != nil {
+		cents, err := utils.ParseCents(*params.Price)
+		if err != nil || cents <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid price format"})
+			return
+		}
+		arg.Price = sql.NullString{String: utils.FormatCents(cents), Valid: true}
+	}
+	if params.Stock != nil {
+		arg.Stock = sql.NullInt32{Int32: *params.Stock, Valid: true}
+	}
+	if params.SKU != nil {
+		arg.SetSku = true
+		arg.Sku = sql.NullString{String: *params.SKU, Valid: *params.SKU != ""}
+	}
+	if params.ReorderThreshold != nil {
+		arg.ReorderThreshold = sql.NullInt32{Int32: *params.ReorderThreshold, Valid: true}
+	}
+	if params.TaxClass != nil {
+		arg.TaxClass = sql.NullString{String: strings.ToLower(strings.TrimSpace(*params.TaxClass)), Valid: true}
+	}
+
+	product, err := p.server.store.PatchProductTx(context.Background(), db.PatchProductTxParams{
+		PatchProductParams: arg,
+		UpdatedBy:          actingUser(c),
+	})
+	if err != nil {
+		p.productWriteError(c, id, err)
+		return
+	}
+
+	images, err := p.productImages(product.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch product images: " + err.Error()})
+		return
+	}
+
+	reserved, err := p.reservedStock(product.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch reserved stock: " + err.Error()})
+		return
+	}
+
+	response := ProductResponse{}.toProductResponse(&product)
+	response.Images = images[product.ID]
+	response.AvailableToSell -= reserved[product.ID]
+	c.Header("ETag", productETag(&product))
+	c.JSON(http.StatusOK, response)
+}
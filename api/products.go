@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	db "github.com/adedaryorh/ecommerceapi/db/sqlc"
@@ -20,12 +21,33 @@ type Product struct {
 func (p *Product) router(server *Server) {
 	p.server = server
 
-	serverGroup := server.router.Group("/products", server.AuthenticatedMiddleware(), RoleBasedMiddleware(server, "admin"))
-	serverGroup.POST("/createProduct", p.createProduct)
-	serverGroup.GET("/:id", p.getProduct)
-	serverGroup.GET("", p.listProducts)
-	serverGroup.PUT("/:id", p.updateProduct)
-	serverGroup.DELETE("/:id", p.deleteProduct)
+	// Storefront routes are public and read-only.
+	publicGroup := server.router.Group("/products")
+	publicGroup.GET("/search", p.searchProducts)
+	publicGroup.GET("/categories", p.listCategories)
+	publicGroup.GET("/:id", p.getProduct)
+	publicGroup.GET("/:id/variants", p.listVariants)
+	publicGroup.GET("", p.listProducts)
+
+	adminGroup := server.router.Group("/products", server.AuthenticatedMiddleware(), RoleBasedMiddleware(server, "admin"))
+	adminGroup.POST("/createProduct", p.createProduct)
+	adminGroup.POST("/import", p.importProducts)
+	adminGroup.GET("/export", p.exportProducts)
+	adminGroup.PUT("/:id", p.updateProduct)
+	adminGroup.PATCH("/:id", p.patchProduct)
+	adminGroup.DELETE("/:id", p.deleteProduct)
+	adminGroup.POST("/:id/restore", p.restoreProduct)
+	adminGroup.PUT("/:id/categories", p.setProductCategories)
+	adminGroup.POST("/:id/variants", p.createVariant)
+	adminGroup.PUT("/:id/variants/:variant_id", p.updateVariant)
+	adminGroup.DELETE("/:id/variants/:variant_id", p.deleteVariant)
+	adminGroup.POST("/:id/images", p.uploadProductImage)
+	adminGroup.DELETE("/:id/images/:image_id", p.deleteProductImage)
+	adminGroup.GET("/:id/inventory", p.listInventoryMovements)
+	adminGroup.POST("/:id/inventory", p.adjustStock)
+	adminGroup.POST("/categories", p.createCategory)
+	adminGroup.PUT("/categories/:category_id", p.updateCategory)
+	adminGroup.DELETE("/categories/:category_id", p.deleteCategory)
 }
 
 // ProductParams defines the expected input for product operations.
@@ -34,17 +56,25 @@ type ProductParams struct {
 	Description *string `json:"description"` // Nullable description
 	Price       string  `json:"price" binding:"required,gt=0"`
 	Stock       int32   `json:"stock" binding:"required,gt=0"`
+	SKU         *string `json:"sku"` // Only set on creation
 }
 
 // ProductResponse defines the response structure for product data.
+// AvailableToSell is the stock not held by pending orders.
 type ProductResponse struct {
-	ID          int64     `json:"id"`
-	Name        string    `json:"name"`
-	Description *string   `json:"description"` // Nullable description
-	Price       float64   `json:"price"`       // Now a float64
-	Stock       int32     `json:"stock"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID               int64                  `json:"id"`
+	SKU              *string                `json:"sku"`
+	Name             string                 `json:"name"`
+	Description      *string                `json:"description"` // Nullable description
+	Price            float64                `json:"price"`       // Now a float64
+	Stock            int32                  `json:"stock"`
+	AvailableToSell  int32                  `json:"available_to_sell"`
+	ReorderThreshold int32                  `json:"reorder_threshold"`
+	TaxClass         string                 `json:"tax_class"`
+	Images           []ProductImageResponse `json:"images"`
+	ArchivedAt       *time.Time             `json:"archived_at,omitempty"`
+	CreatedAt        time.Time              `json:"created_at"`
+	UpdatedAt        time.Time              `json:"updated_at"`
 }
 
 // Converts a db.Product to a ProductResponse.
@@ -54,6 +84,14 @@ func (p ProductResponse) toProductResponse(product *db.Product) ProductResponse
 	if product.Description.Valid {
 		description = &product.Description.String
 	}
+	var sku *string
+	if product.Sku.Valid {
+		sku = &product.Sku.String
+	}
+	var archivedAt *time.Time
+	if product.DeletedAt.Valid {
+		archivedAt = &product.DeletedAt.Time
+	}
 	// Convert the product price from string to float64
 	price, err := strconv.ParseFloat(product.Price, 64)
 	if err != nil {
@@ -63,13 +101,49 @@ func (p ProductResponse) toProductResponse(product *db.Product) ProductResponse
 	}
 	// Accessing the price as a string, not Float64
 	return ProductResponse{
-		ID:          product.ID,
-		Name:        product.Name,
-		Description: description,
-		Price:       price,
-		Stock:       product.Stock,
-		CreatedAt:   product.CreatedAt,
-		UpdatedAt:   product.UpdatedAt,
+		ID:               product.ID,
+		SKU:              sku,
+		Name:             product.Name,
+		Description:      description,
+		Price:            price,
+		Stock:            product.Stock,
+		AvailableToSell:  product.Stock,
+		ReorderThreshold: product.ReorderThreshold,
+		TaxClass:         product.TaxClass,
+		Images:           []ProductImageResponse{},
+		ArchivedAt:       archivedAt,
+		CreatedAt:        product.CreatedAt,
+		UpdatedAt:        product.UpdatedAt,
+	}
+}
+
+// PublicProductResponse defines the storefront view of a product. Exact
+// stock levels are internal, so shoppers only see whether it is in stock.
+type PublicProductResponse struct {
+	ID          int64                  `json:"id"`
+	Name        string                 `json:"name"`
+	Description *string                `json:"description"`
+	Price       float64                `json:"price"`
+	InStock     bool                   `json:"in_stock"`
+	Images      []ProductImageResponse `json:"images"`
+	ArchivedAt  *time.Time             `json:"archived_at,omitempty"`
+	CreatedAt   time.Time              `json:"created_at"`
+	UpdatedAt   time.Time              `json:"updated_at"`
+}
+
+// Converts a db.Product to a PublicProductResponse.
+func (p PublicProductResponse) toPublicProductResponse(product *db.Product) PublicProductResponse {
+	full := ProductResponse{}.toProductResponse(product)
+	return PublicProductResponse{
+		ID:          full.ID,
+		Name:        full.Name,
+		Description: full.Description,
+		Price:       full.Price,
+		InStock:     full.AvailableToSell > 0 && full.ArchivedAt == nil,
+		Images:      full.Images,
+		ArchivedAt:  full.ArchivedAt,
+		CreatedAt:   full.CreatedAt,
+		UpdatedAt:   full.UpdatedAt,
 	}
 }
 
@@ -81,6 +155,7 @@ func (p ProductResponse) toProductResponse(product *db.Product) ProductResponse
 // @Param product body ProductParams true "Product Details"
 // @Success 201 {object} api_errors.ProductResponse
 // @Failure 400 {object} api_errors.ApiError
+// @Failure 409 {object} api_errors.ApiError
 // @Failure 500 {object} api_errors.ApiError
 // @Security BearerAuth
 // @Router /products/createProduct [post]
@@ -99,31 +174,40 @@ func (p *Product) createProduct(c *gin.Context) {
 	}
 
 	arg := db.CreateProductParams{
-		Name:        params.Name,
-		Description: sql.NullString{String: *params.Description, Valid: params.Description != nil},
-		Price:       price, // Pass the converted price
-		Stock:       params.Stock,
+		Name:  params.Name,
+		Price: price, // Pass the converted price
+		Stock: params.Stock,
+	}
+	if params.Description != nil {
+		arg.Description = sql.NullString{String: *params.Description, Valid: true}
+	}
+	if params.SKU != nil && *params.SKU != "" {
+		arg.Sku = sql.NullString{String: *params.SKU, Valid: true}
 	}
 
-	product, err := p.server.queries.CreateProduct(context.Background(), arg)
+	product, err := p.server.store.CreateProductTx(context.Background(), db.CreateProductTxParams{
+		CreateProductParams: arg,
+		CreatedBy:           actingUser(c),
+	})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create product: " + err.Error()})
+		dbError(c, err)
 		return
 	}
 
+	c.Header("ETag", productETag(&product))
 	c.JSON(http.StatusCreated, ProductResponse{}.toProductResponse(&product))
 }
 
 // @Summary Get Product
-// @Description Retrieve a specific product by ID
+// @Description Retrieve a specific product by ID (public storefront)
 // @Tags Products
 // @Produce json
 // @Param id path string true "Product ID"
-// @Success 200 {object} api_errors.ProductResponse
+// @Param include_archived query bool false "Also resolve archived products, e.g. when following a past order line"
+// @Success 200 {object} api_errors.PublicProductResponse
 // @Failure 400 {object} api_errors.ApiError
 // @Failure 404 {object} api_errors.ApiError
 // @Failure 500 {object} api_errors.ApiError
-// @Security BearerAuth
 // @Router /products/{id} [get]
 func (p *Product) getProduct(c *gin.Context) {
 	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
@@ -132,7 +216,14 @@ func (p *Product) getProduct(c *gin.Context) {
 		return
 	}
 
-	product, err := p.server.queries.GetProductByID(context.Background(), id)
+	// Archived products are hidden unless explicitly asked for, so order
+	// history can still link to them.
+	var product db.Product
+	if includeArchived, _ := strconv.ParseBool(c.Query("include_archived")); includeArchived {
+		product, err = p.server.queries.GetProductByIDWithArchived(context.Background(), id)
+	} else {
+		product, err = p.server.queries.GetProductByID(context.Background(), id)
+	}
 	if err == sql.ErrNoRows {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
 		return
@@ -141,18 +232,35 @@ func (p *Product) getProduct(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, ProductResponse{}.toProductResponse(&product))
+	images, err := p.productImages(product.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch product images: " + err.Error()})
+		return
+	}
+
+	reserved, err := p.reservedStock(product.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch reserved stock: " + err.Error()})
+		return
+	}
+
+	response := PublicProductResponse{}.toPublicProductResponse(&product)
+	response.Images = images[product.ID]
+	response.InStock = response.InStock && product.Stock > reserved[product.ID]
+	c.Header("ETag", productETag(&product))
+	c.JSON(http.StatusOK, response)
 }
 
 // @Summary List Products
-// @Description Retrieve paginated list of products
+// @Description Retrieve paginated list of products (public storefront)
 // @Tags Products
 // @Produce json
 // @Param limit query int false "Number of products to retrieve" default(10)
 // @Param offset query int false "Offset for pagination" default(0)
-// @Success 200 {object} api_errors.ProductResponse
+// @Param category query int false "Category ID; includes products in its subcategories"
+// @Success 200 {object} api_errors.PublicProductResponse
+// @Failure 400 {object} api_errors.ApiError
 // @Failure 500 {object} api_errors.ApiError
-// @Security BearerAuth
 // @Router /products [get]
 func (p *Product) listProducts(c *gin.Context) {
 	limit := int32(10)
@@ -166,8 +274,16 @@ func (p *Product) listProducts(c *gin.Context) {
 	}
 
 	arg := db.ListProductsParams{
-		Limit:  limit,
-		Offset: offset,
+		PageLimit:  limit,
+		PageOffset: offset,
+	}
+	if v := c.Query("category"); v != "" {
+		categoryID, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid category ID"})
+			return
+		}
+		arg.CategoryID = sql.NullInt64{Int64: categoryID, Valid: true}
 	}
 
 	products, err := p.server.queries.ListProducts(context.Background(), arg)
@@ -176,24 +292,181 @@ func (p *Product) listProducts(c *gin.Context) {
 		return
 	}
 
-	response := []ProductResponse{}
+	productIDs := []int64{}
 	for _, product := range products {
-		response = append(response, ProductResponse{}.toProductResponse(&product))
+		productIDs = append(productIDs, product.ID)
+	}
+	images, err := p.productImages(productIDs...)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list product images: " + err.Error()})
+		return
+	}
+
+	reserved, err := p.reservedStock(productIDs...)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list reserved stock: " + err.Error()})
+		return
+	}
+
+	response := []PublicProductResponse{}
+	for _, product := range products {
+		item := PublicProductResponse{}.toPublicProductResponse(&product)
+		item.Images = images[product.ID]
+		item.InStock = item.InStock && product.Stock > reserved[product.ID]
+		response = append(response, item)
 	}
 
 	c.JSON(http.StatusOK, gin.H{"products": response, "limit": limit, "offset": offset})
 }
 
+// ProductSearchResponse defines one page of storefront search results.
+type ProductSearchResponse struct {
+	Products []PublicProductResponse `json:"products"`
+	Total    int64                   `json:"total"`
+	Limit    int32                   `json:"limit"`
+	Offset   int32                   `json:"offset"`
+}
+
+// @Summary Search Products
+// @Description Full-text product search with price and stock filters (public storefront)
+// @Tags Products
+// @Produce json
+// @Param q query string false "Search text, matched against name and description"
+// @Param min_price query string false "Minimum price"
+// @Param max_price query string false "Maximum price"
+// @Param in_stock query bool false "Only return products that are in stock"
+// @Param sort query string false "relevance, price_asc, price_desc or newest (default relevance when q is set, otherwise newest)"
+// @Param limit query int false "Number of products to retrieve (max 100)" default(20)
+// @Param offset query int false "Offset for pagination" default(0)
+// @Success 200 {object} api_errors.ProductSearchResponse
+// @Failure 400 {object} api_errors.ApiError
+// @Failure 500 {object} api_errors.ApiError
+// @Router /products/search [get]
+func (p *Product) searchProducts(c *gin.Context) {
+	arg := db.SearchProductsParams{
+		Query:      strings.TrimSpace(c.Query("q")),
+		PageLimit:  20,
+		PageOffset: 0,
+	}
+
+	arg.SortBy = c.Query("sort")
+	if arg.SortBy == "" {
+		arg.SortBy = "newest"
+		if arg.Query != "" {
+			arg.SortBy = "relevance"
+		}
+	}
+	switch arg.SortBy {
+	case "relevance", "price_asc", "price_desc", "newest":
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "sort must be relevance, price_asc, price_desc or newest"})
+		return
+	}
+
+	var err error
+	if arg.MinPrice, err = parseAmountParam(c, "min_price"); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if arg.MaxPrice, err = parseAmountParam(c, "max_price"); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if v := c.Query("in_stock"); v != "" {
+		if arg.InStockOnly, err = strconv.ParseBool(v); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "in_stock must be a boolean"})
+			return
+		}
+	}
+	if v := c.Query("limit"); v != "" {
+		l, err := strconv.Atoi(v)
+		if err != nil || l <= 0 || l > 100 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 100"})
+			return
+		}
+		arg.PageLimit = int32(l)
+	}
+	if v := c.Query("offset"); v != "" {
+		o, err := strconv.Atoi(v)
+		if err != nil || o < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "offset must not be negative"})
+			return
+		}
+		arg.PageOffset = int32(o)
+	}
+
+	rows, err := p.server.queries.SearchProducts(context.Background(), arg)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search products: " + err.Error()})
+		return
+	}
+
+	productIDs := []int64{}
+	for _, row := range rows {
+		productIDs = append(productIDs, row.ID)
+	}
+	images, err := p.productImages(productIDs...)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list product images: " + err.Error()})
+		return
+	}
+	reserved, err := p.reservedStock(productIDs...)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list reserved stock: " + err.Error()})
+		return
+	}
+
+	response := ProductSearchResponse{
+		Products: []PublicProductResponse{},
+		Limit:    arg.PageLimit,
+		Offset:   arg.PageOffset,
+	}
+	for _, row := range rows {
+		product := db.Product{
+			ID:          row.ID,
+			Name:        row.Name,
+			Description: row.Description,
+			Price:       row.Price,
+			Stock:       row.Stock,
+			CreatedAt:   row.CreatedAt,
+			UpdatedAt:   row.UpdatedAt,
+		}
+		item := PublicProductResponse{}.toPublicProductResponse(&product)
+		item.Images = images[row.ID]
+		item.InStock = item.InStock && row.Stock > reserved[row.ID]
+		response.Products = append(response.Products, item)
+		// Every row carries the same window count of all matches.
+		response.Total = row.TotalCount
+	}
+	// A page past the last match has no row to carry the count.
+	if len(rows) == 0 && arg.PageOffset > 0 {
+		response.Total, err = p.server.queries.CountSearchProducts(context.Background(), db.CountSearchProductsParams{
+			Query:       arg.Query,
+			MinPrice:    arg.MinPrice,
+			MaxPrice:    arg.MaxPrice,
+			InStockOnly: arg.InStockOnly,
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count products: " + err.Error()})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
 // @Summary Update Product
-// @Description Update an existing product (admin only)
+// @Description Replace an existing product (admin only). Send the ETag from a previous response in If-Match to avoid overwriting concurrent changes.
 // @Tags Products
 // @Accept json
 // @Produce json
 // @Param id path string true "Product ID"
+// @Param If-Match header string false "ETag of the product version being edited"
 // @Param product body ProductParams true "Product Update Details"
 // @Success 200 {object} api_errors.ProductResponse
 // @Failure 400 {object} api_errors.ApiError
 // @Failure 404 {object} api_errors.ApiError
+// @Failure 412 {object} api_errors.ApiError
 // @Failure 500 {object} api_errors.ApiError
 // @Security BearerAuth
 // @Router /products/{id} [put]
@@ -217,29 +490,54 @@ func (p *Product) updateProduct(c *gin.Context) {
 		return
 	}
 
+	expectedVersion, err := ifMatchVersion(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	arg := db.UpdateProductParams{
-		Name:        params.Name,
-		Description: sql.NullString{String: *params.Description, Valid: params.Description != nil},
-		Price:       price, // Pass the converted price
-		Stock:       params.Stock,
-		UpdatedAt:   time.Now(),
-		ID:          id,
+		Name:            params.Name,
+		Price:           price, // Pass the converted price
+		Stock:           params.Stock,
+		UpdatedAt:       time.Now(),
+		ID:              id,
+		ExpectedVersion: expectedVersion,
+	}
+	if params.Description != nil {
+		arg.Description = sql.NullString{String: *params.Description, Valid: true}
 	}
 
-	product, err := p.server.queries.UpdateProduct(context.Background(), arg)
-	if err == sql.ErrNoRows {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+	product, err := p.server.store.UpdateProductTx(context.Background(), db.UpdateProductTxParams{
+		UpdateProductParams: arg,
+		UpdatedBy:           actingUser(c),
+	})
+	if err != nil {
+		p.productWriteError(c, id, err)
 		return
-	} else if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update product: " + err.Error()})
+	}
+
+	images, err := p.productImages(product.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch product images: " + err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, ProductResponse{}.toProductResponse(&product))
+	reserved, err := p.reservedStock(product.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch reserved stock: " + err.Error()})
+		return
+	}
+
+	response := ProductResponse{}.toProductResponse(&product)
+	response.Images = images[product.ID]
+	response.AvailableToSell -= reserved[product.ID]
+	c.Header("ETag", productETag(&product))
+	c.JSON(http.StatusOK, response)
 }
 
 // @Summary Delete Product
-// @Description Delete a product by ID (admin only)
+// @Description Archive a product by ID (admin only). Archived products are hidden from the catalog but stay resolvable from past orders and can be restored.
 // @Tags Products
 // @Param id path string true "Product ID"
 // @Success 200 {object} api_errors.ApiError
@@ -254,14 +552,61 @@ func (p *Product) deleteProduct(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
 		return
 	}
-	err = p.server.queries.DeleteProduct(context.Background(), id)
-	if err == sql.ErrNoRows {
+	archived, err := p.server.queries.ArchiveProduct(context.Background(), id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete product: " + err.Error()})
+		return
+	}
+	if archived == 0 {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
 		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Product deleted successfully"})
+}
+
+// @Summary Restore Product
+// @Description Bring an archived product back into the catalog (admin only)
+// @Tags Products
+// @Produce json
+// @Param id path string true "Product ID"
+// @Success 200 {object} api_errors.ProductResponse
+// @Failure 400 {object} api_errors.ApiError
+// @Failure 404 {object} api_errors.ApiError
+// @Failure 500 {object} api_errors.ApiError
+// @Security BearerAuth
+// @Router /products/{id}/restore [post]
+func (p *Product) restoreProduct(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+
+	product, err := p.server.queries.RestoreProduct(context.Background(), id)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Archived product not found"})
+		return
 	} else if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete product: " + err.Error()})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to restore product: " + err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Product deleted successfully"})
+	images, err := p.productImages(product.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch product images: " + err.Error()})
+		return
+	}
+
+	reserved, err := p.reservedStock(product.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch reserved stock: " + err.Error()})
+		return
+	}
+
+	response := ProductResponse{}.toProductResponse(&product)
+	response.Images = images[product.ID]
+	response.AvailableToSell -= reserved[product.ID]
+	c.Header("ETag", productETag(&product))
+	c.JSON(http.StatusOK, response)
 }
@@ -0,0 +1,86 @@
+package api_errors
+
+import (
+	"context"
+	"log"
+	"time"
+
+	db "github.com/adedaryorh/ecommerceapi/db/sqlc"
+)
+
+const (
+	defaultReservationSweepInterval = time.Minute
+	reservationSweepBatchSize       = 100
+)
+
+// sweepReservations periodically expires pending orders whose stock
+// reservation ran out, handing the stock back to other shoppers. Pending
+// orders placed before reservations existed are expired once they are older
+// than ttl.
+func (s *Server) sweepReservations(ctx context.Context, interval, ttl time.Duration) {
+	if interval <= 0 {
+		interval = defaultReservationSweepInterval
+	}
+	if ttl <= 0 {
+		ttl = db.DefaultReservationTTL
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.expireReservations(ctx, ttl)
+		}
+	}
+}
+
+// expireReservations expires one batch of overdue orders and returns how
+// many were expired. Anything left over is picked up on the next tick.
+func (s *Server) expireReservations(ctx context.Context, ttl time.Duration) int {
+	orderIDs, err := s.queries.ListExpiredPendingOrders(ctx, db.ListExpiredPendingOrdersParams{
+		StaleBefore: time.Now().Add(-ttl),
+		PageLimit:   reservationSweepBatchSize,
+	})
+	if err != nil {
+		log.Printf("reservation sweep failed: %v", err)
+		return 0
+	}
+
+	expired := 0
+	for _, orderID := range orderIDs {
+		// An order paid or cancelled since it was listed is skipped.
+		ok, err := s.store.ExpireOrderTx(ctx, orderID)
+		if err != nil {
+			log.Printf("failed to expire order %d: %v", orderID, err)
+			continue
+		}
+		if ok {
+			expired++
+		}
+	}
+	if expired > 0 {
+		log.Printf("expired %d pending order(s)", expired)
+	}
+	return expired
+}
+
+// reservedStock returns the units of each product held by pending orders.
+// Variant reservations are not included; they draw on variant stock.
+func (p *Product) reservedStock(productIDs ...int64) (map[int64]int32, error) {
+	reserved := make(map[int64]int32, len(productIDs))
+	if len(productIDs) == 0 {
+		return reserved, nil
+	}
+
+	rows, err := p.server.queries.ListReservedProductStock(context.Background(), productIDs)
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		reserved[row.ProductID] = row.Reserved
+	}
+	return reserved, nil
+}
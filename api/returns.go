@@ -0,0 +1,465 @@
+package api_errors
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	db "github.com/adedaryorh/ecommerceapi/db/sqlc"
+	"github.com/adedaryorh/ecommerceapi/payment"
+	"github.com/gin-gonic/gin"
+)
+
+// ReturnItemParams defines how many units of an order item to send back.
+type ReturnItemParams struct {
+	OrderItemID int64 `json:"order_item_id" binding:"required"`
+	Quantity    int32 `json:"quantity" binding:"required,gt=0"`
+}
+
+// ReturnRequestParams defines a customer's return request.
+type ReturnRequestParams struct {
+	Reason string             `json:"reason" binding:"required"`
+	Items  []ReturnItemParams `json:"items" binding:"required,min=1,dive"`
+}
+
+// ReturnReviewParams defines an optional note an admin leaves on a return.
+type ReturnReviewParams struct {
+	Note *string `json:"note"`
+}
+
+// ReturnItemResponse defines a returned order line.
+type ReturnItemResponse struct {
+	OrderItemID int64  `json:"order_item_id"`
+	ProductID   int64  `json:"product_id"`
+	VariantID   *int64 `json:"variant_id,omitempty"`
+	ProductName string `json:"product_name"`
+	Price       string `json:"price"`
+	Quantity    int32  `json:"quantity"`
+}
+
+// RefundResponse defines money paid back against an order's payment.
+type RefundResponse struct {
+	ID               int64     `json:"id"`
+	PaymentID        int64     `json:"payment_id"`
+	Amount           string    `json:"amount"`
+	ProviderRefundID string    `json:"provider_refund_id"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+// ReturnResponse defines a return request. Items and refunds are left out
+// of listings.
+type ReturnResponse struct {
+	ID        int64                `json:"id"`
+	OrderID   int64                `json:"order_id"`
+	UserID    int64                `json:"user_id"`
+	Status    string               `json:"status"`
+	Reason    string               `json:"reason"`
+	AdminNote *string              `json:"admin_note,omitempty"`
+	Items     []ReturnItemResponse `json:"items,omitempty"`
+	Refunds   []RefundResponse     `json:"refunds,omitempty"`
+	CreatedAt time.Time            `json:"created_at"`
+	UpdatedAt time.Time            `json:"updated_at"`
+}
+
+// Converts a db.Return and, optionally, its items and refunds to a ReturnResponse.
+func (r ReturnResponse) toReturnResponse(ret *db.Return, items []db.ListReturnItemsRow, refunds []db.Refund) ReturnResponse {
+	response := ReturnResponse{
+		ID:        ret.ID,
+		OrderID:   ret.OrderID,
+		UserID:    ret.UserID,
+		Status:    ret.Status,
+		Reason:    ret.Reason,
+		CreatedAt: ret.CreatedAt,
+		UpdatedAt: ret.UpdatedAt,
+	}
+	if ret.AdminNote.Valid {
+		response.AdminNote = &ret.AdminNote.String
+	}
+	for _, item := range items {
+		line := ReturnItemResponse{
+			OrderItemID: item.OrderItemID,
+			ProductID:   item.ProductID,
+			ProductName: item.ProductName,
+			Price:       item.Price,
+			Quantity:    item.Quantity,
+		}
+		if item.VariantID.Valid {
+			line.VariantID = &item.VariantID.Int64
+		}
+		response.Items = append(response.Items, line)
+	}
+	for _, refund := range refunds {
+		response.Refunds = append(response.Refunds, RefundResponse{
+			ID:               refund.ID,
+			PaymentID:        refund.PaymentID,
+			Amount:           refund.Amount,
+			ProviderRefundID: refund.ProviderRefundID,
+			CreatedAt:        refund.CreatedAt,
+		})
+	}
+	return response
+}
+
+// gatewayError marks a failure reported by the payment provider.
+type gatewayError struct {
+	err error
+}
+
+func (e *gatewayError) Error() string {
+	return e.err.Error()
+}
+
+func (e *gatewayError) Unwrap() error {
+	return e.err
+}
+
+// refundThroughProvider refunds a payment through the configured provider.
+// The reference names the return or order being refunded, so retrying after
+// a failure cannot pay it out twice.
+func (s *Server) refundThroughProvider(reference string) db.RefundFunc {
+	return func(ctx context.Context, paid db.Payment, amount int64) (string, error) {
+		if paid.Provider != s.payments.Name() {
+			return "", &gatewayError{fmt.Errorf("payment %d was taken by %s, which is not configured", paid.ID, paid.Provider)}
+		}
+		refund, err := s.payments.Refund(ctx, payment.RefundRequest{
+			TransactionID: paid.ProviderTransactionID,
+			Amount:        amount,
+			Reference:     reference,
+		})
+		if err != nil {
+			return "", &gatewayError{err}
+		}
+		return refund.ID, nil
+	}
+}
+
+// returnError maps return failures to HTTP responses.
+func (s *Server) returnError(c *gin.Context, err error) {
+	var transitionErr *db.InvalidReturnTransitionError
+	var quantityErr *db.ReturnQuantityError
+	switch {
+	case errors.Is(err, db.ErrOrderNotFound), errors.Is(err, db.ErrReturnNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, db.ErrEmptyReturn), errors.As(err, &quantityErr):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, db.ErrOrderNotReturnable), errors.As(err, &transitionErr):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		s.orderTransitionError(c, err)
+	}
+}
+
+// @Summary Request Return
+// @Description Ask to return units of one of the authenticated user's delivered orders
+// @Tags Returns
+// @Accept json
+// @Produce json
+// @Param id path string true "Order ID"
+// @Param return body ReturnRequestParams true "Items to return and why"
+// @Success 201 {object} ReturnResponse
+// @Failure 400 {object} api_errors.ApiError "Invalid items or quantities"
+// @Failure 404 {object} api_errors.ApiError
+// @Failure 409 {object} api_errors.ApiError "Order has not been delivered"
+// @Failure 500 {object} api_errors.ApiError
+// @Security BearerAuth
+// @Router /orders/{id}/returns [post]
+func (s *Server) RequestReturn(c *gin.Context) {
+	userID, ok := authenticatedUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	orderID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid order ID"})
+		return
+	}
+
+	var params ReturnRequestParams
+	if err := c.ShouldBindJSON(&params); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	arg := db.RequestReturnTxParams{
+		OrderID: orderID,
+		UserID:  userID,
+		Reason:  params.Reason,
+		Items:   []db.ReturnItemParams{},
+	}
+	for _, item := range params.Items {
+		arg.Items = append(arg.Items, db.ReturnItemParams{
+			OrderItemID: item.OrderItemID,
+			Quantity:    item.Quantity,
+		})
+	}
+
+	result, err := s.store.RequestReturnTx(context.Background(), arg)
+	if err != nil {
+		s.returnError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, ReturnResponse{}.toReturnResponse(&result.Return, result.Items, nil))
+}
+
+// @Summary List Order Returns
+// @Description List the returns requested for an order (owner or admin)
+// @Tags Returns
+// @Produce json
+// @Param id path string true "Order ID"
+// @Success 200 {array} ReturnResponse
+// @Failure 400 {object} api_errors.ApiError
+// @Failure 404 {object} api_errors.ApiError
+// @Failure 500 {object} api_errors.ApiError
+// @Security BearerAuth
+// @Router /orders/{id}/returns [get]
+func (s *Server) ListOrderReturns(c *gin.Context) {
+	userID, ok := authenticatedUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	orderID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid order ID"})
+		return
+	}
+
+	order, err := s.queries.GetOrderByID(context.Background(), orderID)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if order.UserID != userID {
+		admin, err := s.isAdmin(userID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if !admin {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
+			return
+		}
+	}
+
+	returns, err := s.queries.ListOrderReturns(context.Background(), order.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	response := []ReturnResponse{}
+	for _, ret := range returns {
+		item, err := s.returnResponse(&ret)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		response = append(response, item)
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// returnResponse builds the full view of a return with its items and refunds.
+func (s *Server) returnResponse(ret *db.Return) (ReturnResponse, error) {
+	items, err := s.queries.ListReturnItems(context.Background(), ret.ID)
+	if err != nil {
+		return ReturnResponse{}, err
+	}
+	refunds, err := s.queries.ListReturnRefunds(context.Background(), sql.NullInt64{Int64: ret.ID, Valid: true})
+	if err != nil {
+		return ReturnResponse{}, err
+	}
+	return ReturnResponse{}.toReturnResponse(ret, items, refunds), nil
+}
+
+// @Summary List Returns
+// @Description List return requests, newest first (admin only)
+// @Tags Returns
+// @Produce json
+// @Param status query string false "Only returns in this status" Enums(requested, approved, rejected, received)
+// @Param limit query int false "Number of returns to retrieve" default(50)
+// @Param offset query int false "Offset for pagination" default(0)
+// @Success 200 {array} ReturnResponse
+// @Failure 400 {object} api_errors.ApiError
+// @Failure 500 {object} api_errors.ApiError
+// @Security BearerAuth
+// @Router /admin/returns [get]
+func (s *Server) ListReturns(c *gin.Context) {
+	arg := db.ListReturnsParams{
+		PageLimit:  50,
+		PageOffset: 0,
+	}
+	if l, err := strconv.Atoi(c.Query("limit")); err == nil && l > 0 {
+		arg.PageLimit = int32(l)
+	}
+	if o, err := strconv.Atoi(c.Query("offset")); err == nil && o >= 0 {
+		arg.PageOffset = int32(o)
+	}
+	if v := c.Query("status"); v != "" {
+		if !db.ReturnStatus(v).Valid() {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid return status"})
+			return
+		}
+		arg.Status = sql.NullString{String: v, Valid: true}
+	}
+
+	returns, err := s.queries.ListReturns(context.Background(), arg)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	response := []ReturnResponse{}
+	for _, ret := range returns {
+		response = append(response, ReturnResponse{}.toReturnResponse(&ret, nil, nil))
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// @Summary Get Return
+// @Description Retrieve a return with its items and refunds (admin only)
+// @Tags Returns
+// @Produce json
+// @Param id path string true "Return ID"
+// @Success 200 {object} ReturnResponse
+// @Failure 400 {object} api_errors.ApiError
+// @Failure 404 {object} api_errors.ApiError
+// @Failure 500 {object} api_errors.ApiError
+// @Security BearerAuth
+// @Router /admin/returns/{id} [get]
+func (s *Server) GetReturn(c *gin.Context) {
+	returnID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid return ID"})
+		return
+	}
+
+	ret, err := s.queries.GetReturnByID(context.Background(), returnID)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Return not found"})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	response, err := s.returnResponse(&ret)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// @Summary Approve Return
+// @Description Approve a requested return and refund its share of the order's payment (admin only)
+// @Tags Returns
+// @Accept json
+// @Produce json
+// @Param id path string true "Return ID"
+// @Param review body ReturnReviewParams false "Note for the customer"
+// @Success 200 {object} ReturnResponse
+// @Failure 400 {object} api_errors.ApiError
+// @Failure 404 {object} api_errors.ApiError
+// @Failure 409 {object} api_errors.ApiError "Return is not awaiting review"
+// @Failure 502 {object} api_errors.ApiError "Payment provider error"
+// @Failure 500 {object} api_errors.ApiError
+// @Security BearerAuth
+// @Router /admin/returns/{id}/approve [post]
+func (s *Server) ApproveReturn(c *gin.Context) {
+	s.reviewReturn(c, func(arg db.ReviewReturnTxParams) (db.ReturnTxResult, error) {
+		arg.Refund = s.refundThroughProvider(fmt.Sprintf("return-%d", arg.ReturnID))
+		return s.store.ApproveReturnTx(context.Background(), arg)
+	})
+}
+
+// @Summary Reject Return
+// @Description Reject a requested return (admin only)
+// @Tags Returns
+// @Accept json
+// @Produce json
+// @Param id path string true "Return ID"
+// @Param review body ReturnReviewParams false "Note for the customer"
+// @Success 200 {object} ReturnResponse
+// @Failure 400 {object} api_errors.ApiError
+// @Failure 404 {object} api_errors.ApiError
+// @Failure 409 {object} api_errors.ApiError "Return is not awaiting review"
+// @Failure 500 {object} api_errors.ApiError
+// @Security BearerAuth
+// @Router /admin/returns/{id}/reject [post]
+func (s *Server) RejectReturn(c *gin.Context) {
+	s.reviewReturn(c, func(arg db.ReviewReturnTxParams) (db.ReturnTxResult, error) {
+		return s.store.RejectReturnTx(context.Background(), arg)
+	})
+}
+
+// @Summary Receive Return
+// @Description Record that the goods of an approved return arrived and put them back in stock (admin only)
+// @Tags Returns
+// @Accept json
+// @Produce json
+// @Param id path string true "Return ID"
+// @Param review body ReturnReviewParams false "Note for the customer"
+// @Success 200 {object} ReturnResponse
+// @Failure 400 {object} api_errors.ApiError
+// @Failure 404 {object} api_errors.ApiError
+// @Failure 409 {object} api_errors.ApiError "Return has not been approved"
+// @Failure 500 {object} api_errors.ApiError
+// @Security BearerAuth
+// @Router /admin/returns/{id}/receive [post]
+func (s *Server) ReceiveReturn(c *gin.Context) {
+	s.reviewReturn(c, func(arg db.ReviewReturnTxParams) (db.ReturnTxResult, error) {
+		return s.store.ReceiveReturnTx(context.Background(), arg)
+	})
+}
+
+// reviewReturn parses an admin's return decision, applies it with review
+// and responds with the updated return.
+func (s *Server) reviewReturn(c *gin.Context, review func(arg db.ReviewReturnTxParams) (db.ReturnTxResult, error)) {
+	returnID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid return ID"})
+		return
+	}
+
+	// The note is optional, so an empty body is accepted.
+	var params ReturnReviewParams
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&params); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	arg := db.ReviewReturnTxParams{
+		ReturnID:   returnID,
+		ReviewedBy: actingUser(c),
+	}
+	if params.Note != nil {
+		arg.Note = sql.NullString{String: *params.Note, Valid: true}
+	}
+
+	result, err := review(arg)
+	if err != nil {
+		s.returnError(c, err)
+		return
+	}
+
+	response, err := s.returnResponse(&result.Return)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
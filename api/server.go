@@ -1,13 +1,20 @@
 package api_errors
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
+	"log"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
 	"net/http"
 
+	"github.com/adedaryorh/ecommerceapi/db/migrations"
 	db "github.com/adedaryorh/ecommerceapi/db/sqlc"
+	"github.com/adedaryorh/ecommerceapi/notify"
+	"github.com/adedaryorh/ecommerceapi/payment"
+	"github.com/adedaryorh/ecommerceapi/storage"
+	"github.com/adedaryorh/ecommerceapi/tax"
 	"github.com/adedaryorh/ecommerceapi/utils"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
@@ -19,9 +26,14 @@ import (
 
 type Server struct {
 	queries         *db.Queries
+	store           *db.Store
 	router          *gin.Engine
 	config          *utils.Config
 	tokenController *utils.JWTToken
+	storage         storage.Storage
+	notifier        notify.Notifier
+	payments        payment.PaymentProvider
+	taxes           tax.Calculator
 }
 
 var gValid = galidator.New().CustomMessages(
@@ -33,7 +45,8 @@ var gValid = galidator.New().CustomMessages(
 func myCorsHandler() gin.HandlerFunc {
 	config := cors.DefaultConfig()
 	config.AllowAllOrigins = true
-	config.AllowHeaders = append(config.AllowHeaders, "Authorization")
+	config.AllowHeaders = append(config.AllowHeaders, "Authorization", "If-Match", "Idempotency-Key")
+	config.ExposeHeaders = append(config.ExposeHeaders, "ETag", "Idempotent-Replayed")
 	return cors.New(config)
 }
 
@@ -46,17 +59,55 @@ func NewServer(envPath string) *Server {
 	if err != nil {
 		panic(fmt.Sprintf("Error connecting to DB: %v", err))
 	}
+	if config.Auto_migrate {
+		migrator, err := migrations.NewMigrator(conn)
+		if err != nil {
+			panic(fmt.Sprintf("Error loading migrations: %v", err))
+		}
+		applied, err := migrator.Up(context.Background())
+		if err != nil {
+			panic(fmt.Sprintf("Error migrating DB: %v", err))
+		}
+		log.Printf("applied %d migration(s)", applied)
+	}
 	tokenController := utils.NewJWTToken(config)
+	fileStorage, err := storage.NewLocalStorage(config.Storage_dir, config.Storage_url)
+	if err != nil {
+		panic(fmt.Sprintf("Error preparing file storage: %v", err))
+	}
+
+	var notifier notify.Notifier = notify.NewLogNotifier()
+	if config.Notify_file != "" {
+		notifier, err = notify.NewFileNotifier(config.Notify_file)
+		if err != nil {
+			panic(fmt.Sprintf("Error opening notification file: %v", err))
+		}
+	}
+
+	payments, err := payment.NewProvider(config.Payment_provider)
+	if err != nil {
+		panic(fmt.Sprintf("Error configuring payments: %v", err))
+	}
+
+	taxes, err := tax.NewCalculator(config.Tax_rates_file)
+	if err != nil {
+		panic(fmt.Sprintf("Error loading tax rates: %v", err))
+	}
 
-	q := db.New(conn)
+	store := db.NewStore(conn)
 	g := gin.Default()
 	g.Use(myCorsHandler())
 
 	return &Server{
-		queries:         q,
+		queries:         store.Queries,
+		store:           store,
 		router:          g,
 		config:          config,
 		tokenController: tokenController,
+		storage:         fileStorage,
+		notifier:        notifier,
+		payments:        payments,
+		taxes:           taxes,
 	}
 }
 
@@ -68,6 +119,9 @@ func (s *Server) initializeRoutes() {
 		ginSwagger.WrapHandler(swaggerFiles.Handler)(c)
 	})
 	
+	// Uploaded files such as product images are served from local storage.
+	router.Static(s.config.Storage_url, s.config.Storage_dir)
+
 	//router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 	// Public routes (accessible by any authenticated user)
 	// Public routes (accessible by any authenticated user)
@@ -77,12 +131,29 @@ func (s *Server) initializeRoutes() {
 	// @Accept json
 	// @Produce json
 	// @Param order body OrderParams true "Order Details"
+	// @Param Idempotency-Key header string false "Key that makes retries of this request safe"
 	// @Success 201 {object} OrderResponse
 	// @Failure 400 {object} api_errors.ApiError
+	// @Failure 404 {object} api_errors.ApiError
+	// @Failure 422 {object} api_errors.ApiError
 	// @Failure 500 {object} api_errors.ApiError
 	// @Security BearerAuth
 	// @Router /orders [post]
-	router.POST("/orders", s.AuthenticatedMiddleware(), s.CreateOrder)
+	router.POST("/orders", s.AuthenticatedMiddleware(), s.IdempotencyMiddleware(), s.CreateOrder)
+	// @Summary Quote Order
+	// @Description Price a prospective order, including discount and tax, without placing it
+	// @Tags Orders
+	// @Accept json
+	// @Produce json
+	// @Param order body OrderParams true "Order Details"
+	// @Success 200 {object} QuoteResponse
+	// @Failure 400 {object} api_errors.ApiError
+	// @Failure 404 {object} api_errors.ApiError
+	// @Failure 422 {object} api_errors.ApiError
+	// @Failure 500 {object} api_errors.ApiError
+	// @Security BearerAuth
+	// @Router /orders/quote [post]
+	router.POST("/orders/quote", s.AuthenticatedMiddleware(), s.QuoteOrder)
 	// @Summary List User Orders
 	// @Description Retrieve a list of orders placed by the authenticated user
 	// @Tags Orders
@@ -92,11 +163,96 @@ func (s *Server) initializeRoutes() {
 	// @Security BearerAuth
 	// @Router /orders [get]
 	router.GET("/orders", s.AuthenticatedMiddleware(), s.ListUserOrders)
+	// @Summary Get Order
+	// @Description Retrieve an order with its line items and totals (owner or admin)
+	// @Tags Orders
+	// @Produce json
+	// @Param id path string true "Order ID"
+	// @Success 200 {object} OrderResponse
+	// @Failure 400 {object} api_errors.ApiError
+	// @Failure 404 {object} api_errors.ApiError
+	// @Failure 500 {object} api_errors.ApiError
+	// @Security BearerAuth
+	// @Router /orders/{id} [get]
+	router.GET("/orders/:id", s.AuthenticatedMiddleware(), s.GetOrder)
+	// @Summary Cancel My Order
+	// @Description Cancel one of the authenticated user's pending orders
+	// @Tags Orders
+	// @Param id path string true "Order ID"
+	// @Success 200 {object} OrderResponse
+	// @Failure 400 {object} api_errors.ApiError
+	// @Failure 404 {object} api_errors.ApiError
+	// @Failure 409 {object} api_errors.ApiError
+	// @Failure 500 {object} api_errors.ApiError
+	// @Security BearerAuth
+	// @Router /orders/{id}/cancel [post]
+	router.POST("/orders/:id/cancel", s.AuthenticatedMiddleware(), s.CancelUserOrder)
+	// @Summary Pay Order
+	// @Description Charge one of the authenticated user's pending orders and mark it Paid
+	// @Tags Orders
+	// @Param id path string true "Order ID"
+	// @Param Idempotency-Key header string false "Key that makes retries of this request safe"
+	// @Success 201 {object} PayOrderResponse
+	// @Failure 400 {object} api_errors.ApiError
+	// @Failure 402 {object} api_errors.ApiError
+	// @Failure 404 {object} api_errors.ApiError
+	// @Failure 409 {object} api_errors.ApiError
+	// @Failure 422 {object} api_errors.ApiError
+	// @Failure 502 {object} api_errors.ApiError
+	// @Security BearerAuth
+	// @Router /orders/{id}/pay [post]
+	router.POST("/orders/:id/pay", s.AuthenticatedMiddleware(), s.IdempotencyMiddleware(), s.PayOrder)
+	// @Summary Request Return
+	// @Description Ask to return units of one of the authenticated user's delivered orders
+	// @Tags Returns
+	// @Param id path string true "Order ID"
+	// @Success 201 {object} ReturnResponse
+	// @Failure 400 {object} api_errors.ApiError
+	// @Failure 404 {object} api_errors.ApiError
+	// @Failure 409 {object} api_errors.ApiError
+	// @Security BearerAuth
+	// @Router /orders/{id}/returns [post]
+	router.POST("/orders/:id/returns", s.AuthenticatedMiddleware(), s.RequestReturn)
+	// @Summary List Order Returns
+	// @Description List the returns requested for an order (owner or admin)
+	// @Tags Returns
+	// @Param id path string true "Order ID"
+	// @Success 200 {array} ReturnResponse
+	// @Failure 404 {object} api_errors.ApiError
+	// @Security BearerAuth
+	// @Router /orders/{id}/returns [get]
+	router.GET("/orders/:id/returns", s.AuthenticatedMiddleware(), s.ListOrderReturns)
+	// @Summary Payment Webhook
+	// @Description Receive a signed payment event from the payment provider
+	// @Tags Payments
+	// @Success 200 {object} db.ProcessPaymentEventTxResult
+	// @Failure 400 {object} api_errors.ApiError
+	// @Failure 401 {object} api_errors.ApiError
+	// @Router /webhooks/payments [post]
+	router.POST("/webhooks/payments", s.PaymentWebhook)
 
 	// Admin routes (only accessible by admins)
 	adminRoutes := router.Group("/admin")
 	adminRoutes.Use(s.AuthenticatedMiddleware(), RoleBasedMiddleware(s, "admin"))
 	{
+		// @Summary Search Orders
+		// @Description Search all orders with filters and keyset pagination (admin only)
+		// @Tags Orders
+		// @Success 200 {object} OrderSearchResponse
+		// @Failure 400 {object} api_errors.ApiError
+		// @Failure 500 {object} api_errors.ApiError
+		// @Security BearerAuth
+		// @Router /admin/orders [get]
+		adminRoutes.GET("/orders", s.SearchOrders)
+		// @Summary Order Summary
+		// @Description Order counts and revenue grouped by status and day (admin only)
+		// @Tags Orders
+		// @Success 200 {object} OrderSummaryResponse
+		// @Failure 400 {object} api_errors.ApiError
+		// @Failure 500 {object} api_errors.ApiError
+		// @Security BearerAuth
+		// @Router /admin/orders/summary [get]
+		adminRoutes.GET("/orders/summary", s.OrderSummary)
 		// @Summary Cancel Order
 		// @Description Cancel an order by ID (admin only)
 		// @Tags Orders
@@ -113,13 +269,123 @@ func (s *Server) initializeRoutes() {
 		// @Tags Orders
 		// @Param id path string true "Order ID"
 		// @Param status body string true "New Order Status"
-		// @Success 200 {object}
+		// @Success 200 {object} OrderResponse
 		// @Failure 400 {object} api_errors.ApiError
 		// @Failure 404 {object} api_errors.ApiError
 		// @Failure 500 {object} api_errors.ApiError
 		// @Security BearerAuth
 		// @Router /admin/orders/{id}/status [patch]
 		adminRoutes.PATCH("/orders/:id/status", s.UpdateOrderStatus)
+		// @Summary Get Order Status History
+		// @Description List every status change recorded for an order (admin only)
+		// @Tags Orders
+		// @Param id path string true "Order ID"
+		// @Success 200 {array} db.OrderStatusHistory
+		// @Failure 400 {object} api_errors.ApiError
+		// @Failure 404 {object} api_errors.ApiError
+		// @Failure 500 {object} api_errors.ApiError
+		// @Security BearerAuth
+		// @Router /admin/orders/{id}/history [get]
+		adminRoutes.GET("/orders/:id/history", s.ListOrderStatusHistory)
+		// @Summary Low Stock Report
+		// @Description List products whose stock, including their variants', is below their reorder threshold (admin only)
+		// @Tags Inventory
+		// @Param limit query int false "Number of products to retrieve" default(50)
+		// @Param offset query int false "Offset for pagination" default(0)
+		// @Success 200 {array} LowStockProductResponse
+		// @Failure 500 {object} api_errors.ApiError
+		// @Security BearerAuth
+		// @Router /admin/inventory/low-stock [get]
+		adminRoutes.GET("/inventory/low-stock", s.ListLowStockProducts)
+		// @Summary List Returns
+		// @Description List return requests, newest first (admin only)
+		// @Tags Returns
+		// @Success 200 {array} ReturnResponse
+		// @Failure 400 {object} api_errors.ApiError
+		// @Security BearerAuth
+		// @Router /admin/returns [get]
+		adminRoutes.GET("/returns", s.ListReturns)
+		// @Summary Get Return
+		// @Description Retrieve a return with its items and refunds (admin only)
+		// @Tags Returns
+		// @Param id path string true "Return ID"
+		// @Success 200 {object} ReturnResponse
+		// @Failure 404 {object} api_errors.ApiError
+		// @Security BearerAuth
+		// @Router /admin/returns/{id} [get]
+		adminRoutes.GET("/returns/:id", s.GetReturn)
+		// @Summary Approve Return
+		// @Description Approve a requested return and refund it (admin only)
+		// @Tags Returns
+		// @Param id path string true "Return ID"
+		// @Success 200 {object} ReturnResponse
+		// @Failure 409 {object} api_errors.ApiError
+		// @Failure 502 {object} api_errors.ApiError
+		// @Security BearerAuth
+		// @Router /admin/returns/{id}/approve [post]
+		adminRoutes.POST("/returns/:id/approve", s.ApproveReturn)
+		// @Summary Reject Return
+		// @Description Reject a requested return (admin only)
+		// @Tags Returns
+		// @Param id path string true "Return ID"
+		// @Success 200 {object} ReturnResponse
+		// @Failure 409 {object} api_errors.ApiError
+		// @Security BearerAuth
+		// @Router /admin/returns/{id}/reject [post]
+		adminRoutes.POST("/returns/:id/reject", s.RejectReturn)
+		// @Summary Receive Return
+		// @Description Put the goods of an approved return back in stock (admin only)
+		// @Tags Returns
+		// @Param id path string true "Return ID"
+		// @Success 200 {object} ReturnResponse
+		// @Failure 409 {object} api_errors.ApiError
+		// @Security BearerAuth
+		// @Router /admin/returns/{id}/receive [post]
+		adminRoutes.POST("/returns/:id/receive", s.ReceiveReturn)
+		// @Summary Create Coupon
+		// @Description Create a discount code (admin only)
+		// @Tags Coupons
+		// @Success 201 {object} CouponResponse
+		// @Failure 400 {object} api_errors.ApiError
+		// @Failure 409 {object} api_errors.ApiError
+		// @Security BearerAuth
+		// @Router /admin/coupons [post]
+		adminRoutes.POST("/coupons", s.CreateCoupon)
+		// @Summary List Coupons
+		// @Description List coupons, newest first (admin only)
+		// @Tags Coupons
+		// @Success 200 {array} CouponResponse
+		// @Security BearerAuth
+		// @Router /admin/coupons [get]
+		adminRoutes.GET("/coupons", s.ListCoupons)
+		// @Summary Get Coupon
+		// @Description Retrieve a coupon and its scope (admin only)
+		// @Tags Coupons
+		// @Param id path string true "Coupon ID"
+		// @Success 200 {object} CouponResponse
+		// @Failure 404 {object} api_errors.ApiError
+		// @Security BearerAuth
+		// @Router /admin/coupons/{id} [get]
+		adminRoutes.GET("/coupons/:id", s.GetCoupon)
+		// @Summary Update Coupon
+		// @Description Replace a coupon and its scope (admin only)
+		// @Tags Coupons
+		// @Param id path string true "Coupon ID"
+		// @Success 200 {object} CouponResponse
+		// @Failure 400 {object} api_errors.ApiError
+		// @Failure 404 {object} api_errors.ApiError
+		// @Security BearerAuth
+		// @Router /admin/coupons/{id} [put]
+		adminRoutes.PUT("/coupons/:id", s.UpdateCoupon)
+		// @Summary Delete Coupon
+		// @Description Delete a coupon that has never been used (admin only)
+		// @Tags Coupons
+		// @Param id path string true "Coupon ID"
+		// @Failure 404 {object} api_errors.ApiError
+		// @Failure 409 {object} api_errors.ApiError
+		// @Security BearerAuth
+		// @Router /admin/coupons/{id} [delete]
+		adminRoutes.DELETE("/coupons/:id", s.DeleteCoupon)
 	}
 
 	// Assign router to the server instance
@@ -134,7 +400,11 @@ func (s *Server) Start(port int) {
 	User{}.router(s)
 	Auth{}.router(s)
 	(&Product{}).router(s)
+	(&Cart{}).router(s)
 	s.initializeRoutes()
 
+	go s.watchLowStock(context.Background(), s.config.Low_stock_interval)
+	go s.sweepReservations(context.Background(), s.config.Reservation_sweep_interval, s.config.Reservation_ttl)
+
 	s.router.Run(fmt.Sprintf(":%v", port))
 }
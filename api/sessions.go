@@ -44,6 +44,10 @@ func (server *Server) CreateSession(c *gin.Context) {
 
 	session, err := server.queries.CreateSession(c, arg)
 	if err != nil {
+		if status, message, ok := dbErrorResponse(err); ok {
+			c.JSON(status, gin.H{"error": message})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create session"})
 		return
 	}
@@ -23,12 +23,15 @@ func (u User) router(server *Server) {
 	serverGroup.GET("", u.listUsers)
 	serverGroup.GET("/:id", u.getUserByID)
 	serverGroup.GET("/me", u.getLoggedInUser)
-	serverGroup.DELETE("/:id", u.deleteUser)
 	serverGroup.PUT("/:id/password", u.updateUserPassword)
+
+	adminGroup := server.router.Group("/users", u.server.AuthenticatedMiddleware(), RoleBasedMiddleware(server, "admin"))
+	adminGroup.DELETE("/:id", u.deleteUser)
+	adminGroup.POST("/:id/restore", u.restoreUser)
 }
 
 // @Summary Delete User
-// @Description Delete a user account
+// @Description Archive a user account (admin only). The user can no longer sign in, but their orders are kept and the account can be restored.
 // @Tags Users
 // @Produce json
 // @Param id path string true "User ID"
@@ -40,30 +43,55 @@ func (u User) router(server *Server) {
 // @Security BearerAuth
 // @Router /users/{id} [delete]
 func (u *User) deleteUser(c *gin.Context) {
-	// Check if the logged-in user is an admin
-	value, exists := c.Get("user_role")
-	if !exists || value.(string) != "admin" {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Only administrators can delete users"})
+	id := c.Param("id")
+	userID, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
 		return
 	}
 
-	id := c.Param("id")
-	userID, err := strconv.ParseInt(id, 10, 64)
+	archived, err := u.server.queries.ArchiveUser(context.Background(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if archived == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// @Summary Restore User
+// @Description Reactivate an archived user account (admin only)
+// @Tags Users
+// @Produce json
+// @Param id path string true "User ID"
+// @Success 200 {object} UserResponse
+// @Failure 400 {object} api_errors.ApiError
+// @Failure 403 {object} api_errors.ApiError
+// @Failure 404 {object} api_errors.ApiError
+// @Failure 500 {object} api_errors.ApiError
+// @Security BearerAuth
+// @Router /users/{id}/restore [post]
+func (u *User) restoreUser(c *gin.Context) {
+	userID, err := strconv.ParseInt(c.Param("id"), 10, 64)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
 		return
 	}
 
-	err = u.server.queries.DeleteUser(context.Background(), userID)
+	user, err := u.server.queries.RestoreUser(context.Background(), userID)
 	if err == sql.ErrNoRows {
-		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		c.JSON(http.StatusNotFound, gin.H{"error": "Archived user not found"})
 		return
 	} else if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.Status(http.StatusNoContent)
+	c.JSON(http.StatusOK, UserResponse{}.toUserResponse(&user))
 }
 
 type UpdatePasswordRequest struct {
@@ -198,10 +226,16 @@ func (u *User) updateUserPassword(c *gin.Context) {
 	}
 
 	// Check if the logged-in user is updating their own password or is an admin
-	userRole, _ := c.Get("user_role")
-	if loggedInUserID.(int64) != targetUserID && userRole.(string) != "admin" {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Cannot update another user's password"})
-		return
+	if loggedInUserID.(int64) != targetUserID {
+		admin, err := u.server.isAdmin(loggedInUserID.(int64))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if !admin {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Cannot update another user's password"})
+			return
+		}
 	}
 
 	var req UpdatePasswordRequest
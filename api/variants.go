@@ -0,0 +1,307 @@
+package api_errors
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	db "github.com/adedaryorh/ecommerceapi/db/sqlc"
+	"github.com/adedaryorh/ecommerceapi/utils"
+	"github.com/gin-gonic/gin"
+)
+
+var errInvalidPrice = errors.New("price must be a positive amount")
+
+// VariantParams defines the expected input for variant operations.
+// Price overrides the product price when set.
+type VariantParams struct {
+	SKU        string            `json:"sku" binding:"required"`
+	Attributes map[string]string `json:"attributes"`
+	Price      *string           `json:"price"`
+	Stock      int32             `json:"stock" binding:"gte=0"`
+}
+
+// VariantResponse defines the admin view of a product variant.
+type VariantResponse struct {
+	ID         int64           `json:"id"`
+	ProductID  int64           `json:"product_id"`
+	SKU        string          `json:"sku"`
+	Attributes json.RawMessage `json:"attributes"`
+	Price      *string         `json:"price"`
+	Stock      int32           `json:"stock"`
+	CreatedAt  time.Time       `json:"created_at"`
+	UpdatedAt  time.Time       `json:"updated_at"`
+}
+
+// Converts a db.ProductVariant to a VariantResponse.
+func (v VariantResponse) toVariantResponse(variant *db.ProductVariant) VariantResponse {
+	var price *string
+	if variant.Price.Valid {
+		price = &variant.Price.String
+	}
+	return VariantResponse{
+		ID:         variant.ID,
+		ProductID:  variant.ProductID,
+		SKU:        variant.Sku,
+		Attributes: variant.Attributes,
+		Price:      price,
+		Stock:      variant.Stock,
+		CreatedAt:  variant.CreatedAt,
+		UpdatedAt:  variant.UpdatedAt,
+	}
+}
+
+// PublicVariantResponse defines the storefront view of a variant. Price is
+// the effective price, falling back to the product's when not overridden.
+type PublicVariantResponse struct {
+	ID         int64           `json:"id"`
+	SKU        string          `json:"sku"`
+	Attributes json.RawMessage `json:"attributes"`
+	Price      string          `json:"price"`
+	InStock    bool            `json:"in_stock"`
+}
+
+// variantArgs validates variant input and converts it to column values.
+func (params VariantParams) variantArgs() (json.RawMessage, sql.NullString, error) {
+	attributes := params.Attributes
+	if attributes == nil {
+		attributes = map[string]string{}
+	}
+	data, err := json.Marshal(attributes)
+	if err != nil {
+		return nil, sql.NullString{}, err
+	}
+
+	var price sql.NullString
+	if params.Price != nil {
+		cents, err := utils.ParseCents(*params.Price)
+		if err != nil || cents <= 0 {
+			return nil, sql.NullString{}, errInvalidPrice
+		}
+		price = sql.NullString{String: utils.FormatCents(cents), Valid: true}
+	}
+
+	return data, price, nil
+}
+
+// @Summary List Product Variants
+// @Description Retrieve the variants of a product (public storefront)
+// @Tags Variants
+// @Produce json
+// @Param id path string true "Product ID"
+// @Success 200 {array} api_errors.PublicVariantResponse
+// @Failure 400 {object} api_errors.ApiError
+// @Failure 404 {object} api_errors.ApiError
+// @Failure 500 {object} api_errors.ApiError
+// @Router /products/{id}/variants [get]
+func (p *Product) listVariants(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+
+	product, err := p.server.queries.GetProductByID(context.Background(), id)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	variants, err := p.server.queries.ListProductVariants(context.Background(), id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list variants: " + err.Error()})
+		return
+	}
+	reservedRows, err := p.server.queries.ListReservedVariantStock(context.Background(), id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list reserved stock: " + err.Error()})
+		return
+	}
+	reserved := map[int64]int32{}
+	for _, row := range reservedRows {
+		reserved[row.VariantID] = row.Reserved
+	}
+
+	response := []PublicVariantResponse{}
+	for _, variant := range variants {
+		price := product.Price
+		if variant.Price.Valid {
+			price = variant.Price.String
+		}
+		response = append(response, PublicVariantResponse{
+			ID:         variant.ID,
+			SKU:        variant.Sku,
+			Attributes: variant.Attributes,
+			Price:      price,
+			InStock:    variant.Stock > reserved[variant.ID],
+		})
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// @Summary Create Product Variant
+// @Description Add a variant with its own SKU, attributes, stock and optional price override (admin only)
+// @Tags Variants
+// @Accept json
+// @Produce json
+// @Param id path string true "Product ID"
+// @Param variant body VariantParams true "Variant Details"
+// @Success 201 {object} api_errors.VariantResponse
+// @Failure 400 {object} api_errors.ApiError
+// @Failure 404 {object} api_errors.ApiError
+// @Failure 409 {object} api_errors.ApiError
+// @Failure 500 {object} api_errors.ApiError
+// @Security BearerAuth
+// @Router /products/{id}/variants [post]
+func (p *Product) createVariant(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+
+	var params VariantParams
+	if err := c.ShouldBindJSON(&params); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	attributes, price, err := params.variantArgs()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	_, err = p.server.queries.GetProductByID(context.Background(), id)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	variant, err := p.server.store.CreateProductVariantTx(context.Background(), db.CreateProductVariantTxParams{
+		CreateProductVariantParams: db.CreateProductVariantParams{
+			ProductID:  id,
+			Sku:        params.SKU,
+			Attributes: attributes,
+			Price:      price,
+			Stock:      params.Stock,
+		},
+		CreatedBy: actingUser(c),
+	})
+	if err != nil {
+		dbError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, VariantResponse{}.toVariantResponse(&variant))
+}
+
+// @Summary Update Product Variant
+// @Description Replace a variant's SKU, attributes, price override and stock (admin only)
+// @Tags Variants
+// @Accept json
+// @Produce json
+// @Param id path string true "Product ID"
+// @Param variant_id path string true "Variant ID"
+// @Param variant body VariantParams true "Variant Details"
+// @Success 200 {object} api_errors.VariantResponse
+// @Failure 400 {object} api_errors.ApiError
+// @Failure 404 {object} api_errors.ApiError
+// @Failure 409 {object} api_errors.ApiError
+// @Failure 500 {object} api_errors.ApiError
+// @Security BearerAuth
+// @Router /products/{id}/variants/{variant_id} [put]
+func (p *Product) updateVariant(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+	variantID, err := strconv.ParseInt(c.Param("variant_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid variant ID"})
+		return
+	}
+
+	var params VariantParams
+	if err := c.ShouldBindJSON(&params); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	attributes, price, err := params.variantArgs()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	variant, err := p.server.store.UpdateProductVariantTx(context.Background(), db.UpdateProductVariantTxParams{
+		UpdateProductVariantParams: db.UpdateProductVariantParams{
+			Sku:        params.SKU,
+			Attributes: attributes,
+			Price:      price,
+			Stock:      params.Stock,
+			ID:         variantID,
+			ProductID:  id,
+		},
+		UpdatedBy: actingUser(c),
+	})
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Variant not found"})
+		return
+	} else if err != nil {
+		dbError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, VariantResponse{}.toVariantResponse(&variant))
+}
+
+// @Summary Delete Product Variant
+// @Description Delete a variant that has never been ordered (admin only)
+// @Tags Variants
+// @Param id path string true "Product ID"
+// @Param variant_id path string true "Variant ID"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} api_errors.ApiError
+// @Failure 404 {object} api_errors.ApiError
+// @Failure 409 {object} api_errors.ApiError
+// @Failure 500 {object} api_errors.ApiError
+// @Security BearerAuth
+// @Router /products/{id}/variants/{variant_id} [delete]
+func (p *Product) deleteVariant(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+	variantID, err := strconv.ParseInt(c.Param("variant_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid variant ID"})
+		return
+	}
+
+	deleted, err := p.server.queries.DeleteProductVariant(context.Background(), db.DeleteProductVariantParams{
+		ID:        variantID,
+		ProductID: id,
+	})
+	if err != nil {
+		dbError(c, err)
+		return
+	}
+	if deleted == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Variant not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Variant deleted successfully"})
+}
@@ -0,0 +1,72 @@
+package api_errors
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	db "github.com/adedaryorh/ecommerceapi/db/sqlc"
+	"github.com/adedaryorh/ecommerceapi/payment"
+	"github.com/gin-gonic/gin"
+)
+
+const defaultWebhookTolerance = 5 * time.Minute
+
+// @Summary Payment Webhook
+// @Description Receive a signed payment event from the payment provider. The X-Payment-Signature header must sign the raw body with the configured secret; events are applied once per event ID. A capture the order cannot take (cancelled, expired or already paid order, or an amount other than the order total) is recorded and refunded. A refund may not exceed what is left of its payment.
+// @Tags Payments
+// @Accept json
+// @Produce json
+// @Param X-Payment-Signature header string true "t=<unix time>,v1=<hex HMAC-SHA256>"
+// @Success 200 {object} db.ProcessPaymentEventTxResult
+// @Failure 400 {object} api_errors.ApiError "Malformed or stale event"
+// @Failure 401 {object} api_errors.ApiError "Invalid signature"
+// @Failure 404 {object} api_errors.ApiError "Unknown order or payment"
+// @Failure 409 {object} api_errors.ApiError "Event conflicts with the order"
+// @Failure 500 {object} api_errors.ApiError
+// @Failure 502 {object} api_errors.ApiError "Payment provider failed to refund a late capture"
+// @Router /webhooks/payments [post]
+func (s *Server) PaymentWebhook(c *gin.Context) {
+	body, err := c.GetRawData()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tolerance := s.config.Payment_webhook_tolerance
+	if tolerance <= 0 {
+		tolerance = defaultWebhookTolerance
+	}
+	event, err := payment.VerifyWebhook(s.config.Payment_webhook_secret, c.GetHeader(payment.SignatureHeader), body, tolerance, time.Now())
+	if errors.Is(err, payment.ErrInvalidSignature) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := s.store.ProcessPaymentEventTx(context.Background(), db.ProcessPaymentEventTxParams{
+		Provider: s.payments.Name(),
+		Event:    event,
+		Payload:  body,
+		Refund:   s.refundThroughProvider(fmt.Sprintf("order-%d-void-%s", event.Data.OrderID, event.Data.TransactionID)),
+	})
+	if errors.Is(err, db.ErrPaymentNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	} else if errors.Is(err, db.ErrInvalidEventAmount) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	} else if errors.Is(err, db.ErrRefundExceedsPayment) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	} else if err != nil {
+		s.paymentError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
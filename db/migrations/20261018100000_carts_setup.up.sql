@@ -0,0 +1,16 @@
+CREATE TABLE "carts" (
+                         "id" BIGSERIAL PRIMARY KEY,
+                         "user_id" BIGINT UNIQUE NOT NULL REFERENCES "users" ("id") ON DELETE CASCADE,
+                         "created_at" timestamptz NOT NULL DEFAULT NOW(),
+                         "updated_at" timestamptz NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE "cart_items" (
+                              "id" BIGSERIAL PRIMARY KEY,
+                              "cart_id" BIGINT NOT NULL REFERENCES "carts" ("id") ON DELETE CASCADE,
+                              "product_id" BIGINT NOT NULL REFERENCES "products" ("id") ON DELETE CASCADE,
+                              "quantity" integer NOT NULL CHECK ("quantity" > 0),
+                              "created_at" timestamptz NOT NULL DEFAULT NOW(),
+                              "updated_at" timestamptz NOT NULL DEFAULT NOW(),
+                              UNIQUE ("cart_id", "product_id")
+);
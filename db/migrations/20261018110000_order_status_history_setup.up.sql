@@ -0,0 +1,11 @@
+CREATE TABLE "order_status_history" (
+                                        "id" BIGSERIAL PRIMARY KEY,
+                                        "order_id" BIGINT NOT NULL REFERENCES "orders" ("id") ON DELETE CASCADE,
+                                        "from_status" varchar(50) NOT NULL,
+                                        "to_status" varchar(50) NOT NULL,
+                                        "changed_by" BIGINT REFERENCES "users" ("id") ON DELETE SET NULL,
+                                        "note" text,
+                                        "created_at" timestamptz NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX "order_status_history_order_id_idx" ON "order_status_history" ("order_id");
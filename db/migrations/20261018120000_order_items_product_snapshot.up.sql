@@ -0,0 +1,6 @@
+ALTER TABLE "order_items" ADD COLUMN "product_name" varchar(256) NOT NULL DEFAULT '';
+
+UPDATE "order_items" oi
+SET "product_name" = p."name"
+FROM "products" p
+WHERE p."id" = oi."product_id";
@@ -0,0 +1,7 @@
+ALTER TABLE "products" ADD COLUMN "search_vector" tsvector
+    GENERATED ALWAYS AS (
+        setweight(to_tsvector('english', coalesce("name", '')), 'A') ||
+        setweight(to_tsvector('english', coalesce("description", '')), 'B')
+    ) STORED;
+
+CREATE INDEX "products_search_vector_idx" ON "products" USING GIN ("search_vector");
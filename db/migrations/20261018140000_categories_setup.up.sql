@@ -0,0 +1,18 @@
+CREATE TABLE "categories" (
+                              "id" BIGSERIAL PRIMARY KEY,
+                              "name" varchar(256) NOT NULL,
+                              "slug" varchar(256) UNIQUE NOT NULL,
+                              "parent_id" BIGINT REFERENCES "categories" ("id") ON DELETE RESTRICT,
+                              "created_at" timestamptz NOT NULL DEFAULT NOW(),
+                              "updated_at" timestamptz NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX "categories_parent_id_idx" ON "categories" ("parent_id");
+
+CREATE TABLE "product_categories" (
+                                      "product_id" BIGINT NOT NULL REFERENCES "products" ("id") ON DELETE CASCADE,
+                                      "category_id" BIGINT NOT NULL REFERENCES "categories" ("id") ON DELETE CASCADE,
+                                      PRIMARY KEY ("product_id", "category_id")
+);
+
+CREATE INDEX "product_categories_category_id_idx" ON "product_categories" ("category_id");
@@ -0,0 +1,14 @@
+CREATE TABLE "product_variants" (
+                                    "id" BIGSERIAL PRIMARY KEY,
+                                    "product_id" BIGINT NOT NULL REFERENCES "products" ("id") ON DELETE CASCADE,
+                                    "sku" varchar(64) UNIQUE NOT NULL,
+                                    "attributes" jsonb NOT NULL DEFAULT '{}',
+                                    "price" numeric(10,2),
+                                    "stock" INT NOT NULL DEFAULT 0 CHECK ("stock" >= 0),
+                                    "created_at" timestamptz NOT NULL DEFAULT NOW(),
+                                    "updated_at" timestamptz NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX "product_variants_product_id_idx" ON "product_variants" ("product_id");
+
+ALTER TABLE "order_items" ADD COLUMN "variant_id" BIGINT REFERENCES "product_variants" ("id") ON DELETE RESTRICT;
@@ -0,0 +1,11 @@
+CREATE TABLE "product_images" (
+                                  "id" BIGSERIAL PRIMARY KEY,
+                                  "product_id" BIGINT NOT NULL REFERENCES "products" ("id") ON DELETE CASCADE,
+                                  "storage_key" varchar(512) NOT NULL,
+                                  "thumbnail_key" varchar(512) NOT NULL,
+                                  "content_type" varchar(64) NOT NULL,
+                                  "position" INT NOT NULL,
+                                  "created_at" timestamptz NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX "product_images_product_id_position_idx" ON "product_images" ("product_id", "position");
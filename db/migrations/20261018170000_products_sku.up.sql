@@ -0,0 +1 @@
+ALTER TABLE "products" ADD COLUMN "sku" varchar(64) UNIQUE;
@@ -0,0 +1 @@
+ALTER TABLE "products" ADD COLUMN "version" INT NOT NULL DEFAULT 1;
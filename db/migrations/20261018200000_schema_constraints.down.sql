@@ -0,0 +1,17 @@
+DROP INDEX IF EXISTS "sessions_user_id_idx";
+DROP INDEX IF EXISTS "order_items_product_id_idx";
+DROP INDEX IF EXISTS "order_items_order_id_idx";
+DROP INDEX IF EXISTS "orders_user_id_idx";
+
+ALTER TABLE "products" DROP CONSTRAINT IF EXISTS "products_stock_check";
+
+ALTER TABLE "sessions"
+    DROP CONSTRAINT IF EXISTS "sessions_token_key",
+    DROP CONSTRAINT IF EXISTS "sessions_user_id_fkey";
+
+ALTER TABLE "order_items"
+    DROP CONSTRAINT IF EXISTS "order_items_quantity_check",
+    DROP CONSTRAINT IF EXISTS "order_items_product_id_fkey",
+    DROP CONSTRAINT IF EXISTS "order_items_order_id_fkey";
+
+ALTER TABLE "orders" DROP CONSTRAINT IF EXISTS "orders_user_id_fkey";
@@ -0,0 +1,97 @@
+-- The foreign keys and checks are added NOT VALID so they apply to new rows
+-- at once, and validated once the existing rows that break them are dealt
+-- with below.
+ALTER TABLE "orders"
+    ADD CONSTRAINT "orders_user_id_fkey" FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE RESTRICT NOT VALID;
+
+ALTER TABLE "order_items"
+    ADD CONSTRAINT "order_items_order_id_fkey" FOREIGN KEY ("order_id") REFERENCES "orders" ("id") ON DELETE CASCADE NOT VALID,
+    ADD CONSTRAINT "order_items_product_id_fkey" FOREIGN KEY ("product_id") REFERENCES "products" ("id") ON DELETE RESTRICT NOT VALID,
+    ADD CONSTRAINT "order_items_quantity_check" CHECK ("quantity" > 0) NOT VALID;
+
+ALTER TABLE "sessions"
+    ADD CONSTRAINT "sessions_user_id_fkey" FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE NOT VALID;
+
+ALTER TABLE "products"
+    ADD CONSTRAINT "products_stock_check" CHECK ("stock" >= 0) NOT VALID;
+
+-- Users and products used to be hard-deleted, leaving orders and sessions
+-- that point at them. Rather than lose that history, each missing parent is
+-- restored as an archived placeholder, which cannot log in or be ordered.
+DO $$
+DECLARE
+    restored bigint;
+    fixed bigint;
+BEGIN
+    INSERT INTO "users" ("id", "email", "hashed_password", "username", "deleted_at")
+    SELECT missing."user_id", 'deleted-user-' || missing."user_id" || '@invalid', '', 'deleted-user-' || missing."user_id", NOW()
+    FROM (
+        SELECT "user_id" FROM "orders"
+        UNION
+        SELECT "user_id" FROM "sessions"
+    ) missing
+    WHERE NOT EXISTS (SELECT 1 FROM "users" u WHERE u."id" = missing."user_id");
+    GET DIAGNOSTICS restored = ROW_COUNT;
+    IF restored > 0 THEN
+        RAISE NOTICE 'restored % deleted user(s) as archived placeholders', restored;
+    END IF;
+
+    -- Order lines keep a snapshot of the product name and price.
+    INSERT INTO "products" ("id", "name", "price", "stock", "deleted_at")
+    SELECT oi."product_id", COALESCE(NULLIF(MAX(oi."product_name"), ''), 'Deleted product ' || oi."product_id"), MAX(oi."price"), 0, NOW()
+    FROM "order_items" oi
+    WHERE NOT EXISTS (SELECT 1 FROM "products" p WHERE p."id" = oi."product_id")
+    GROUP BY oi."product_id";
+    GET DIAGNOSTICS restored = ROW_COUNT;
+    IF restored > 0 THEN
+        RAISE NOTICE 'restored % deleted product(s) as archived placeholders', restored;
+    END IF;
+
+    -- Lines whose order is gone cannot be attributed to anyone, so they are
+    -- reported for an operator to resolve instead of being deleted.
+    SELECT COUNT(*) INTO restored
+    FROM "order_items" oi
+    WHERE NOT EXISTS (SELECT 1 FROM "orders" o WHERE o."id" = oi."order_id");
+    IF restored > 0 THEN
+        RAISE EXCEPTION '% order_items row(s) reference missing orders; move or remove them before migrating', restored;
+    END IF;
+    -- Order lines are history too, so a bad quantity is reported, not fixed.
+    SELECT COUNT(*) INTO fixed FROM "order_items" WHERE "quantity" <= 0;
+    IF fixed > 0 THEN
+        RAISE EXCEPTION '% order_items row(s) have a quantity below 1; correct them before migrating', fixed;
+    END IF;
+
+    -- A token identifies one session, so only the latest of each duplicate
+    -- is kept; the others just have to log in again.
+    DELETE FROM "sessions" s
+    USING "sessions" newer
+    WHERE newer."token" = s."token" AND newer."id" > s."id";
+    GET DIAGNOSTICS fixed = ROW_COUNT;
+    IF fixed > 0 THEN
+        RAISE NOTICE 'deleted % session(s) with a duplicate token', fixed;
+    END IF;
+
+    -- Stock below zero was oversold. The inventory ledger, which opens with
+    -- each product's stock in the next migration, starts from the clamped
+    -- value.
+    UPDATE "products" SET "stock" = 0, "version" = "version" + 1, "updated_at" = NOW() WHERE "stock" < 0;
+    GET DIAGNOSTICS fixed = ROW_COUNT;
+    IF fixed > 0 THEN
+        RAISE NOTICE 'reset negative stock to 0 on % product(s)', fixed;
+    END IF;
+END
+$$;
+
+ALTER TABLE "sessions" ADD CONSTRAINT "sessions_token_key" UNIQUE ("token");
+
+ALTER TABLE "orders" VALIDATE CONSTRAINT "orders_user_id_fkey";
+ALTER TABLE "order_items" VALIDATE CONSTRAINT "order_items_order_id_fkey";
+ALTER TABLE "order_items" VALIDATE CONSTRAINT "order_items_product_id_fkey";
+ALTER TABLE "sessions" VALIDATE CONSTRAINT "sessions_user_id_fkey";
+ALTER TABLE "order_items" VALIDATE CONSTRAINT "order_items_quantity_check";
+ALTER TABLE "products" VALIDATE CONSTRAINT "products_stock_check";
+
+CREATE INDEX "orders_user_id_idx" ON "orders" ("user_id");
+CREATE INDEX "order_items_order_id_idx" ON "order_items" ("order_id");
+CREATE INDEX "order_items_product_id_idx" ON "order_items" ("product_id");
+CREATE INDEX "sessions_user_id_idx" ON "sessions" ("user_id");
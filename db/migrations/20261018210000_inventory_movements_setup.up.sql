@@ -0,0 +1,24 @@
+CREATE TABLE "inventory_movements" (
+                                       "id" BIGSERIAL PRIMARY KEY,
+                                       "product_id" BIGINT NOT NULL REFERENCES "products" ("id") ON DELETE CASCADE,
+                                       "variant_id" BIGINT REFERENCES "product_variants" ("id") ON DELETE CASCADE,
+                                       "kind" varchar(20) NOT NULL CHECK ("kind" IN ('receipt', 'sale', 'return', 'adjustment', 'cancellation')),
+                                       "quantity" INT NOT NULL CHECK ("quantity" <> 0),
+                                       "balance" INT NOT NULL CHECK ("balance" >= 0),
+                                       "reason" text,
+                                       "order_id" BIGINT REFERENCES "orders" ("id") ON DELETE SET NULL,
+                                       "created_by" BIGINT REFERENCES "users" ("id") ON DELETE SET NULL,
+                                       "created_at" timestamptz NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX "inventory_movements_product_id_idx" ON "inventory_movements" ("product_id", "id");
+CREATE INDEX "inventory_movements_variant_id_idx" ON "inventory_movements" ("variant_id") WHERE "variant_id" IS NOT NULL;
+
+-- Open the ledger with the current stock so balances add up from here on.
+INSERT INTO "inventory_movements" ("product_id", "kind", "quantity", "balance", "reason")
+SELECT "id", 'adjustment', "stock", "stock", 'Opening balance'
+FROM "products" WHERE "stock" > 0;
+
+INSERT INTO "inventory_movements" ("product_id", "variant_id", "kind", "quantity", "balance", "reason")
+SELECT "product_id", "id", 'adjustment', "stock", "stock", 'Opening balance'
+FROM "product_variants" WHERE "stock" > 0;
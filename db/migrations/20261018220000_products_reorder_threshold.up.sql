@@ -0,0 +1 @@
+ALTER TABLE "products" ADD COLUMN "reorder_threshold" INT NOT NULL DEFAULT 0 CHECK ("reorder_threshold" >= 0);
@@ -0,0 +1,15 @@
+CREATE TABLE "stock_reservations" (
+                                      "id" BIGSERIAL PRIMARY KEY,
+                                      "order_id" BIGINT NOT NULL REFERENCES "orders" ("id") ON DELETE CASCADE,
+                                      "product_id" BIGINT NOT NULL REFERENCES "products" ("id") ON DELETE CASCADE,
+                                      "variant_id" BIGINT REFERENCES "product_variants" ("id") ON DELETE CASCADE,
+                                      "quantity" INT NOT NULL CHECK ("quantity" > 0),
+                                      "status" varchar(20) NOT NULL DEFAULT 'active' CHECK ("status" IN ('active', 'committed', 'released')),
+                                      "expires_at" timestamptz NOT NULL,
+                                      "created_at" timestamptz NOT NULL DEFAULT NOW(),
+                                      "updated_at" timestamptz NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX "stock_reservations_order_id_idx" ON "stock_reservations" ("order_id");
+CREATE INDEX "stock_reservations_active_product_idx" ON "stock_reservations" ("product_id", "variant_id") WHERE "status" = 'active';
+CREATE INDEX "stock_reservations_active_expires_at_idx" ON "stock_reservations" ("expires_at") WHERE "status" = 'active';
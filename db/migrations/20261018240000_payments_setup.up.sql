@@ -0,0 +1,14 @@
+CREATE TABLE "payments" (
+                            "id" BIGSERIAL PRIMARY KEY,
+                            "order_id" BIGINT NOT NULL REFERENCES "orders" ("id") ON DELETE RESTRICT,
+                            "provider" varchar(50) NOT NULL,
+                            "provider_transaction_id" varchar(255) NOT NULL,
+                            "amount" numeric(10,2) NOT NULL CHECK ("amount" > 0),
+                            "status" varchar(20) NOT NULL CHECK ("status" IN ('authorized', 'captured', 'refunded', 'failed')),
+                            "failure_reason" varchar(255),
+                            "created_at" timestamptz NOT NULL DEFAULT NOW(),
+                            "updated_at" timestamptz NOT NULL DEFAULT NOW(),
+                            CONSTRAINT "payments_provider_transaction_key" UNIQUE ("provider", "provider_transaction_id")
+);
+
+CREATE INDEX "payments_order_id_idx" ON "payments" ("order_id");
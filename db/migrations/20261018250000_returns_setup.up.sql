@@ -0,0 +1,36 @@
+CREATE TABLE "returns" (
+                           "id" BIGSERIAL PRIMARY KEY,
+                           "order_id" BIGINT NOT NULL REFERENCES "orders" ("id") ON DELETE RESTRICT,
+                           "user_id" BIGINT NOT NULL REFERENCES "users" ("id") ON DELETE RESTRICT,
+                           "status" varchar(20) NOT NULL DEFAULT 'requested' CHECK ("status" IN ('requested', 'approved', 'rejected', 'received')),
+                           "reason" TEXT NOT NULL,
+                           "admin_note" TEXT,
+                           "reviewed_by" BIGINT REFERENCES "users" ("id") ON DELETE SET NULL,
+                           "created_at" timestamptz NOT NULL DEFAULT NOW(),
+                           "updated_at" timestamptz NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX "returns_order_id_idx" ON "returns" ("order_id");
+CREATE INDEX "returns_status_idx" ON "returns" ("status");
+
+CREATE TABLE "return_items" (
+                                "id" BIGSERIAL PRIMARY KEY,
+                                "return_id" BIGINT NOT NULL REFERENCES "returns" ("id") ON DELETE CASCADE,
+                                "order_item_id" BIGINT NOT NULL REFERENCES "order_items" ("id") ON DELETE RESTRICT,
+                                "quantity" INT NOT NULL CHECK ("quantity" > 0),
+                                CONSTRAINT "return_items_return_order_item_key" UNIQUE ("return_id", "order_item_id")
+);
+
+CREATE INDEX "return_items_order_item_id_idx" ON "return_items" ("order_item_id");
+
+CREATE TABLE "refunds" (
+                           "id" BIGSERIAL PRIMARY KEY,
+                           "payment_id" BIGINT NOT NULL REFERENCES "payments" ("id") ON DELETE RESTRICT,
+                           "return_id" BIGINT REFERENCES "returns" ("id") ON DELETE SET NULL,
+                           "amount" numeric(10,2) NOT NULL CHECK ("amount" > 0),
+                           "provider_refund_id" varchar(255) NOT NULL,
+                           "created_at" timestamptz NOT NULL DEFAULT NOW(),
+                           CONSTRAINT "refunds_payment_provider_refund_key" UNIQUE ("payment_id", "provider_refund_id")
+);
+
+CREATE INDEX "refunds_return_id_idx" ON "refunds" ("return_id");
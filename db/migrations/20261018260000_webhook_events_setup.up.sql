@@ -0,0 +1,9 @@
+CREATE TABLE "webhook_events" (
+                                  "id" BIGSERIAL PRIMARY KEY,
+                                  "provider" varchar(50) NOT NULL,
+                                  "event_id" varchar(255) NOT NULL,
+                                  "type" varchar(100) NOT NULL,
+                                  "payload" jsonb NOT NULL,
+                                  "received_at" timestamptz NOT NULL DEFAULT NOW(),
+                                  CONSTRAINT "webhook_events_provider_event_key" UNIQUE ("provider", "event_id")
+);
@@ -0,0 +1,11 @@
+CREATE TABLE "idempotency_keys" (
+                                    "id" BIGSERIAL PRIMARY KEY,
+                                    "user_id" BIGINT NOT NULL REFERENCES "users" ("id") ON DELETE CASCADE,
+                                    "key" varchar(255) NOT NULL,
+                                    "request_hash" varchar(64) NOT NULL,
+                                    "status_code" INT,
+                                    "response_body" bytea,
+                                    "created_at" timestamptz NOT NULL DEFAULT NOW(),
+                                    "completed_at" timestamptz,
+                                    CONSTRAINT "idempotency_keys_user_key_key" UNIQUE ("user_id", "key")
+);
@@ -0,0 +1,8 @@
+ALTER TABLE "orders"
+    DROP COLUMN IF EXISTS "coupon_code",
+    DROP COLUMN IF EXISTS "discount_amount";
+
+DROP TABLE IF  EXISTS "coupon_redemptions";
+DROP TABLE IF  EXISTS "coupon_categories";
+DROP TABLE IF  EXISTS "coupon_products";
+DROP TABLE IF  EXISTS "coupons";
@@ -0,0 +1,46 @@
+CREATE TABLE "coupons" (
+                           "id" BIGSERIAL PRIMARY KEY,
+                           "code" varchar(64) NOT NULL,
+                           "description" TEXT NOT NULL DEFAULT '',
+                           "kind" varchar(20) NOT NULL CHECK ("kind" IN ('percentage', 'fixed')),
+                           "value" numeric(10,2) NOT NULL DEFAULT 0 CHECK ("value" >= 0),
+                           "min_spend" numeric(10,2) NOT NULL DEFAULT 0 CHECK ("min_spend" >= 0),
+                           "usage_limit" INT CHECK ("usage_limit" > 0),
+                           "per_user_limit" INT CHECK ("per_user_limit" > 0),
+                           "starts_at" timestamptz,
+                           "ends_at" timestamptz,
+                           "active" BOOLEAN NOT NULL DEFAULT TRUE,
+                           "created_at" timestamptz NOT NULL DEFAULT NOW(),
+                           "updated_at" timestamptz NOT NULL DEFAULT NOW(),
+                           CONSTRAINT "coupons_code_key" UNIQUE ("code"),
+                           CONSTRAINT "coupons_percentage_check" CHECK ("kind" <> 'percentage' OR "value" <= 100),
+                           CONSTRAINT "coupons_validity_check" CHECK ("starts_at" IS NULL OR "ends_at" IS NULL OR "ends_at" > "starts_at")
+);
+
+CREATE TABLE "coupon_products" (
+                                   "coupon_id" BIGINT NOT NULL REFERENCES "coupons" ("id") ON DELETE CASCADE,
+                                   "product_id" BIGINT NOT NULL REFERENCES "products" ("id") ON DELETE CASCADE,
+                                   PRIMARY KEY ("coupon_id", "product_id")
+);
+
+CREATE TABLE "coupon_categories" (
+                                     "coupon_id" BIGINT NOT NULL REFERENCES "coupons" ("id") ON DELETE CASCADE,
+                                     "category_id" BIGINT NOT NULL REFERENCES "categories" ("id") ON DELETE CASCADE,
+                                     PRIMARY KEY ("coupon_id", "category_id")
+);
+
+CREATE TABLE "coupon_redemptions" (
+                                      "id" BIGSERIAL PRIMARY KEY,
+                                      "coupon_id" BIGINT NOT NULL REFERENCES "coupons" ("id") ON DELETE RESTRICT,
+                                      "user_id" BIGINT NOT NULL REFERENCES "users" ("id") ON DELETE CASCADE,
+                                      "order_id" BIGINT NOT NULL REFERENCES "orders" ("id") ON DELETE CASCADE,
+                                      "discount_amount" numeric(10,2) NOT NULL CHECK ("discount_amount" >= 0),
+                                      "created_at" timestamptz NOT NULL DEFAULT NOW(),
+                                      CONSTRAINT "coupon_redemptions_order_key" UNIQUE ("order_id")
+);
+
+CREATE INDEX "coupon_redemptions_coupon_user_idx" ON "coupon_redemptions" ("coupon_id", "user_id");
+
+ALTER TABLE "orders"
+    ADD COLUMN "discount_amount" numeric(10,2) NOT NULL DEFAULT 0 CHECK ("discount_amount" >= 0),
+    ADD COLUMN "coupon_code" varchar(64);
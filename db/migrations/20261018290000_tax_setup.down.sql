@@ -0,0 +1,8 @@
+DROP TABLE IF  EXISTS "order_tax_lines";
+
+ALTER TABLE "orders"
+    DROP COLUMN IF EXISTS "tax_state",
+    DROP COLUMN IF EXISTS "tax_country",
+    DROP COLUMN IF EXISTS "tax_amount";
+
+ALTER TABLE "products" DROP COLUMN IF EXISTS "tax_class";
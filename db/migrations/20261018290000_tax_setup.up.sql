@@ -0,0 +1,19 @@
+ALTER TABLE "products" ADD COLUMN "tax_class" varchar(32) NOT NULL DEFAULT 'standard';
+
+ALTER TABLE "orders"
+    ADD COLUMN "tax_amount" numeric(10,2) NOT NULL DEFAULT 0 CHECK ("tax_amount" >= 0),
+    ADD COLUMN "tax_country" varchar(2),
+    ADD COLUMN "tax_state" varchar(10);
+
+CREATE TABLE "order_tax_lines" (
+                                   "id" BIGSERIAL PRIMARY KEY,
+                                   "order_id" BIGINT NOT NULL REFERENCES "orders" ("id") ON DELETE CASCADE,
+                                   "product_id" BIGINT NOT NULL REFERENCES "products" ("id") ON DELETE RESTRICT,
+                                   "tax_class" varchar(32) NOT NULL,
+                                   "jurisdiction" varchar(16) NOT NULL,
+                                   "rate" numeric(6,3) NOT NULL CHECK ("rate" >= 0),
+                                   "taxable_amount" numeric(10,2) NOT NULL,
+                                   "amount" numeric(10,2) NOT NULL CHECK ("amount" >= 0)
+);
+
+CREATE INDEX "order_tax_lines_order_id_idx" ON "order_tax_lines" ("order_id");
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS "inventory_movements_low_stock_unchecked_idx";
+
+ALTER TABLE "inventory_movements" DROP COLUMN IF EXISTS "low_stock_checked_at";
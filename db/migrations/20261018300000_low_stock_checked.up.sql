@@ -0,0 +1,9 @@
+-- Sales are marked once the low-stock checker has looked at them, so a sale
+-- that commits after a later one was checked is still picked up.
+ALTER TABLE "inventory_movements" ADD COLUMN "low_stock_checked_at" timestamptz;
+
+-- Sales made before the checker tracked them are not reported retroactively.
+UPDATE "inventory_movements" SET "low_stock_checked_at" = NOW() WHERE "kind" = 'sale';
+
+CREATE INDEX "inventory_movements_low_stock_unchecked_idx" ON "inventory_movements" ("id")
+    WHERE "kind" = 'sale' AND "low_stock_checked_at" IS NULL;
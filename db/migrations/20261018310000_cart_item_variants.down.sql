@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS "cart_items_cart_line_key";
+
+-- Variant lines cannot be told apart from product lines once the column is gone.
+DELETE FROM "cart_items" WHERE "variant_id" IS NOT NULL;
+
+ALTER TABLE "cart_items" DROP COLUMN IF EXISTS "variant_id";
+
+ALTER TABLE "cart_items" ADD CONSTRAINT "cart_items_cart_id_product_id_key" UNIQUE ("cart_id", "product_id");
@@ -0,0 +1,7 @@
+-- A cart line is a product or one of its variants, so the same product may
+-- appear once per variant plus once without one.
+ALTER TABLE "cart_items" ADD COLUMN "variant_id" BIGINT REFERENCES "product_variants" ("id") ON DELETE CASCADE;
+
+ALTER TABLE "cart_items" DROP CONSTRAINT "cart_items_cart_id_product_id_key";
+
+CREATE UNIQUE INDEX "cart_items_cart_line_key" ON "cart_items" ("cart_id", "product_id", (COALESCE("variant_id", 0)));
@@ -0,0 +1,293 @@
+// Package migrations embeds the SQL schema migrations and applies them.
+//
+// Progress is recorded in the same schema_migrations table the golang-migrate
+// CLI uses, so databases migrated with `make m_up` can switch to the embedded
+// runner, and back, without re-running anything.
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+//go:embed *.sql
+var files embed.FS
+
+// NoVersion is the version of a database with no migrations applied.
+const NoVersion int64 = -1
+
+// lockID keys the advisory lock that stops two processes, such as several
+// API replicas starting at once, from migrating the same database together.
+const lockID = 7264318520
+
+var fileName = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+
+// Migration is one schema change with the SQL to apply and revert it.
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Status reports the applied version of a database.
+type Status struct {
+	Version int64
+	Dirty   bool
+}
+
+// Load returns the embedded migrations ordered by version.
+func Load() ([]Migration, error) {
+	entries, err := fs.ReadDir(files, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int64]*Migration{}
+	for _, entry := range entries {
+		match := fileName.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("unexpected migration file %s", entry.Name())
+		}
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration version in %s: %w", entry.Name(), err)
+		}
+		body, err := fs.ReadFile(files, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		} else if migration.Name != match[2] {
+			return nil, fmt.Errorf("migration version %d is used by both %s and %s", version, migration.Name, match[2])
+		}
+		if match[3] == "up" {
+			migration.Up = string(body)
+		} else {
+			migration.Down = string(body)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up file", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// Migrator applies the embedded migrations to a database.
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+}
+
+func NewMigrator(db *sql.DB) (*Migrator, error) {
+	migrations, err := Load()
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, migrations: migrations}, nil
+}
+
+// Migrations returns the embedded migrations ordered by version.
+func (m *Migrator) Migrations() []Migration {
+	return m.migrations
+}
+
+// Status returns the version the database is at.
+func (m *Migrator) Status(ctx context.Context) (Status, error) {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return Status{}, err
+	}
+	defer conn.Close()
+
+	if err := ensureVersionTable(ctx, conn); err != nil {
+		return Status{}, err
+	}
+	return readStatus(ctx, conn)
+}
+
+// Up applies every pending migration and returns how many ran.
+func (m *Migrator) Up(ctx context.Context) (int, error) {
+	applied := 0
+	err := m.withLock(ctx, func(conn *sql.Conn, status Status) error {
+		for _, migration := range m.migrations {
+			if migration.Version <= status.Version {
+				continue
+			}
+			if err := runMigration(ctx, conn, migration.Up, migration.Version); err != nil {
+				return fmt.Errorf("migration %d_%s: %w", migration.Version, migration.Name, err)
+			}
+			applied++
+		}
+		return nil
+	})
+	return applied, err
+}
+
+// Down reverts the given number of applied migrations, newest first, and
+// returns how many were reverted.
+func (m *Migrator) Down(ctx context.Context, steps int) (int, error) {
+	reverted := 0
+	err := m.withLock(ctx, func(conn *sql.Conn, status Status) error {
+		for i := len(m.migrations) - 1; i >= 0 && reverted < steps; i-- {
+			migration := m.migrations[i]
+			if migration.Version > status.Version {
+				continue
+			}
+			if migration.Down == "" {
+				return fmt.Errorf("migration %d_%s has no down file", migration.Version, migration.Name)
+			}
+			previous := NoVersion
+			if i > 0 {
+				previous = m.migrations[i-1].Version
+			}
+			if err := runMigration(ctx, conn, migration.Down, previous); err != nil {
+				return fmt.Errorf("reverting %d_%s: %w", migration.Version, migration.Name, err)
+			}
+			reverted++
+		}
+		return nil
+	})
+	return reverted, err
+}
+
+// Force records the database as being at version without running any SQL
+// and clears the dirty flag. It is used after fixing a failed migration by
+// hand; NoVersion marks the database as unmigrated.
+func (m *Migrator) Force(ctx context.Context, version int64) error {
+	if version != NoVersion {
+		known := false
+		for _, migration := range m.migrations {
+			known = known || migration.Version == version
+		}
+		if !known {
+			return fmt.Errorf("unknown migration version %d", version)
+		}
+	}
+
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if err := lock(ctx, conn); err != nil {
+		return err
+	}
+	defer unlock(conn)
+
+	if err := ensureVersionTable(ctx, conn); err != nil {
+		return err
+	}
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := setVersion(ctx, tx, version); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// withLock runs fn on a single connection holding the migration lock,
+// refusing to touch a database left dirty by a failed migration.
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *sql.Conn, status Status) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if err := lock(ctx, conn); err != nil {
+		return err
+	}
+	defer unlock(conn)
+
+	if err := ensureVersionTable(ctx, conn); err != nil {
+		return err
+	}
+	status, err := readStatus(ctx, conn)
+	if err != nil {
+		return err
+	}
+	if status.Dirty {
+		return fmt.Errorf("database is dirty at version %d; fix it by hand and run migrate force", status.Version)
+	}
+	return fn(conn, status)
+}
+
+// runMigration executes a migration file and records the new version in
+// the same transaction, so a failure leaves both schema and version as
+// they were.
+func runMigration(ctx context.Context, conn *sql.Conn, query string, version int64) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, query); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := setVersion(ctx, tx, version); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+func lock(ctx context.Context, conn *sql.Conn) error {
+	_, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", lockID)
+	return err
+}
+
+func unlock(conn *sql.Conn) {
+	conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", lockID)
+}
+
+func ensureVersionTable(ctx context.Context, conn *sql.Conn) error {
+	_, err := conn.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS "schema_migrations" (
+    "version" bigint NOT NULL PRIMARY KEY,
+    "dirty" boolean NOT NULL
+)`)
+	return err
+}
+
+func readStatus(ctx context.Context, conn *sql.Conn) (Status, error) {
+	status := Status{}
+	err := conn.QueryRowContext(ctx, `SELECT "version", "dirty" FROM "schema_migrations" LIMIT 1`).Scan(&status.Version, &status.Dirty)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Status{Version: NoVersion}, nil
+	}
+	return status, err
+}
+
+// setVersion stores the version the way golang-migrate does: a single row,
+// or none at all for NoVersion.
+func setVersion(ctx context.Context, tx *sql.Tx, version int64) error {
+	if _, err := tx.ExecContext(ctx, `TRUNCATE "schema_migrations"`); err != nil {
+		return err
+	}
+	if version == NoVersion {
+		return nil
+	}
+	_, err := tx.ExecContext(ctx, `INSERT INTO "schema_migrations" ("version", "dirty") VALUES ($1, false)`, version)
+	return err
+}
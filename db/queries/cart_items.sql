@@ -0,0 +1,24 @@
+-- name: UpsertCartItem :one
+INSERT INTO cart_items (cart_id, product_id, variant_id, quantity)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (cart_id, product_id, (COALESCE(variant_id, 0))) DO UPDATE SET quantity = EXCLUDED.quantity, updated_at = NOW()
+RETURNING *;
+
+-- name: ListCartItems :many
+-- Variant lines are priced and stocked from the variant, falling back to
+-- the product's price when the variant has no override.
+SELECT ci.id, ci.cart_id, ci.product_id, ci.variant_id, ci.quantity, ci.created_at, ci.updated_at,
+       p.name AS product_name, v.sku AS variant_sku,
+       COALESCE(v.price, p.price) AS price, COALESCE(v.stock, p.stock) AS stock
+FROM cart_items ci
+JOIN products p ON p.id = ci.product_id
+LEFT JOIN product_variants v ON v.id = ci.variant_id
+WHERE ci.cart_id = $1
+ORDER BY ci.id;
+
+-- name: RemoveCartItem :execrows
+DELETE FROM cart_items
+WHERE cart_id = $1 AND product_id = $2 AND variant_id IS NOT DISTINCT FROM $3;
+
+-- name: ClearCart :exec
+DELETE FROM cart_items WHERE cart_id = $1;
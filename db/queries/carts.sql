@@ -0,0 +1,11 @@
+-- name: GetOrCreateCart :one
+INSERT INTO carts (user_id)
+VALUES ($1)
+ON CONFLICT (user_id) DO UPDATE SET updated_at = NOW()
+RETURNING *;
+
+-- name: GetCartByUserID :one
+SELECT * FROM carts WHERE user_id = $1;
+
+-- name: GetCartForUpdate :one
+SELECT * FROM carts WHERE user_id = $1 FOR UPDATE;
@@ -0,0 +1,25 @@
+-- name: CreateCategory :one
+INSERT INTO categories (name, slug, parent_id)
+VALUES ($1, $2, $3) RETURNING *;
+
+-- name: GetCategoryByID :one
+SELECT * FROM categories WHERE id = $1;
+
+-- name: ListCategories :many
+SELECT * FROM categories ORDER BY name, id;
+
+-- name: UpdateCategory :one
+UPDATE categories
+SET name = $1, slug = $2, parent_id = $3, updated_at = NOW()
+WHERE id = $4 RETURNING *;
+
+-- name: DeleteCategory :execrows
+DELETE FROM categories WHERE id = $1;
+
+-- name: IsCategoryDescendant :one
+WITH RECURSIVE subtree AS (
+    SELECT c.id FROM categories c WHERE c.id = sqlc.arg(ancestor_id)
+    UNION ALL
+    SELECT child.id FROM categories child JOIN subtree s ON child.parent_id = s.id
+)
+SELECT EXISTS (SELECT 1 FROM subtree WHERE subtree.id = sqlc.arg(category_id))::bool AS is_descendant;
@@ -0,0 +1,76 @@
+-- name: CreateCoupon :one
+INSERT INTO coupons (code, description, kind, value, min_spend, usage_limit, per_user_limit, starts_at, ends_at, active)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) RETURNING *;
+
+-- name: GetCouponByID :one
+SELECT * FROM coupons WHERE id = $1;
+
+-- name: GetCouponByCodeForUpdate :one
+SELECT * FROM coupons WHERE code = $1 FOR UPDATE;
+
+-- name: ListCoupons :many
+SELECT * FROM coupons
+ORDER BY id DESC
+LIMIT sqlc.arg(page_limit) OFFSET sqlc.arg(page_offset);
+
+-- name: UpdateCoupon :one
+UPDATE coupons
+SET code = $1, description = $2, kind = $3, value = $4, min_spend = $5, usage_limit = $6,
+    per_user_limit = $7, starts_at = $8, ends_at = $9, active = $10, updated_at = NOW()
+WHERE id = $11 RETURNING *;
+
+-- name: DeleteCoupon :execrows
+DELETE FROM coupons WHERE id = $1;
+
+-- name: AddCouponProduct :exec
+INSERT INTO coupon_products (coupon_id, product_id)
+VALUES ($1, $2) ON CONFLICT DO NOTHING;
+
+-- name: RemoveCouponProducts :exec
+DELETE FROM coupon_products WHERE coupon_id = $1;
+
+-- name: ListCouponProducts :many
+SELECT product_id FROM coupon_products WHERE coupon_id = $1 ORDER BY product_id;
+
+-- name: AddCouponCategory :exec
+INSERT INTO coupon_categories (coupon_id, category_id)
+VALUES ($1, $2) ON CONFLICT DO NOTHING;
+
+-- name: RemoveCouponCategories :exec
+DELETE FROM coupon_categories WHERE coupon_id = $1;
+
+-- name: ListCouponCategories :many
+SELECT category_id FROM coupon_categories WHERE coupon_id = $1 ORDER BY category_id;
+
+-- name: ListCouponEligibleProducts :many
+-- Returns which of the given products a coupon applies to, either directly
+-- or through one of its categories or their subcategories.
+WITH RECURSIVE category_tree AS (
+    SELECT cc.category_id AS id FROM coupon_categories cc WHERE cc.coupon_id = sqlc.arg(coupon_id)
+    UNION
+    SELECT child.id FROM categories child JOIN category_tree ct ON child.parent_id = ct.id
+)
+SELECT p.id FROM products p
+WHERE p.id = ANY(sqlc.arg(product_ids)::bigint[])
+  AND (
+    EXISTS (SELECT 1 FROM coupon_products cp WHERE cp.coupon_id = sqlc.arg(coupon_id) AND cp.product_id = p.id)
+    OR EXISTS (
+        SELECT 1 FROM product_categories pc
+        WHERE pc.product_id = p.id
+          AND pc.category_id IN (SELECT category_tree.id FROM category_tree)
+    )
+  )
+ORDER BY p.id;
+
+-- name: CountCouponRedemptions :one
+SELECT COUNT(*) FROM coupon_redemptions WHERE coupon_id = $1;
+
+-- name: CountUserCouponRedemptions :one
+SELECT COUNT(*) FROM coupon_redemptions WHERE coupon_id = $1 AND user_id = $2;
+
+-- name: CreateCouponRedemption :one
+INSERT INTO coupon_redemptions (coupon_id, user_id, order_id, discount_amount)
+VALUES ($1, $2, $3, $4) RETURNING *;
+
+-- name: DeleteOrderCouponRedemption :execrows
+DELETE FROM coupon_redemptions WHERE order_id = $1;
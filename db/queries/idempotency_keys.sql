@@ -0,0 +1,20 @@
+-- name: ClaimIdempotencyKey :execrows
+-- Claims a new key, or takes over one whose request never completed and
+-- was started before stale_before with the same request.
+INSERT INTO idempotency_keys (user_id, key, request_hash)
+VALUES (sqlc.arg(user_id), sqlc.arg(key), sqlc.arg(request_hash))
+ON CONFLICT (user_id, key) DO UPDATE SET created_at = NOW()
+WHERE idempotency_keys.status_code IS NULL
+  AND idempotency_keys.request_hash = EXCLUDED.request_hash
+  AND idempotency_keys.created_at < sqlc.arg(stale_before);
+
+-- name: CompleteIdempotencyKey :exec
+UPDATE idempotency_keys
+SET status_code = $1, response_body = $2, completed_at = NOW()
+WHERE user_id = $3 AND key = $4;
+
+-- name: DeleteIdempotencyKey :exec
+DELETE FROM idempotency_keys WHERE user_id = $1 AND key = $2;
+
+-- name: GetIdempotencyKey :one
+SELECT * FROM idempotency_keys WHERE user_id = $1 AND key = $2;
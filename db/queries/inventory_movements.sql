@@ -0,0 +1,45 @@
+-- name: CheckLowStockSales :many
+-- Marks the oldest unchecked sales as checked and returns them, flagging
+-- those that took a product below its reorder threshold. A product's stock
+-- counts its own units and those of all its variants, so a variant sale is
+-- checked against the product's threshold too. Rows being checked by
+-- another server are skipped.
+WITH checked AS (
+    UPDATE inventory_movements
+    SET low_stock_checked_at = NOW()
+    WHERE id IN (
+        SELECT id FROM inventory_movements
+        WHERE kind = 'sale' AND low_stock_checked_at IS NULL
+        ORDER BY id
+        LIMIT sqlc.arg(page_limit)
+        FOR UPDATE SKIP LOCKED
+    )
+    RETURNING id, product_id, variant_id, quantity, balance, order_id, created_at
+)
+SELECT c.id, c.product_id, c.variant_id, c.balance, c.order_id, c.created_at,
+       p.name, p.sku, p.reorder_threshold, t.total_stock,
+       (t.total_stock < p.reorder_threshold
+           AND t.total_stock - c.quantity >= p.reorder_threshold)::bool AS crossed_threshold
+FROM checked c
+JOIN products p ON p.id = c.product_id
+-- The sold line's balance is taken from the sale; the product's other
+-- lines count with their current stock.
+CROSS JOIN LATERAL (
+    SELECT (c.balance
+            + CASE WHEN c.variant_id IS NULL THEN 0 ELSE p.stock END
+            + COALESCE(SUM(v.stock), 0))::int AS total_stock
+    FROM product_variants v
+    WHERE v.product_id = c.product_id AND v.id IS DISTINCT FROM c.variant_id
+) t
+ORDER BY c.id;
+
+-- name: CreateInventoryMovement :one
+INSERT INTO inventory_movements (product_id, variant_id, kind, quantity, balance, reason, order_id, created_by)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING *;
+
+-- name: ListInventoryMovements :many
+SELECT * FROM inventory_movements
+WHERE product_id = sqlc.arg(product_id)
+  AND (sqlc.narg(variant_id)::bigint IS NULL OR variant_id = sqlc.narg(variant_id))
+ORDER BY id DESC
+LIMIT sqlc.arg(page_limit) OFFSET sqlc.arg(page_offset);
@@ -1,9 +1,9 @@
 -- name: AddOrderItem :one
-INSERT INTO order_items (order_id, product_id, quantity, price)
-VALUES ($1, $2, $3, $4) RETURNING *;
+INSERT INTO order_items (order_id, product_id, quantity, price, product_name, variant_id)
+VALUES ($1, $2, $3, $4, $5, $6) RETURNING *;
 
 -- name: ListOrderItems :many
-SELECT * FROM order_items WHERE order_id = $1;
+SELECT * FROM order_items WHERE order_id = $1 ORDER BY id;
 
 -- name: RemoveOrderItem :exec
 DELETE FROM order_items WHERE id = $1;
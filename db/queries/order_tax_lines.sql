@@ -0,0 +1,6 @@
+-- name: CreateOrderTaxLine :one
+INSERT INTO order_tax_lines (order_id, product_id, tax_class, jurisdiction, rate, taxable_amount, amount)
+VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING *;
+
+-- name: ListOrderTaxLines :many
+SELECT * FROM order_tax_lines WHERE order_id = $1 ORDER BY id;
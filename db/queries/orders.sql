@@ -1,6 +1,6 @@
 -- name: CreateOrder :one
-INSERT INTO orders (user_id, total_amount, status)
-VALUES ($1, $2, $3) RETURNING *;
+INSERT INTO orders (user_id, total_amount, status, discount_amount, coupon_code, tax_amount, tax_country, tax_state)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING *;
 
 -- name: GetOrderByID :one
 SELECT * FROM orders WHERE id = $1;
@@ -8,8 +8,47 @@ SELECT * FROM orders WHERE id = $1;
 -- name: ListUserOrders :many
 SELECT * FROM orders WHERE user_id = $1 ORDER BY id LIMIT $2 OFFSET $3;
 
+-- name: GetOrderForUpdate :one
+SELECT * FROM orders WHERE id = $1 FOR UPDATE;
+
 -- name: UpdateOrderStatus :one
-UPDATE orders SET status = $1 WHERE id = $2 RETURNING *;
+UPDATE orders SET status = $1, updated_at = NOW() WHERE id = $2 RETURNING *;
+
+-- name: SearchOrders :many
+SELECT * FROM orders
+WHERE (sqlc.narg(status)::text IS NULL OR status = sqlc.narg(status))
+  AND (sqlc.narg(user_id)::bigint IS NULL OR user_id = sqlc.narg(user_id))
+  AND (sqlc.narg(created_from)::timestamptz IS NULL OR created_at >= sqlc.narg(created_from))
+  AND (sqlc.narg(created_to)::timestamptz IS NULL OR created_at < sqlc.narg(created_to))
+  AND (sqlc.narg(min_amount)::numeric IS NULL OR total_amount >= sqlc.narg(min_amount))
+  AND (sqlc.narg(max_amount)::numeric IS NULL OR total_amount <= sqlc.narg(max_amount))
+  AND (
+    sqlc.narg(cursor_id)::bigint IS NULL
+    OR (sqlc.arg(sort_by)::text = 'created_at' AND NOT sqlc.arg(descending)::bool
+        AND (created_at, id) > (sqlc.narg(cursor_created_at)::timestamptz, sqlc.narg(cursor_id)))
+    OR (sqlc.arg(sort_by) = 'created_at' AND sqlc.arg(descending)
+        AND (created_at, id) < (sqlc.narg(cursor_created_at), sqlc.narg(cursor_id)))
+    OR (sqlc.arg(sort_by) = 'total_amount' AND NOT sqlc.arg(descending)
+        AND (total_amount, id) > (sqlc.narg(cursor_amount)::numeric, sqlc.narg(cursor_id)))
+    OR (sqlc.arg(sort_by) = 'total_amount' AND sqlc.arg(descending)
+        AND (total_amount, id) < (sqlc.narg(cursor_amount), sqlc.narg(cursor_id)))
+  )
+ORDER BY
+  CASE WHEN sqlc.arg(sort_by) = 'created_at' AND NOT sqlc.arg(descending) THEN created_at END ASC,
+  CASE WHEN sqlc.arg(sort_by) = 'created_at' AND sqlc.arg(descending) THEN created_at END DESC,
+  CASE WHEN sqlc.arg(sort_by) = 'total_amount' AND NOT sqlc.arg(descending) THEN total_amount END ASC,
+  CASE WHEN sqlc.arg(sort_by) = 'total_amount' AND sqlc.arg(descending) THEN total_amount END DESC,
+  CASE WHEN NOT sqlc.arg(descending) THEN id END ASC,
+  CASE WHEN sqlc.arg(descending) THEN id END DESC
+LIMIT sqlc.arg(page_size);
 
--- name: CancelOrder :exec
-UPDATE orders SET status = 'Cancelled' WHERE id = $1 AND status = 'Pending';
+-- name: OrderSummary :many
+SELECT date_trunc('day', created_at)::date AS day,
+       status,
+       COUNT(*) AS order_count,
+       COALESCE(SUM(total_amount), 0)::numeric AS revenue
+FROM orders
+WHERE (sqlc.narg(created_from)::timestamptz IS NULL OR created_at >= sqlc.narg(created_from))
+  AND (sqlc.narg(created_to)::timestamptz IS NULL OR created_at < sqlc.narg(created_to))
+GROUP BY day, status
+ORDER BY day, status;
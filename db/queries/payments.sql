@@ -0,0 +1,24 @@
+-- name: GetCapturedOrderPayment :one
+SELECT * FROM payments
+WHERE order_id = $1 AND status IN ('captured', 'refunded')
+ORDER BY id DESC
+LIMIT 1;
+
+-- name: GetPaymentByProviderTransaction :one
+SELECT * FROM payments
+WHERE provider = $1 AND provider_transaction_id = $2;
+
+-- name: ListOrderPayments :many
+SELECT * FROM payments
+WHERE order_id = $1
+ORDER BY id;
+
+-- name: RecordPayment :one
+-- A transaction that was already recorded is returned unchanged.
+INSERT INTO payments (order_id, provider, provider_transaction_id, amount, status, failure_reason)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (provider, provider_transaction_id) DO UPDATE SET provider = EXCLUDED.provider
+RETURNING *;
+
+-- name: UpdatePaymentStatus :one
+UPDATE payments SET status = $1, updated_at = NOW() WHERE id = $2 RETURNING *;
@@ -0,0 +1,12 @@
+-- name: AddProductCategory :exec
+INSERT INTO product_categories (product_id, category_id)
+VALUES ($1, $2) ON CONFLICT DO NOTHING;
+
+-- name: RemoveProductCategories :exec
+DELETE FROM product_categories WHERE product_id = $1;
+
+-- name: ListProductCategories :many
+SELECT c.* FROM categories c
+JOIN product_categories pc ON pc.category_id = c.id
+WHERE pc.product_id = $1
+ORDER BY c.name, c.id;
@@ -0,0 +1,14 @@
+-- name: CreateProductImage :one
+INSERT INTO product_images (product_id, storage_key, thumbnail_key, content_type, position)
+VALUES (
+    sqlc.arg(product_id), sqlc.arg(storage_key), sqlc.arg(thumbnail_key), sqlc.arg(content_type),
+    (SELECT COALESCE(MAX(pi.position), 0) + 1 FROM product_images pi WHERE pi.product_id = sqlc.arg(product_id))
+) RETURNING *;
+
+-- name: ListProductImages :many
+SELECT * FROM product_images
+WHERE product_id = ANY(sqlc.arg(product_ids)::bigint[])
+ORDER BY product_id, position, id;
+
+-- name: DeleteProductImage :one
+DELETE FROM product_images WHERE id = $1 AND product_id = $2 RETURNING *;
@@ -0,0 +1,25 @@
+-- name: CreateProductVariant :one
+INSERT INTO product_variants (product_id, sku, attributes, price, stock)
+VALUES ($1, $2, $3, $4, $5) RETURNING *;
+
+-- name: GetProductVariant :one
+SELECT * FROM product_variants WHERE id = $1 AND product_id = $2;
+
+-- name: GetProductVariantForUpdate :one
+SELECT * FROM product_variants WHERE id = $1 FOR UPDATE;
+
+-- name: ListProductVariants :many
+SELECT * FROM product_variants WHERE product_id = $1 ORDER BY id;
+
+-- name: UpdateProductVariant :one
+UPDATE product_variants
+SET sku = $1, attributes = $2, price = $3, stock = $4, updated_at = NOW()
+WHERE id = $5 AND product_id = $6 RETURNING *;
+
+-- name: AddProductVariantStock :one
+UPDATE product_variants
+SET stock = stock + sqlc.arg(amount), updated_at = NOW()
+WHERE id = sqlc.arg(id) RETURNING *;
+
+-- name: DeleteProductVariant :execrows
+DELETE FROM product_variants WHERE id = $1 AND product_id = $2;
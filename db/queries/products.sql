@@ -1,17 +1,123 @@
 -- name: CreateProduct :one
-INSERT INTO products (name, description, price, stock)
-VALUES ($1, $2, $3, $4) RETURNING *;
+INSERT INTO products (name, description, price, stock, sku)
+VALUES ($1, $2, $3, $4, $5) RETURNING *;
 
 -- name: GetProductByID :one
+SELECT * FROM products WHERE id = $1 AND deleted_at IS NULL;
+
+-- name: GetProductByIDWithArchived :one
 SELECT * FROM products WHERE id = $1;
 
+-- name: GetProductBySku :one
+SELECT * FROM products WHERE sku = $1;
+
+-- name: GetProductByName :one
+SELECT * FROM products WHERE name = $1 AND deleted_at IS NULL ORDER BY id LIMIT 1;
+
 -- name: UpdateProduct :one
 UPDATE products
-SET name = $1, description = $2, price = $3, stock = $4, updated_at = $5
-WHERE id = $6 RETURNING *;
+SET name = sqlc.arg(name), description = sqlc.arg(description), price = sqlc.arg(price), stock = sqlc.arg(stock),
+    updated_at = sqlc.arg(updated_at), version = version + 1
+WHERE id = sqlc.arg(id) AND deleted_at IS NULL
+  AND (sqlc.narg(expected_version)::int IS NULL OR version = sqlc.narg(expected_version))
+RETURNING *;
+
+-- name: PatchProduct :one
+UPDATE products
+SET name = COALESCE(sqlc.narg(name), name),
+    description = CASE WHEN sqlc.arg(set_description)::bool THEN sqlc.narg(description) ELSE description END,
+    price = COALESCE(sqlc.narg(price), price),
+    stock = COALESCE(sqlc.narg(stock), stock),
+    sku = CASE WHEN sqlc.arg(set_sku)::bool THEN sqlc.narg(sku) ELSE sku END,
+    reorder_threshold = COALESCE(sqlc.narg(reorder_threshold), reorder_threshold),
+    tax_class = COALESCE(sqlc.narg(tax_class), tax_class),
+    version = version + 1,
+    updated_at = NOW()
+WHERE id = sqlc.arg(id) AND deleted_at IS NULL
+  AND (sqlc.narg(expected_version)::int IS NULL OR version = sqlc.narg(expected_version))
+RETURNING *;
+
+-- name: ArchiveProduct :execrows
+UPDATE products
+SET deleted_at = NOW(), version = version + 1, updated_at = NOW()
+WHERE id = $1 AND deleted_at IS NULL;
 
--- name: DeleteProduct :exec
-DELETE FROM products WHERE id = $1;
+-- name: RestoreProduct :one
+UPDATE products
+SET deleted_at = NULL, version = version + 1, updated_at = NOW()
+WHERE id = $1 AND deleted_at IS NOT NULL RETURNING *;
 
 -- name: ListProducts :many
-SELECT * FROM products ORDER BY id LIMIT $1 OFFSET $2;
+WITH RECURSIVE category_tree AS (
+    SELECT c.id FROM categories c WHERE c.id = sqlc.narg(category_id)
+    UNION ALL
+    SELECT child.id FROM categories child JOIN category_tree ct ON child.parent_id = ct.id
+)
+SELECT * FROM products
+WHERE deleted_at IS NULL
+  AND (sqlc.narg(category_id)::bigint IS NULL
+   OR EXISTS (
+       SELECT 1 FROM product_categories pc
+       WHERE pc.product_id = products.id
+         AND pc.category_id IN (SELECT category_tree.id FROM category_tree)
+   ))
+ORDER BY id
+LIMIT sqlc.arg(page_limit) OFFSET sqlc.arg(page_offset);
+
+-- name: GetProductForUpdate :one
+SELECT * FROM products WHERE id = $1 AND deleted_at IS NULL FOR UPDATE;
+
+-- name: AddProductStock :one
+UPDATE products
+SET stock = stock + sqlc.arg(amount), version = version + 1, updated_at = NOW()
+WHERE id = sqlc.arg(id) RETURNING *;
+
+-- name: CountSearchProducts :one
+-- Counts the products SearchProducts matches, for pages past the last match.
+SELECT COUNT(*) FROM products p
+WHERE p.deleted_at IS NULL
+  AND (sqlc.arg(query)::text = '' OR p.search_vector @@ websearch_to_tsquery('english', sqlc.arg(query)))
+  AND (sqlc.narg(min_price)::numeric IS NULL OR p.price >= sqlc.narg(min_price))
+  AND (sqlc.narg(max_price)::numeric IS NULL OR p.price <= sqlc.narg(max_price))
+  AND (NOT sqlc.arg(in_stock_only)::bool OR p.stock > (
+    SELECT COALESCE(SUM(r.quantity), 0) FROM stock_reservations r
+    WHERE r.product_id = p.id AND r.variant_id IS NULL AND r.status = 'active'));
+
+-- name: SearchProducts :many
+SELECT p.id, p.name, p.description, p.price, p.stock, p.created_at, p.updated_at,
+       ts_rank(p.search_vector, websearch_to_tsquery('english', sqlc.arg(query)::text))::real AS rank,
+       COUNT(*) OVER() AS total_count
+FROM products p
+WHERE p.deleted_at IS NULL
+  AND (sqlc.arg(query) = '' OR p.search_vector @@ websearch_to_tsquery('english', sqlc.arg(query)))
+  AND (sqlc.narg(min_price)::numeric IS NULL OR p.price >= sqlc.narg(min_price))
+  AND (sqlc.narg(max_price)::numeric IS NULL OR p.price <= sqlc.narg(max_price))
+  AND (NOT sqlc.arg(in_stock_only)::bool OR p.stock > (
+    SELECT COALESCE(SUM(r.quantity), 0) FROM stock_reservations r
+    WHERE r.product_id = p.id AND r.variant_id IS NULL AND r.status = 'active'))
+ORDER BY
+  CASE WHEN sqlc.arg(sort_by)::text = 'relevance' THEN ts_rank(p.search_vector, websearch_to_tsquery('english', sqlc.arg(query))) END DESC,
+  CASE WHEN sqlc.arg(sort_by) = 'price_asc' THEN p.price END ASC,
+  CASE WHEN sqlc.arg(sort_by) = 'price_desc' THEN p.price END DESC,
+  CASE WHEN sqlc.arg(sort_by) = 'newest' THEN p.created_at END DESC,
+  p.id
+LIMIT sqlc.arg(page_limit) OFFSET sqlc.arg(page_offset);
+
+-- name: ListProductsAfter :many
+SELECT * FROM products
+WHERE id > sqlc.arg(after_id) AND deleted_at IS NULL
+ORDER BY id
+LIMIT sqlc.arg(page_limit);
+
+-- name: ListLowStockProducts :many
+-- Stock counts the product's own units and those of all its variants.
+SELECT p.id, p.name, p.sku, p.reorder_threshold, s.total_stock
+FROM products p
+CROSS JOIN LATERAL (
+    SELECT (p.stock + COALESCE(SUM(v.stock), 0))::int AS total_stock
+    FROM product_variants v
+    WHERE v.product_id = p.id
+) s
+WHERE p.deleted_at IS NULL AND s.total_stock < p.reorder_threshold
+ORDER BY s.total_stock, p.id
+LIMIT sqlc.arg(page_limit) OFFSET sqlc.arg(page_offset);
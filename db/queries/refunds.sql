@@ -0,0 +1,19 @@
+-- name: GetRefundByProviderRefundID :one
+SELECT * FROM refunds
+WHERE payment_id = $1 AND provider_refund_id = $2;
+
+-- name: GetRefundedAmount :one
+SELECT COALESCE(SUM(amount), 0)::numeric(10,2) FROM refunds
+WHERE payment_id = $1;
+
+-- name: ListReturnRefunds :many
+SELECT * FROM refunds
+WHERE return_id = $1
+ORDER BY id;
+
+-- name: RecordRefund :one
+-- A refund the provider already reported is returned unchanged.
+INSERT INTO refunds (payment_id, return_id, amount, provider_refund_id)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (payment_id, provider_refund_id) DO UPDATE SET provider_refund_id = EXCLUDED.provider_refund_id
+RETURNING *;
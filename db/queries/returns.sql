@@ -0,0 +1,57 @@
+-- name: CreateReturn :one
+INSERT INTO returns (order_id, user_id, reason)
+VALUES ($1, $2, $3) RETURNING *;
+
+-- name: CreateReturnItem :one
+INSERT INTO return_items (return_id, order_item_id, quantity)
+VALUES ($1, $2, $3) RETURNING *;
+
+-- name: GetReturnByID :one
+SELECT * FROM returns WHERE id = $1;
+
+-- name: GetReturnForUpdate :one
+SELECT * FROM returns WHERE id = $1 FOR UPDATE;
+
+-- name: ListApprovedReturnQuantities :many
+-- Units of each order item covered by returns that were approved.
+SELECT ri.order_item_id, SUM(ri.quantity)::int AS returned
+FROM return_items ri
+JOIN returns r ON r.id = ri.return_id
+WHERE r.order_id = $1 AND r.status IN ('approved', 'received')
+GROUP BY ri.order_item_id;
+
+-- name: ListOrderReturns :many
+SELECT * FROM returns
+WHERE order_id = $1
+ORDER BY id;
+
+-- name: ListReturnItems :many
+SELECT ri.id, ri.return_id, ri.order_item_id, ri.quantity,
+       oi.product_id, oi.variant_id, oi.product_name, oi.price
+FROM return_items ri
+JOIN order_items oi ON oi.id = ri.order_item_id
+WHERE ri.return_id = $1
+ORDER BY ri.id;
+
+-- name: ListReturnedQuantities :many
+-- Units of each order item already covered by returns that were not rejected.
+SELECT ri.order_item_id, SUM(ri.quantity)::int AS returned
+FROM return_items ri
+JOIN returns r ON r.id = ri.return_id
+WHERE r.order_id = $1 AND r.status <> 'rejected'
+GROUP BY ri.order_item_id;
+
+-- name: ListReturns :many
+SELECT * FROM returns
+WHERE sqlc.narg(status)::varchar IS NULL OR status = sqlc.narg(status)
+ORDER BY id DESC
+LIMIT sqlc.arg(page_limit) OFFSET sqlc.arg(page_offset);
+
+-- name: UpdateReturnStatus :one
+UPDATE returns
+SET status = sqlc.arg(status),
+    reviewed_by = COALESCE(sqlc.narg(reviewed_by), reviewed_by),
+    admin_note = COALESCE(sqlc.narg(admin_note), admin_note),
+    updated_at = NOW()
+WHERE id = sqlc.arg(id)
+RETURNING *;
@@ -0,0 +1,44 @@
+-- name: CreateStockReservation :one
+INSERT INTO stock_reservations (order_id, product_id, variant_id, quantity, expires_at)
+VALUES ($1, $2, $3, $4, $5) RETURNING *;
+
+-- name: GetReservedProductStock :one
+SELECT COALESCE(SUM(quantity), 0)::int FROM stock_reservations
+WHERE product_id = $1 AND variant_id IS NULL AND status = 'active';
+
+-- name: GetReservedVariantStock :one
+SELECT COALESCE(SUM(quantity), 0)::int FROM stock_reservations
+WHERE variant_id = $1 AND status = 'active';
+
+-- name: ListReservedProductStock :many
+SELECT product_id, SUM(quantity)::int AS reserved FROM stock_reservations
+WHERE product_id = ANY(sqlc.arg(product_ids)::bigint[]) AND variant_id IS NULL AND status = 'active'
+GROUP BY product_id;
+
+-- name: ListReservedVariantStock :many
+SELECT variant_id::bigint AS variant_id, SUM(quantity)::int AS reserved FROM stock_reservations
+WHERE product_id = $1 AND variant_id IS NOT NULL AND status = 'active'
+GROUP BY variant_id;
+
+-- name: ListActiveOrderReservations :many
+SELECT * FROM stock_reservations
+WHERE order_id = $1 AND status = 'active'
+ORDER BY id;
+
+-- name: SetOrderReservationsStatus :execrows
+UPDATE stock_reservations
+SET status = sqlc.arg(to_status), updated_at = NOW()
+WHERE order_id = sqlc.arg(order_id) AND status = 'active';
+
+-- name: ListExpiredPendingOrders :many
+SELECT o.id FROM orders o
+WHERE o.status = 'Pending'
+  AND (
+    EXISTS (SELECT 1 FROM stock_reservations r
+            WHERE r.order_id = o.id AND r.status = 'active' AND r.expires_at <= NOW())
+    -- Orders placed before reservations existed expire by age.
+    OR (NOT EXISTS (SELECT 1 FROM stock_reservations r WHERE r.order_id = o.id)
+        AND o.created_at <= sqlc.arg(stale_before))
+  )
+ORDER BY o.id
+LIMIT sqlc.arg(page_limit);
@@ -6,24 +6,32 @@ INSERT INTO users (
 ) VALUES ($1, $2, $3) RETURNING *;
 
 -- name: GetUserByID :one
-SELECT * FROM users WHERE id= $1;
+SELECT * FROM users WHERE id= $1 AND deleted_at IS NULL;
 
 -- name: GetUserByEmail :one
-SELECT * FROM users WHERE email= $1;
+SELECT * FROM users WHERE email= $1 AND deleted_at IS NULL;
 
 -- name: ListUser :many
-SELECT * FROM users ORDER BY id
+SELECT * FROM users WHERE deleted_at IS NULL ORDER BY id
     LIMIT $1 OFFSET $2;
 
 -- name: UpdateUserPassword :one
 UPDATE users SET hashed_password = $1, updated_at = $2
-WHERE id = $3 RETURNING *;
+WHERE id = $3 AND deleted_at IS NULL RETURNING *;
 
--- name: DeleteUser :exec
-DELETE FROM users WHERE id = $1;
+-- name: ArchiveUser :execrows
+UPDATE users SET deleted_at = NOW(), updated_at = NOW()
+WHERE id = $1 AND deleted_at IS NULL;
+
+-- name: RestoreUser :one
+UPDATE users SET deleted_at = NULL, updated_at = NOW()
+WHERE id = $1 AND deleted_at IS NOT NULL RETURNING *;
 
 -- name: DeleteAllUsers :exec
 DELETE FROM users;
 
+-- name: DeleteUsers :exec
+DELETE FROM users WHERE id = ANY(sqlc.arg(ids)::bigint[]);
+
 -- Get a user by ID
 SELECT id, email, username, role FROM users WHERE id = $1 LIMIT 1;
\ No newline at end of file
@@ -0,0 +1,5 @@
+-- name: RecordWebhookEvent :execrows
+-- Nothing is inserted for an event that was already received.
+INSERT INTO webhook_events (provider, event_id, type, payload)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (provider, event_id) DO NOTHING;
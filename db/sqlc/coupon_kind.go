@@ -0,0 +1,28 @@
+package db
+
+import "strings"
+
+// CouponKind is the type of discount a coupon gives, stored in coupons.kind.
+type CouponKind string
+
+const (
+	// CouponPercentage takes value percent off the eligible lines.
+	CouponPercentage CouponKind = "percentage"
+	// CouponFixed takes value off the eligible lines, up to their total.
+	CouponFixed CouponKind = "fixed"
+)
+
+// Valid reports whether k is a known coupon kind.
+func (k CouponKind) Valid() bool {
+	switch k {
+	case CouponPercentage, CouponFixed:
+		return true
+	}
+	return false
+}
+
+// NormalizeCouponCode returns the form coupon codes are stored and looked
+// up in, so customers do not have to match their case.
+func NormalizeCouponCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
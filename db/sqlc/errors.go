@@ -0,0 +1,109 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrEmptyOrder    = errors.New("order must contain at least one item")
+	ErrEmptyCart     = errors.New("cart is empty")
+	ErrOrderNotFound = errors.New("order not found")
+
+	ErrCategoryNotFound = errors.New("category not found")
+
+	ErrPaymentAmountMismatch = errors.New("payment amount does not match the order total")
+	ErrPaymentOrderMismatch  = errors.New("payment transaction belongs to another order")
+	ErrPaymentRecorded       = errors.New("payment transaction was already recorded as failed")
+	ErrPaymentNotFound       = errors.New("payment not found")
+	ErrInvalidEventAmount    = errors.New("event amount must be positive")
+	ErrRefundExceedsPayment  = errors.New("refund exceeds the amount captured")
+
+	ErrEmptyReturn        = errors.New("return must contain at least one item")
+	ErrReturnNotFound     = errors.New("return not found")
+	ErrOrderNotReturnable = errors.New("only delivered orders can be returned")
+
+	ErrCouponNotFound = errors.New("coupon not found")
+
+	ErrOrderPaymentCaptured = errors.New("order has a captured payment that must be refunded first")
+)
+
+// ProductNotFoundError is returned when an order references a product that does not exist.
+type ProductNotFoundError struct {
+	ProductID int64
+}
+
+func (e *ProductNotFoundError) Error() string {
+	return fmt.Sprintf("product %d not found", e.ProductID)
+}
+
+// InsufficientStockError is returned when a product or one of its variants
+// cannot cover the requested quantity. VariantID is zero for product-level stock.
+type InsufficientStockError struct {
+	ProductID int64
+	VariantID int64
+	Requested int32
+	Available int32
+}
+
+func (e *InsufficientStockError) Error() string {
+	if e.VariantID != 0 {
+		return fmt.Sprintf("insufficient stock for variant %d of product %d: requested %d, available %d", e.VariantID, e.ProductID, e.Requested, e.Available)
+	}
+	return fmt.Sprintf("insufficient stock for product %d: requested %d, available %d", e.ProductID, e.Requested, e.Available)
+}
+
+// InvalidTransitionError is returned when an order cannot move between two statuses.
+type InvalidTransitionError struct {
+	From OrderStatus
+	To   OrderStatus
+}
+
+func (e *InvalidTransitionError) Error() string {
+	return fmt.Sprintf("cannot change order status from %s to %s", e.From, e.To)
+}
+
+// VariantNotFoundError is returned when an order references a variant that
+// does not exist or belongs to a different product.
+type VariantNotFoundError struct {
+	ProductID int64
+	VariantID int64
+}
+
+func (e *VariantNotFoundError) Error() string {
+	return fmt.Sprintf("variant %d of product %d not found", e.VariantID, e.ProductID)
+}
+
+// InvalidReturnTransitionError is returned when a return cannot move between two statuses.
+type InvalidReturnTransitionError struct {
+	From ReturnStatus
+	To   ReturnStatus
+}
+
+func (e *InvalidReturnTransitionError) Error() string {
+	return fmt.Sprintf("cannot change return status from %s to %s", e.From, e.To)
+}
+
+// ReturnQuantityError is returned when a return asks for more units of an
+// order item than were ordered and not yet returned. An item that is not
+// part of the order has nothing returnable.
+type ReturnQuantityError struct {
+	OrderItemID int64
+	Requested   int32
+	Returnable  int32
+}
+
+func (e *ReturnQuantityError) Error() string {
+	return fmt.Sprintf("cannot return %d of order item %d: %d returnable", e.Requested, e.OrderItemID, e.Returnable)
+}
+
+// CouponNotApplicableError is returned when a coupon exists but its rules
+// do not allow it on the order being placed.
+type CouponNotApplicableError struct {
+	Code   string
+	Reason string
+}
+
+func (e *CouponNotApplicableError) Error() string {
+	return fmt.Sprintf("coupon %s %s", e.Code, e.Reason)
+}
@@ -6,35 +6,222 @@ package db
 
 import (
 	"database/sql"
+	"encoding/json"
 	"time"
 )
 
+type Cart struct {
+	ID        int64     `json:"id"`
+	UserID    int64     `json:"user_id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type CartItem struct {
+	ID        int64         `json:"id"`
+	CartID    int64         `json:"cart_id"`
+	ProductID int64         `json:"product_id"`
+	Quantity  int32         `json:"quantity"`
+	CreatedAt time.Time     `json:"created_at"`
+	UpdatedAt time.Time     `json:"updated_at"`
+	VariantID sql.NullInt64 `json:"variant_id"`
+}
+
+type Category struct {
+	ID        int64         `json:"id"`
+	Name      string        `json:"name"`
+	Slug      string        `json:"slug"`
+	ParentID  sql.NullInt64 `json:"parent_id"`
+	CreatedAt time.Time     `json:"created_at"`
+	UpdatedAt time.Time     `json:"updated_at"`
+}
+
+type Coupon struct {
+	ID           int64         `json:"id"`
+	Code         string        `json:"code"`
+	Description  string        `json:"description"`
+	Kind         string        `json:"kind"`
+	Value        string        `json:"value"`
+	MinSpend     string        `json:"min_spend"`
+	UsageLimit   sql.NullInt32 `json:"usage_limit"`
+	PerUserLimit sql.NullInt32 `json:"per_user_limit"`
+	StartsAt     sql.NullTime  `json:"starts_at"`
+	EndsAt       sql.NullTime  `json:"ends_at"`
+	Active       bool          `json:"active"`
+	CreatedAt    time.Time     `json:"created_at"`
+	UpdatedAt    time.Time     `json:"updated_at"`
+}
+
+type CouponCategory struct {
+	CouponID   int64 `json:"coupon_id"`
+	CategoryID int64 `json:"category_id"`
+}
+
+type CouponProduct struct {
+	CouponID  int64 `json:"coupon_id"`
+	ProductID int64 `json:"product_id"`
+}
+
+type CouponRedemption struct {
+	ID             int64     `json:"id"`
+	CouponID       int64     `json:"coupon_id"`
+	UserID         int64     `json:"user_id"`
+	OrderID        int64     `json:"order_id"`
+	DiscountAmount string    `json:"discount_amount"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+type IdempotencyKey struct {
+	ID           int64         `json:"id"`
+	UserID       int64         `json:"user_id"`
+	Key          string        `json:"key"`
+	RequestHash  string        `json:"request_hash"`
+	StatusCode   sql.NullInt32 `json:"status_code"`
+	ResponseBody []byte        `json:"response_body"`
+	CreatedAt    time.Time     `json:"created_at"`
+	CompletedAt  sql.NullTime  `json:"completed_at"`
+}
+
+type InventoryMovement struct {
+	ID                int64          `json:"id"`
+	ProductID         int64          `json:"product_id"`
+	VariantID         sql.NullInt64  `json:"variant_id"`
+	Kind              string         `json:"kind"`
+	Quantity          int32          `json:"quantity"`
+	Balance           int32          `json:"balance"`
+	Reason            sql.NullString `json:"reason"`
+	OrderID           sql.NullInt64  `json:"order_id"`
+	CreatedBy         sql.NullInt64  `json:"created_by"`
+	CreatedAt         time.Time      `json:"created_at"`
+	LowStockCheckedAt sql.NullTime   `json:"low_stock_checked_at"`
+}
+
 type Order struct {
-	ID          int64     `json:"id"`
-	UserID      int64     `json:"user_id"`
-	Status      string    `json:"status"`
-	TotalAmount string    `json:"total_amount"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID             int64          `json:"id"`
+	UserID         int64          `json:"user_id"`
+	Status         string         `json:"status"`
+	TotalAmount    string         `json:"total_amount"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+	DiscountAmount string         `json:"discount_amount"`
+	CouponCode     sql.NullString `json:"coupon_code"`
+	TaxAmount      string         `json:"tax_amount"`
+	TaxCountry     sql.NullString `json:"tax_country"`
+	TaxState       sql.NullString `json:"tax_state"`
 }
 
 type OrderItem struct {
-	ID        int64     `json:"id"`
-	OrderID   int64     `json:"order_id"`
-	ProductID int64     `json:"product_id"`
-	Quantity  int32     `json:"quantity"`
-	Price     string    `json:"price"`
-	CreatedAt time.Time `json:"created_at"`
+	ID          int64         `json:"id"`
+	OrderID     int64         `json:"order_id"`
+	ProductID   int64         `json:"product_id"`
+	Quantity    int32         `json:"quantity"`
+	Price       string        `json:"price"`
+	CreatedAt   time.Time     `json:"created_at"`
+	ProductName string        `json:"product_name"`
+	VariantID   sql.NullInt64 `json:"variant_id"`
+}
+
+type OrderStatusHistory struct {
+	ID         int64          `json:"id"`
+	OrderID    int64          `json:"order_id"`
+	FromStatus string         `json:"from_status"`
+	ToStatus   string         `json:"to_status"`
+	ChangedBy  sql.NullInt64  `json:"changed_by"`
+	Note       sql.NullString `json:"note"`
+	CreatedAt  time.Time      `json:"created_at"`
+}
+
+type OrderTaxLine struct {
+	ID            int64  `json:"id"`
+	OrderID       int64  `json:"order_id"`
+	ProductID     int64  `json:"product_id"`
+	TaxClass      string `json:"tax_class"`
+	Jurisdiction  string `json:"jurisdiction"`
+	Rate          string `json:"rate"`
+	TaxableAmount string `json:"taxable_amount"`
+	Amount        string `json:"amount"`
+}
+
+type Payment struct {
+	ID                    int64          `json:"id"`
+	OrderID               int64          `json:"order_id"`
+	Provider              string         `json:"provider"`
+	ProviderTransactionID string         `json:"provider_transaction_id"`
+	Amount                string         `json:"amount"`
+	Status                string         `json:"status"`
+	FailureReason         sql.NullString `json:"failure_reason"`
+	CreatedAt             time.Time      `json:"created_at"`
+	UpdatedAt             time.Time      `json:"updated_at"`
 }
 
 type Product struct {
-	ID          int64          `json:"id"`
-	Name        string         `json:"name"`
-	Description sql.NullString `json:"description"`
-	Price       string         `json:"price"`
-	Stock       int32          `json:"stock"`
-	CreatedAt   time.Time      `json:"created_at"`
-	UpdatedAt   time.Time      `json:"updated_at"`
+	ID               int64          `json:"id"`
+	Name             string         `json:"name"`
+	Description      sql.NullString `json:"description"`
+	Price            string         `json:"price"`
+	Stock            int32          `json:"stock"`
+	CreatedAt        time.Time      `json:"created_at"`
+	UpdatedAt        time.Time      `json:"updated_at"`
+	SearchVector     interface{}    `json:"search_vector"`
+	Sku              sql.NullString `json:"sku"`
+	Version          int32          `json:"version"`
+	DeletedAt        sql.NullTime   `json:"deleted_at"`
+	ReorderThreshold int32          `json:"reorder_threshold"`
+	TaxClass         string         `json:"tax_class"`
+}
+
+type ProductCategory struct {
+	ProductID  int64 `json:"product_id"`
+	CategoryID int64 `json:"category_id"`
+}
+
+type ProductImage struct {
+	ID           int64     `json:"id"`
+	ProductID    int64     `json:"product_id"`
+	StorageKey   string    `json:"storage_key"`
+	ThumbnailKey string    `json:"thumbnail_key"`
+	ContentType  string    `json:"content_type"`
+	Position     int32     `json:"position"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+type ProductVariant struct {
+	ID         int64           `json:"id"`
+	ProductID  int64           `json:"product_id"`
+	Sku        string          `json:"sku"`
+	Attributes json.RawMessage `json:"attributes"`
+	Price      sql.NullString  `json:"price"`
+	Stock      int32           `json:"stock"`
+	CreatedAt  time.Time       `json:"created_at"`
+	UpdatedAt  time.Time       `json:"updated_at"`
+}
+
+type Refund struct {
+	ID               int64         `json:"id"`
+	PaymentID        int64         `json:"payment_id"`
+	ReturnID         sql.NullInt64 `json:"return_id"`
+	Amount           string        `json:"amount"`
+	ProviderRefundID string        `json:"provider_refund_id"`
+	CreatedAt        time.Time     `json:"created_at"`
+}
+
+type Return struct {
+	ID         int64          `json:"id"`
+	OrderID    int64          `json:"order_id"`
+	UserID     int64          `json:"user_id"`
+	Status     string         `json:"status"`
+	Reason     string         `json:"reason"`
+	AdminNote  sql.NullString `json:"admin_note"`
+	ReviewedBy sql.NullInt64  `json:"reviewed_by"`
+	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+}
+
+type ReturnItem struct {
+	ID          int64 `json:"id"`
+	ReturnID    int64 `json:"return_id"`
+	OrderItemID int64 `json:"order_item_id"`
+	Quantity    int32 `json:"quantity"`
 }
 
 type Session struct {
@@ -45,12 +232,34 @@ type Session struct {
 	ExpiresAt time.Time `json:"expires_at"`
 }
 
+type StockReservation struct {
+	ID        int64         `json:"id"`
+	OrderID   int64         `json:"order_id"`
+	ProductID int64         `json:"product_id"`
+	VariantID sql.NullInt64 `json:"variant_id"`
+	Quantity  int32         `json:"quantity"`
+	Status    string        `json:"status"`
+	ExpiresAt time.Time     `json:"expires_at"`
+	CreatedAt time.Time     `json:"created_at"`
+	UpdatedAt time.Time     `json:"updated_at"`
+}
+
 type User struct {
-	ID             int64     `json:"id"`
-	Email          string    `json:"email"`
-	HashedPassword string    `json:"hashed_password"`
-	Username       string    `json:"username"`
-	Role           string    `json:"role"`
-	CreatedAt      time.Time `json:"created_at"`
-	UpdatedAt      time.Time `json:"updated_at"`
+	ID             int64        `json:"id"`
+	Email          string       `json:"email"`
+	HashedPassword string       `json:"hashed_password"`
+	Username       string       `json:"username"`
+	Role           string       `json:"role"`
+	CreatedAt      time.Time    `json:"created_at"`
+	UpdatedAt      time.Time    `json:"updated_at"`
+	DeletedAt      sql.NullTime `json:"deleted_at"`
+}
+
+type WebhookEvent struct {
+	ID         int64           `json:"id"`
+	Provider   string          `json:"provider"`
+	EventID    string          `json:"event_id"`
+	Type       string          `json:"type"`
+	Payload    json.RawMessage `json:"payload"`
+	ReceivedAt time.Time       `json:"received_at"`
 }
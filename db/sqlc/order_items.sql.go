@@ -7,18 +7,21 @@ package db
 
 import (
 	"context"
+	"database/sql"
 )
 
 const addOrderItem = `-- name: AddOrderItem :one
-INSERT INTO order_items (order_id, product_id, quantity, price)
-VALUES ($1, $2, $3, $4) RETURNING id, order_id, product_id, quantity, price, created_at
+INSERT INTO order_items (order_id, product_id, quantity, price, product_name, variant_id)
+VALUES ($1, $2, $3, $4, $5, $6) RETURNING id, order_id, product_id, quantity, price, created_at, product_name, variant_id
 `
 
 type AddOrderItemParams struct {
-	OrderID   int64  `json:"order_id"`
-	ProductID int64  `json:"product_id"`
-	Quantity  int32  `json:"quantity"`
-	Price     string `json:"price"`
+	OrderID     int64         `json:"order_id"`
+	ProductID   int64         `json:"product_id"`
+	Quantity    int32         `json:"quantity"`
+	Price       string        `json:"price"`
+	ProductName string        `json:"product_name"`
+	VariantID   sql.NullInt64 `json:"variant_id"`
 }
 
 func (q *Queries) AddOrderItem(ctx context.Context, arg AddOrderItemParams) (OrderItem, error) {
@@ -27,6 +30,8 @@ func (q *Queries) AddOrderItem(ctx context.Context, arg AddOrderItemParams) (Ord
 		arg.ProductID,
 		arg.Quantity,
 		arg.Price,
+		arg.ProductName,
+		arg.VariantID,
 	)
 	var i OrderItem
 	err := row.Scan(
@@ -36,12 +41,14 @@ func (q *Queries) AddOrderItem(ctx context.Context, arg AddOrderItemParams) (Ord
 		&i.Quantity,
 		&i.Price,
 		&i.CreatedAt,
+		&i.ProductName,
+		&i.VariantID,
 	)
 	return i, err
 }
 
 const listOrderItems = `-- name: ListOrderItems :many
-SELECT id, order_id, product_id, quantity, price, created_at FROM order_items WHERE order_id = $1
+SELECT id, order_id, product_id, quantity, price, created_at, product_name, variant_id FROM order_items WHERE order_id = $1 ORDER BY id
 `
 
 func (q *Queries) ListOrderItems(ctx context.Context, orderID int64) ([]OrderItem, error) {
@@ -60,6 +67,8 @@ func (q *Queries) ListOrderItems(ctx context.Context, orderID int64) ([]OrderIte
 			&i.Quantity,
 			&i.Price,
 			&i.CreatedAt,
+			&i.ProductName,
+			&i.VariantID,
 		); err != nil {
 			return nil, err
 		}
@@ -0,0 +1,53 @@
+package db
+
+// OrderStatus is the lifecycle state stored in orders.status.
+type OrderStatus string
+
+const (
+	OrderStatusPending   OrderStatus = "Pending"
+	OrderStatusPaid      OrderStatus = "Paid"
+	OrderStatusShipped   OrderStatus = "Shipped"
+	OrderStatusDelivered OrderStatus = "Delivered"
+	OrderStatusCancelled OrderStatus = "Cancelled"
+	OrderStatusRefunded  OrderStatus = "Refunded"
+	// OrderStatusExpired is set by the reservation sweeper on pending orders
+	// that were not paid in time.
+	OrderStatusExpired OrderStatus = "Expired"
+)
+
+// orderTransitions lists the statuses each status may move to.
+// Cancelled, Refunded and Expired are terminal.
+var orderTransitions = map[OrderStatus][]OrderStatus{
+	OrderStatusPending:   {OrderStatusPaid, OrderStatusCancelled, OrderStatusExpired},
+	OrderStatusPaid:      {OrderStatusShipped, OrderStatusCancelled, OrderStatusRefunded},
+	OrderStatusShipped:   {OrderStatusDelivered},
+	OrderStatusDelivered: {OrderStatusRefunded},
+	OrderStatusCancelled: {},
+	OrderStatusRefunded:  {},
+	OrderStatusExpired:   {},
+}
+
+// Valid reports whether s is a known order status.
+func (s OrderStatus) Valid() bool {
+	_, ok := orderTransitions[s]
+	return ok
+}
+
+// CanTransitionTo reports whether an order in status s may move to next.
+func (s OrderStatus) CanTransitionTo(next OrderStatus) bool {
+	for _, allowed := range orderTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// Reservation statuses stored in stock_reservations.status. Active
+// reservations hold stock for a pending order; they are committed when the
+// order is paid and released when it is cancelled or expires.
+const (
+	ReservationActive    = "active"
+	ReservationCommitted = "committed"
+	ReservationReleased  = "released"
+)
@@ -7,30 +7,37 @@ package db
 
 import (
 	"context"
+	"database/sql"
+	"time"
 )
 
-const cancelOrder = `-- name: CancelOrder :exec
-UPDATE orders SET status = 'Cancelled' WHERE id = $1 AND status = 'Pending'
-`
-
-func (q *Queries) CancelOrder(ctx context.Context, id int64) error {
-	_, err := q.db.ExecContext(ctx, cancelOrder, id)
-	return err
-}
-
 const createOrder = `-- name: CreateOrder :one
-INSERT INTO orders (user_id, total_amount, status)
-VALUES ($1, $2, $3) RETURNING id, user_id, status, total_amount, created_at, updated_at
+INSERT INTO orders (user_id, total_amount, status, discount_amount, coupon_code, tax_amount, tax_country, tax_state)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id, user_id, status, total_amount, created_at, updated_at, discount_amount, coupon_code, tax_amount, tax_country, tax_state
 `
 
 type CreateOrderParams struct {
-	UserID      int64  `json:"user_id"`
-	TotalAmount string `json:"total_amount"`
-	Status      string `json:"status"`
+	UserID         int64          `json:"user_id"`
+	TotalAmount    string         `json:"total_amount"`
+	Status         string         `json:"status"`
+	DiscountAmount string         `json:"discount_amount"`
+	CouponCode     sql.NullString `json:"coupon_code"`
+	TaxAmount      string         `json:"tax_amount"`
+	TaxCountry     sql.NullString `json:"tax_country"`
+	TaxState       sql.NullString `json:"tax_state"`
 }
 
 func (q *Queries) CreateOrder(ctx context.Context, arg CreateOrderParams) (Order, error) {
-	row := q.db.QueryRowContext(ctx, createOrder, arg.UserID, arg.TotalAmount, arg.Status)
+	row := q.db.QueryRowContext(ctx, createOrder,
+		arg.UserID,
+		arg.TotalAmount,
+		arg.Status,
+		arg.DiscountAmount,
+		arg.CouponCode,
+		arg.TaxAmount,
+		arg.TaxCountry,
+		arg.TaxState,
+	)
 	var i Order
 	err := row.Scan(
 		&i.ID,
@@ -39,12 +46,17 @@ func (q *Queries) CreateOrder(ctx context.Context, arg CreateOrderParams) (Order
 		&i.TotalAmount,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.DiscountAmount,
+		&i.CouponCode,
+		&i.TaxAmount,
+		&i.TaxCountry,
+		&i.TaxState,
 	)
 	return i, err
 }
 
 const getOrderByID = `-- name: GetOrderByID :one
-SELECT id, user_id, status, total_amount, created_at, updated_at FROM orders WHERE id = $1
+SELECT id, user_id, status, total_amount, created_at, updated_at, discount_amount, coupon_code, tax_amount, tax_country, tax_state FROM orders WHERE id = $1
 `
 
 func (q *Queries) GetOrderByID(ctx context.Context, id int64) (Order, error) {
@@ -57,12 +69,40 @@ func (q *Queries) GetOrderByID(ctx context.Context, id int64) (Order, error) {
 		&i.TotalAmount,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.DiscountAmount,
+		&i.CouponCode,
+		&i.TaxAmount,
+		&i.TaxCountry,
+		&i.TaxState,
+	)
+	return i, err
+}
+
+const getOrderForUpdate = `-- name: GetOrderForUpdate :one
+SELECT id, user_id, status, total_amount, created_at, updated_at, discount_amount, coupon_code, tax_amount, tax_country, tax_state FROM orders WHERE id = $1 FOR UPDATE
+`
+
+func (q *Queries) GetOrderForUpdate(ctx context.Context, id int64) (Order, error) {
+	row := q.db.QueryRowContext(ctx, getOrderForUpdate, id)
+	var i Order
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Status,
+		&i.TotalAmount,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DiscountAmount,
+		&i.CouponCode,
+		&i.TaxAmount,
+		&i.TaxCountry,
+		&i.TaxState,
 	)
 	return i, err
 }
 
 const listUserOrders = `-- name: ListUserOrders :many
-SELECT id, user_id, status, total_amount, created_at, updated_at FROM orders WHERE user_id = $1 ORDER BY id LIMIT $2 OFFSET $3
+SELECT id, user_id, status, total_amount, created_at, updated_at, discount_amount, coupon_code, tax_amount, tax_country, tax_state FROM orders WHERE user_id = $1 ORDER BY id LIMIT $2 OFFSET $3
 `
 
 type ListUserOrdersParams struct {
@@ -87,6 +127,155 @@ func (q *Queries) ListUserOrders(ctx context.Context, arg ListUserOrdersParams)
 			&i.TotalAmount,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.DiscountAmount,
+			&i.CouponCode,
+			&i.TaxAmount,
+			&i.TaxCountry,
+			&i.TaxState,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const orderSummary = `-- name: OrderSummary :many
+SELECT date_trunc('day', created_at)::date AS day,
+       status,
+       COUNT(*) AS order_count,
+       COALESCE(SUM(total_amount), 0)::numeric AS revenue
+FROM orders
+WHERE ($1::timestamptz IS NULL OR created_at >= $1)
+  AND ($2::timestamptz IS NULL OR created_at < $2)
+GROUP BY day, status
+ORDER BY day, status
+`
+
+type OrderSummaryParams struct {
+	CreatedFrom sql.NullTime `json:"created_from"`
+	CreatedTo   sql.NullTime `json:"created_to"`
+}
+
+type OrderSummaryRow struct {
+	Day        time.Time `json:"day"`
+	Status     string    `json:"status"`
+	OrderCount int64     `json:"order_count"`
+	Revenue    string    `json:"revenue"`
+}
+
+func (q *Queries) OrderSummary(ctx context.Context, arg OrderSummaryParams) ([]OrderSummaryRow, error) {
+	rows, err := q.db.QueryContext(ctx, orderSummary, arg.CreatedFrom, arg.CreatedTo)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []OrderSummaryRow{}
+	for rows.Next() {
+		var i OrderSummaryRow
+		if err := rows.Scan(
+			&i.Day,
+			&i.Status,
+			&i.OrderCount,
+			&i.Revenue,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const searchOrders = `-- name: SearchOrders :many
+SELECT id, user_id, status, total_amount, created_at, updated_at, discount_amount, coupon_code, tax_amount, tax_country, tax_state FROM orders
+WHERE ($1::text IS NULL OR status = $1)
+  AND ($2::bigint IS NULL OR user_id = $2)
+  AND ($3::timestamptz IS NULL OR created_at >= $3)
+  AND ($4::timestamptz IS NULL OR created_at < $4)
+  AND ($5::numeric IS NULL OR total_amount >= $5)
+  AND ($6::numeric IS NULL OR total_amount <= $6)
+  AND (
+    $7::bigint IS NULL
+    OR ($8::text = 'created_at' AND NOT $9::bool
+        AND (created_at, id) > ($10::timestamptz, $7))
+    OR ($8 = 'created_at' AND $9
+        AND (created_at, id) < ($10, $7))
+    OR ($8 = 'total_amount' AND NOT $9
+        AND (total_amount, id) > ($11::numeric, $7))
+    OR ($8 = 'total_amount' AND $9
+        AND (total_amount, id) < ($11, $7))
+  )
+ORDER BY
+  CASE WHEN $8 = 'created_at' AND NOT $9 THEN created_at END ASC,
+  CASE WHEN $8 = 'created_at' AND $9 THEN created_at END DESC,
+  CASE WHEN $8 = 'total_amount' AND NOT $9 THEN total_amount END ASC,
+  CASE WHEN $8 = 'total_amount' AND $9 THEN total_amount END DESC,
+  CASE WHEN NOT $9 THEN id END ASC,
+  CASE WHEN $9 THEN id END DESC
+LIMIT $12
+`
+
+type SearchOrdersParams struct {
+	Status          sql.NullString `json:"status"`
+	UserID          sql.NullInt64  `json:"user_id"`
+	CreatedFrom     sql.NullTime   `json:"created_from"`
+	CreatedTo       sql.NullTime   `json:"created_to"`
+	MinAmount       sql.NullString `json:"min_amount"`
+	MaxAmount       sql.NullString `json:"max_amount"`
+	CursorID        sql.NullInt64  `json:"cursor_id"`
+	SortBy          string         `json:"sort_by"`
+	Descending      bool           `json:"descending"`
+	CursorCreatedAt sql.NullTime   `json:"cursor_created_at"`
+	CursorAmount    sql.NullString `json:"cursor_amount"`
+	PageSize        int32          `json:"page_size"`
+}
+
+func (q *Queries) SearchOrders(ctx context.Context, arg SearchOrdersParams) ([]Order, error) {
+	rows, err := q.db.QueryContext(ctx, searchOrders,
+		arg.Status,
+		arg.UserID,
+		arg.CreatedFrom,
+		arg.CreatedTo,
+		arg.MinAmount,
+		arg.MaxAmount,
+		arg.CursorID,
+		arg.SortBy,
+		arg.Descending,
+		arg.CursorCreatedAt,
+		arg.CursorAmount,
+		arg.PageSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Order{}
+	for rows.Next() {
+		var i Order
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Status,
+			&i.TotalAmount,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.DiscountAmount,
+			&i.CouponCode,
+			&i.TaxAmount,
+			&i.TaxCountry,
+			&i.TaxState,
 		); err != nil {
 			return nil, err
 		}
@@ -102,7 +291,7 @@ func (q *Queries) ListUserOrders(ctx context.Context, arg ListUserOrdersParams)
 }
 
 const updateOrderStatus = `-- name: UpdateOrderStatus :one
-UPDATE orders SET status = $1 WHERE id = $2 RETURNING id, user_id, status, total_amount, created_at, updated_at
+UPDATE orders SET status = $1, updated_at = NOW() WHERE id = $2 RETURNING id, user_id, status, total_amount, created_at, updated_at, discount_amount, coupon_code, tax_amount, tax_country, tax_state
 `
 
 type UpdateOrderStatusParams struct {
@@ -120,6 +309,11 @@ func (q *Queries) UpdateOrderStatus(ctx context.Context, arg UpdateOrderStatusPa
 		&i.TotalAmount,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.DiscountAmount,
+		&i.CouponCode,
+		&i.TaxAmount,
+		&i.TaxCountry,
+		&i.TaxState,
 	)
 	return i, err
 }
@@ -11,9 +11,86 @@ import (
 	"time"
 )
 
+const addProductStock = `-- name: AddProductStock :one
+UPDATE products
+SET stock = stock + $1, version = version + 1, updated_at = NOW()
+WHERE id = $2 RETURNING id, name, description, price, stock, created_at, updated_at, search_vector, sku, version, deleted_at, reorder_threshold, tax_class
+`
+
+type AddProductStockParams struct {
+	Amount int32 `json:"amount"`
+	ID     int64 `json:"id"`
+}
+
+func (q *Queries) AddProductStock(ctx context.Context, arg AddProductStockParams) (Product, error) {
+	row := q.db.QueryRowContext(ctx, addProductStock, arg.Amount, arg.ID)
+	var i Product
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Description,
+		&i.Price,
+		&i.Stock,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.SearchVector,
+		&i.Sku,
+		&i.Version,
+		&i.DeletedAt,
+		&i.ReorderThreshold,
+		&i.TaxClass,
+	)
+	return i, err
+}
+
+const archiveProduct = `-- name: ArchiveProduct :execrows
+UPDATE products
+SET deleted_at = NOW(), version = version + 1, updated_at = NOW()
+WHERE id = $1 AND deleted_at IS NULL
+`
+
+func (q *Queries) ArchiveProduct(ctx context.Context, id int64) (int64, error) {
+	result, err := q.db.ExecContext(ctx, archiveProduct, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const countSearchProducts = `-- name: CountSearchProducts :one
+SELECT COUNT(*) FROM products p
+WHERE p.deleted_at IS NULL
+  AND ($1::text = '' OR p.search_vector @@ websearch_to_tsquery('english', $1))
+  AND ($2::numeric IS NULL OR p.price >= $2)
+  AND ($3::numeric IS NULL OR p.price <= $3)
+  AND (NOT $4::bool OR p.stock > (
+    SELECT COALESCE(SUM(r.quantity), 0) FROM stock_reservations r
+    WHERE r.product_id = p.id AND r.variant_id IS NULL AND r.status = 'active'))
+`
+
+type CountSearchProductsParams struct {
+	Query       string         `json:"query"`
+	MinPrice    sql.NullString `json:"min_price"`
+	MaxPrice    sql.NullString `json:"max_price"`
+	InStockOnly bool           `json:"in_stock_only"`
+}
+
+// Counts the products SearchProducts matches, for pages past the last match.
+func (q *Queries) CountSearchProducts(ctx context.Context, arg CountSearchProductsParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countSearchProducts,
+		arg.Query,
+		arg.MinPrice,
+		arg.MaxPrice,
+		arg.InStockOnly,
+	)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createProduct = `-- name: CreateProduct :one
-INSERT INTO products (name, description, price, stock)
-VALUES ($1, $2, $3, $4) RETURNING id, name, description, price, stock, created_at, updated_at
+INSERT INTO products (name, description, price, stock, sku)
+VALUES ($1, $2, $3, $4, $5) RETURNING id, name, description, price, stock, created_at, updated_at, search_vector, sku, version, deleted_at, reorder_threshold, tax_class
 `
 
 type CreateProductParams struct {
@@ -21,6 +98,7 @@ type CreateProductParams struct {
 	Description sql.NullString `json:"description"`
 	Price       float64         `json:"price"`
 	Stock       int32          `json:"stock"`
+	Sku         sql.NullString `json:"sku"`
 }
 
 func (q *Queries) CreateProduct(ctx context.Context, arg CreateProductParams) (Product, error) {
@@ -29,6 +107,7 @@ func (q *Queries) CreateProduct(ctx context.Context, arg CreateProductParams) (P
 		arg.Description,
 		arg.Price,
 		arg.Stock,
+		arg.Sku,
 	)
 	var i Product
 	err := row.Scan(
@@ -39,25 +118,47 @@ func (q *Queries) CreateProduct(ctx context.Context, arg CreateProductParams) (P
 		&i.Stock,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.SearchVector,
+		&i.Sku,
+		&i.Version,
+		&i.DeletedAt,
+		&i.ReorderThreshold,
+		&i.TaxClass,
 	)
 	return i, err
 }
 
-const deleteProduct = `-- name: DeleteProduct :exec
-DELETE FROM products WHERE id = $1
+const getProductByID = `-- name: GetProductByID :one
+SELECT id, name, description, price, stock, created_at, updated_at, search_vector, sku, version, deleted_at, reorder_threshold, tax_class FROM products WHERE id = $1 AND deleted_at IS NULL
 `
 
-func (q *Queries) DeleteProduct(ctx context.Context, id int64) error {
-	_, err := q.db.ExecContext(ctx, deleteProduct, id)
-	return err
+func (q *Queries) GetProductByID(ctx context.Context, id int64) (Product, error) {
+	row := q.db.QueryRowContext(ctx, getProductByID, id)
+	var i Product
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Description,
+		&i.Price,
+		&i.Stock,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.SearchVector,
+		&i.Sku,
+		&i.Version,
+		&i.DeletedAt,
+		&i.ReorderThreshold,
+		&i.TaxClass,
+	)
+	return i, err
 }
 
-const getProductByID = `-- name: GetProductByID :one
-SELECT id, name, description, price, stock, created_at, updated_at FROM products WHERE id = $1
+const getProductByIDWithArchived = `-- name: GetProductByIDWithArchived :one
+SELECT id, name, description, price, stock, created_at, updated_at, search_vector, sku, version, deleted_at, reorder_threshold, tax_class FROM products WHERE id = $1
 `
 
-func (q *Queries) GetProductByID(ctx context.Context, id int64) (Product, error) {
-	row := q.db.QueryRowContext(ctx, getProductByID, id)
+func (q *Queries) GetProductByIDWithArchived(ctx context.Context, id int64) (Product, error) {
+	row := q.db.QueryRowContext(ctx, getProductByIDWithArchived, id)
 	var i Product
 	err := row.Scan(
 		&i.ID,
@@ -67,21 +168,173 @@ func (q *Queries) GetProductByID(ctx context.Context, id int64) (Product, error)
 		&i.Stock,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.SearchVector,
+		&i.Sku,
+		&i.Version,
+		&i.DeletedAt,
+		&i.ReorderThreshold,
+		&i.TaxClass,
 	)
 	return i, err
 }
 
+const getProductByName = `-- name: GetProductByName :one
+SELECT id, name, description, price, stock, created_at, updated_at, search_vector, sku, version, deleted_at, reorder_threshold, tax_class FROM products WHERE name = $1 AND deleted_at IS NULL ORDER BY id LIMIT 1
+`
+
+func (q *Queries) GetProductByName(ctx context.Context, name string) (Product, error) {
+	row := q.db.QueryRowContext(ctx, getProductByName, name)
+	var i Product
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Description,
+		&i.Price,
+		&i.Stock,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.SearchVector,
+		&i.Sku,
+		&i.Version,
+		&i.DeletedAt,
+		&i.ReorderThreshold,
+		&i.TaxClass,
+	)
+	return i, err
+}
+
+const getProductBySku = `-- name: GetProductBySku :one
+SELECT id, name, description, price, stock, created_at, updated_at, search_vector, sku, version, deleted_at, reorder_threshold, tax_class FROM products WHERE sku = $1
+`
+
+func (q *Queries) GetProductBySku(ctx context.Context, sku sql.NullString) (Product, error) {
+	row := q.db.QueryRowContext(ctx, getProductBySku, sku)
+	var i Product
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Description,
+		&i.Price,
+		&i.Stock,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.SearchVector,
+		&i.Sku,
+		&i.Version,
+		&i.DeletedAt,
+		&i.ReorderThreshold,
+		&i.TaxClass,
+	)
+	return i, err
+}
+
+const getProductForUpdate = `-- name: GetProductForUpdate :one
+SELECT id, name, description, price, stock, created_at, updated_at, search_vector, sku, version, deleted_at, reorder_threshold, tax_class FROM products WHERE id = $1 AND deleted_at IS NULL FOR UPDATE
+`
+
+func (q *Queries) GetProductForUpdate(ctx context.Context, id int64) (Product, error) {
+	row := q.db.QueryRowContext(ctx, getProductForUpdate, id)
+	var i Product
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Description,
+		&i.Price,
+		&i.Stock,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.SearchVector,
+		&i.Sku,
+		&i.Version,
+		&i.DeletedAt,
+		&i.ReorderThreshold,
+		&i.TaxClass,
+	)
+	return i, err
+}
+
+const listLowStockProducts = `-- name: ListLowStockProducts :many
+SELECT p.id, p.name, p.sku, p.reorder_threshold, s.total_stock
+FROM products p
+CROSS JOIN LATERAL (
+    SELECT (p.stock + COALESCE(SUM(v.stock), 0))::int AS total_stock
+    FROM product_variants v
+    WHERE v.product_id = p.id
+) s
+WHERE p.deleted_at IS NULL AND s.total_stock < p.reorder_threshold
+ORDER BY s.total_stock, p.id
+LIMIT $1 OFFSET $2
+`
+
+type ListLowStockProductsParams struct {
+	PageLimit  int32 `json:"page_limit"`
+	PageOffset int32 `json:"page_offset"`
+}
+
+type ListLowStockProductsRow struct {
+	ID               int64          `json:"id"`
+	Name             string         `json:"name"`
+	Sku              sql.NullString `json:"sku"`
+	ReorderThreshold int32          `json:"reorder_threshold"`
+	TotalStock       int32          `json:"total_stock"`
+}
+
+// Stock counts the product's own units and those of all its variants.
+func (q *Queries) ListLowStockProducts(ctx context.Context, arg ListLowStockProductsParams) ([]ListLowStockProductsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listLowStockProducts, arg.PageLimit, arg.PageOffset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListLowStockProductsRow{}
+	for rows.Next() {
+		var i ListLowStockProductsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Sku,
+			&i.ReorderThreshold,
+			&i.TotalStock,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listProducts = `-- name: ListProducts :many
-SELECT id, name, description, price, stock, created_at, updated_at FROM products ORDER BY id LIMIT $1 OFFSET $2
+WITH RECURSIVE category_tree AS (
+    SELECT c.id FROM categories c WHERE c.id = $1
+    UNION ALL
+    SELECT child.id FROM categories child JOIN category_tree ct ON child.parent_id = ct.id
+)
+SELECT id, name, description, price, stock, created_at, updated_at, search_vector, sku, version, deleted_at, reorder_threshold, tax_class FROM products
+WHERE deleted_at IS NULL
+  AND ($1::bigint IS NULL
+   OR EXISTS (
+       SELECT 1 FROM product_categories pc
+       WHERE pc.product_id = products.id
+         AND pc.category_id IN (SELECT category_tree.id FROM category_tree)
+   ))
+ORDER BY id
+LIMIT $2 OFFSET $3
 `
 
 type ListProductsParams struct {
-	Limit  int32 `json:"limit"`
-	Offset int32 `json:"offset"`
+	CategoryID sql.NullInt64 `json:"category_id"`
+	PageLimit  int32         `json:"page_limit"`
+	PageOffset int32         `json:"page_offset"`
 }
 
 func (q *Queries) ListProducts(ctx context.Context, arg ListProductsParams) ([]Product, error) {
-	rows, err := q.db.QueryContext(ctx, listProducts, arg.Limit, arg.Offset)
+	rows, err := q.db.QueryContext(ctx, listProducts, arg.CategoryID, arg.PageLimit, arg.PageOffset)
 	if err != nil {
 		return nil, err
 	}
@@ -97,6 +350,12 @@ func (q *Queries) ListProducts(ctx context.Context, arg ListProductsParams) ([]P
 			&i.Stock,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.SearchVector,
+			&i.Sku,
+			&i.Version,
+			&i.DeletedAt,
+			&i.ReorderThreshold,
+			&i.TaxClass,
 		); err != nil {
 			return nil, err
 		}
@@ -111,19 +370,246 @@ func (q *Queries) ListProducts(ctx context.Context, arg ListProductsParams) ([]P
 	return items, nil
 }
 
-const updateProduct = `-- name: UpdateProduct :one
+const listProductsAfter = `-- name: ListProductsAfter :many
+SELECT id, name, description, price, stock, created_at, updated_at, search_vector, sku, version, deleted_at, reorder_threshold, tax_class FROM products
+WHERE id > $1 AND deleted_at IS NULL
+ORDER BY id
+LIMIT $2
+`
+
+type ListProductsAfterParams struct {
+	AfterID   int64 `json:"after_id"`
+	PageLimit int32 `json:"page_limit"`
+}
+
+func (q *Queries) ListProductsAfter(ctx context.Context, arg ListProductsAfterParams) ([]Product, error) {
+	rows, err := q.db.QueryContext(ctx, listProductsAfter, arg.AfterID, arg.PageLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Product{}
+	for rows.Next() {
+		var i Product
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Description,
+			&i.Price,
+			&i.Stock,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.SearchVector,
+			&i.Sku,
+			&i.Version,
+			&i.DeletedAt,
+			&i.ReorderThreshold,
+			&i.TaxClass,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const patchProduct = `-- name: PatchProduct :one
 UPDATE products
-SET name = $1, description = $2, price = $3, stock = $4, updated_at = $5
-WHERE id = $6 RETURNING id, name, description, price, stock, created_at, updated_at
+SET name = COALESCE($1, name),
+    description = CASE WHEN $2::bool THEN $3 ELSE description END,
+    price = COALESCE($4, price),
+    stock = COALESCE($5, stock),
+    sku = CASE WHEN $6::bool THEN $7 ELSE sku END,
+    reorder_threshold = COALESCE($8, reorder_threshold),
+    tax_class = COALESCE($9, tax_class),
+    version = version + 1,
+    updated_at = NOW()
+WHERE id = $10 AND deleted_at IS NULL
+  AND ($11::int IS NULL OR version = $11)
+RETURNING id, name, description, price, stock, created_at, updated_at, search_vector, sku, version, deleted_at, reorder_threshold, tax_class
 `
 
-type UpdateProductParams struct {
+type PatchProductParams struct {
+	Name             sql.NullString `json:"name"`
+	SetDescription   bool           `json:"set_description"`
+	Description      sql.NullString `json:"description"`
+	Price            sql.NullString `json:"price"`
+	Stock            sql.NullInt32  `json:"stock"`
+	SetSku           bool           `json:"set_sku"`
+	Sku              sql.NullString `json:"sku"`
+	ReorderThreshold sql.NullInt32  `json:"reorder_threshold"`
+	TaxClass         sql.NullString `json:"tax_class"`
+	ID               int64          `json:"id"`
+	ExpectedVersion  sql.NullInt32  `json:"expected_version"`
+}
+
+func (q *Queries) PatchProduct(ctx context.Context, arg PatchProductParams) (Product, error) {
+	row := q.db.QueryRowContext(ctx, patchProduct,
+		arg.Name,
+		arg.SetDescription,
+		arg.Description,
+		arg.Price,
+		arg.Stock,
+		arg.SetSku,
+		arg.Sku,
+		arg.ReorderThreshold,
+		arg.TaxClass,
+		arg.ID,
+		arg.ExpectedVersion,
+	)
+	var i Product
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Description,
+		&i.Price,
+		&i.Stock,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.SearchVector,
+		&i.Sku,
+		&i.Version,
+		&i.DeletedAt,
+		&i.ReorderThreshold,
+		&i.TaxClass,
+	)
+	return i, err
+}
+
+const restoreProduct = `-- name: RestoreProduct :one
+UPDATE products
+SET deleted_at = NULL, version = version + 1, updated_at = NOW()
+WHERE id = $1 AND deleted_at IS NOT NULL RETURNING id, name, description, price, stock, created_at, updated_at, search_vector, sku, version, deleted_at, reorder_threshold, tax_class
+`
+
+func (q *Queries) RestoreProduct(ctx context.Context, id int64) (Product, error) {
+	row := q.db.QueryRowContext(ctx, restoreProduct, id)
+	var i Product
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Description,
+		&i.Price,
+		&i.Stock,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.SearchVector,
+		&i.Sku,
+		&i.Version,
+		&i.DeletedAt,
+		&i.ReorderThreshold,
+		&i.TaxClass,
+	)
+	return i, err
+}
+
+const searchProducts = `-- name: SearchProducts :many
+SELECT p.id, p.name, p.description, p.price, p.stock, p.created_at, p.updated_at,
+       ts_rank(p.search_vector, websearch_to_tsquery('english', $1::text))::real AS rank,
+       COUNT(*) OVER() AS total_count
+FROM products p
+WHERE p.deleted_at IS NULL
+  AND ($1 = '' OR p.search_vector @@ websearch_to_tsquery('english', $1))
+  AND ($2::numeric IS NULL OR p.price >= $2)
+  AND ($3::numeric IS NULL OR p.price <= $3)
+  AND (NOT $4::bool OR p.stock > (
+    SELECT COALESCE(SUM(r.quantity), 0) FROM stock_reservations r
+    WHERE r.product_id = p.id AND r.variant_id IS NULL AND r.status = 'active'))
+ORDER BY
+  CASE WHEN $5::text = 'relevance' THEN ts_rank(p.search_vector, websearch_to_tsquery('english', $1)) END DESC,
+  CASE WHEN $5 = 'price_asc' THEN p.price END ASC,
+  CASE WHEN $5 = 'price_desc' THEN p.price END DESC,
+  CASE WHEN $5 = 'newest' THEN p.created_at END DESC,
+  p.id
+LIMIT $6 OFFSET $7
+`
+
+type SearchProductsParams struct {
+	Query       string         `json:"query"`
+	MinPrice    sql.NullString `json:"min_price"`
+	MaxPrice    sql.NullString `json:"max_price"`
+	InStockOnly bool           `json:"in_stock_only"`
+	SortBy      string         `json:"sort_by"`
+	PageLimit   int32          `json:"page_limit"`
+	PageOffset  int32          `json:"page_offset"`
+}
+
+type SearchProductsRow struct {
+	ID          int64          `json:"id"`
 	Name        string         `json:"name"`
 	Description sql.NullString `json:"description"`
-	Price       float64         `json:"price"`
+	Price       string         `json:"price"`
 	Stock       int32          `json:"stock"`
+	CreatedAt   time.Time      `json:"created_at"`
 	UpdatedAt   time.Time      `json:"updated_at"`
-	ID          int64          `json:"id"`
+	Rank        float32        `json:"rank"`
+	TotalCount  int64          `json:"total_count"`
+}
+
+func (q *Queries) SearchProducts(ctx context.Context, arg SearchProductsParams) ([]SearchProductsRow, error) {
+	rows, err := q.db.QueryContext(ctx, searchProducts,
+		arg.Query,
+		arg.MinPrice,
+		arg.MaxPrice,
+		arg.InStockOnly,
+		arg.SortBy,
+		arg.PageLimit,
+		arg.PageOffset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []SearchProductsRow{}
+	for rows.Next() {
+		var i SearchProductsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Description,
+			&i.Price,
+			&i.Stock,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Rank,
+			&i.TotalCount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const updateProduct = `-- name: UpdateProduct :one
+UPDATE products
+SET name = $1, description = $2, price = $3, stock = $4,
+    updated_at = $5, version = version + 1
+WHERE id = $6 AND deleted_at IS NULL
+  AND ($7::int IS NULL OR version = $7)
+RETURNING id, name, description, price, stock, created_at, updated_at, search_vector, sku, version, deleted_at, reorder_threshold, tax_class
+`
+
+type UpdateProductParams struct {
+	Name            string         `json:"name"`
+	Description     sql.NullString `json:"description"`
+	Price           float64        `json:"price"`
+	Stock           int32          `json:"stock"`
+	UpdatedAt       time.Time      `json:"updated_at"`
+	ID              int64          `json:"id"`
+	ExpectedVersion sql.NullInt32  `json:"expected_version"`
 }
 
 func (q *Queries) UpdateProduct(ctx context.Context, arg UpdateProductParams) (Product, error) {
@@ -134,6 +620,7 @@ func (q *Queries) UpdateProduct(ctx context.Context, arg UpdateProductParams) (P
 		arg.Stock,
 		arg.UpdatedAt,
 		arg.ID,
+		arg.ExpectedVersion,
 	)
 	var i Product
 	err := row.Scan(
@@ -144,6 +631,12 @@ func (q *Queries) UpdateProduct(ctx context.Context, arg UpdateProductParams) (P
 		&i.Stock,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.SearchVector,
+		&i.Sku,
+		&i.Version,
+		&i.DeletedAt,
+		&i.ReorderThreshold,
+		&i.TaxClass,
 	)
 	return i, err
 }
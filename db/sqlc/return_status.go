@@ -0,0 +1,36 @@
+package db
+
+// ReturnStatus is the state of a return request stored in returns.status.
+type ReturnStatus string
+
+const (
+	ReturnRequested ReturnStatus = "requested"
+	ReturnApproved  ReturnStatus = "approved"
+	ReturnRejected  ReturnStatus = "rejected"
+	ReturnReceived  ReturnStatus = "received"
+)
+
+// returnTransitions lists the statuses each return status may move to.
+// Rejected and Received are terminal.
+var returnTransitions = map[ReturnStatus][]ReturnStatus{
+	ReturnRequested: {ReturnApproved, ReturnRejected},
+	ReturnApproved:  {ReturnReceived},
+	ReturnRejected:  {},
+	ReturnReceived:  {},
+}
+
+// Valid reports whether s is a known return status.
+func (s ReturnStatus) Valid() bool {
+	_, ok := returnTransitions[s]
+	return ok
+}
+
+// CanTransitionTo reports whether a return in status s may move to next.
+func (s ReturnStatus) CanTransitionTo(next ReturnStatus) bool {
+	for _, allowed := range returnTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
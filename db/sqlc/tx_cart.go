@@ -0,0 +1,66 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/adedaryorh/ecommerceapi/tax"
+)
+
+type CheckoutCartTxParams struct {
+	UserID         int64          `json:"user_id"`
+	ReservationTTL time.Duration  `json:"reservation_ttl"`
+	CouponCode     string         `json:"coupon_code"`
+	Tax            tax.Calculator `json:"-"`
+	TaxAddress     tax.Address    `json:"tax_address"`
+}
+
+// CheckoutCartTx converts the user's cart into an order priced from the
+// catalog and empties the cart, all within a single transaction.
+func (store *Store) CheckoutCartTx(ctx context.Context, arg CheckoutCartTxParams) (PlaceOrderTxResult, error) {
+	var result PlaceOrderTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		// Locking the cart stops two concurrent checkouts from ordering it twice.
+		cart, err := q.GetCartForUpdate(ctx, arg.UserID)
+		if err == sql.ErrNoRows {
+			return ErrEmptyCart
+		} else if err != nil {
+			return err
+		}
+
+		cartItems, err := q.ListCartItems(ctx, cart.ID)
+		if err != nil {
+			return err
+		}
+		if len(cartItems) == 0 {
+			return ErrEmptyCart
+		}
+
+		orderArg := PlaceOrderTxParams{
+			UserID:         arg.UserID,
+			Items:          []PlaceOrderItem{},
+			ReservationTTL: arg.ReservationTTL,
+			CouponCode:     arg.CouponCode,
+			Tax:            arg.Tax,
+			TaxAddress:     arg.TaxAddress,
+		}
+		for _, item := range cartItems {
+			orderArg.Items = append(orderArg.Items, PlaceOrderItem{
+				ProductID: item.ProductID,
+				VariantID: item.VariantID,
+				Quantity:  item.Quantity,
+			})
+		}
+
+		result, err = placeOrder(ctx, q, orderArg)
+		if err != nil {
+			return err
+		}
+
+		return q.ClearCart(ctx, cart.ID)
+	})
+
+	return result, err
+}
@@ -0,0 +1,254 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"time"
+
+	"github.com/adedaryorh/ecommerceapi/utils"
+)
+
+// CouponScope limits a coupon to some products and categories, including
+// their subcategories. A coupon with an empty scope applies to every product.
+type CouponScope struct {
+	ProductIDs  []int64 `json:"product_ids"`
+	CategoryIDs []int64 `json:"category_ids"`
+}
+
+type CreateCouponTxParams struct {
+	CreateCouponParams
+	Scope CouponScope `json:"scope"`
+}
+
+type UpdateCouponTxParams struct {
+	UpdateCouponParams
+	Scope CouponScope `json:"scope"`
+}
+
+type CouponTxResult struct {
+	Coupon Coupon      `json:"coupon"`
+	Scope  CouponScope `json:"scope"`
+}
+
+// CreateCouponTx creates a coupon together with its scope.
+func (store *Store) CreateCouponTx(ctx context.Context, arg CreateCouponTxParams) (CouponTxResult, error) {
+	var result CouponTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+		result.Coupon, err = q.CreateCoupon(ctx, arg.CreateCouponParams)
+		if err != nil {
+			return err
+		}
+		result.Scope, err = setCouponScope(ctx, q, result.Coupon.ID, arg.Scope)
+		return err
+	})
+
+	return result, err
+}
+
+// UpdateCouponTx replaces a coupon and its scope. Orders that already used
+// the coupon keep the discount they were given.
+func (store *Store) UpdateCouponTx(ctx context.Context, arg UpdateCouponTxParams) (CouponTxResult, error) {
+	var result CouponTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+		result.Coupon, err = q.UpdateCoupon(ctx, arg.UpdateCouponParams)
+		if err == sql.ErrNoRows {
+			return ErrCouponNotFound
+		} else if err != nil {
+			return err
+		}
+		result.Scope, err = setCouponScope(ctx, q, result.Coupon.ID, arg.Scope)
+		return err
+	})
+
+	return result, err
+}
+
+func setCouponScope(ctx context.Context, q *Queries, couponID int64, scope CouponScope) (CouponScope, error) {
+	if err := q.RemoveCouponProducts(ctx, couponID); err != nil {
+		return CouponScope{}, err
+	}
+	if err := q.RemoveCouponCategories(ctx, couponID); err != nil {
+		return CouponScope{}, err
+	}
+
+	for _, productID := range scope.ProductIDs {
+		_, err := q.GetProductByID(ctx, productID)
+		if err == sql.ErrNoRows {
+			return CouponScope{}, &ProductNotFoundError{ProductID: productID}
+		} else if err != nil {
+			return CouponScope{}, err
+		}
+		err = q.AddCouponProduct(ctx, AddCouponProductParams{CouponID: couponID, ProductID: productID})
+		if err != nil {
+			return CouponScope{}, err
+		}
+	}
+	for _, categoryID := range scope.CategoryIDs {
+		_, err := q.GetCategoryByID(ctx, categoryID)
+		if err == sql.ErrNoRows {
+			return CouponScope{}, ErrCategoryNotFound
+		} else if err != nil {
+			return CouponScope{}, err
+		}
+		err = q.AddCouponCategory(ctx, AddCouponCategoryParams{CouponID: couponID, CategoryID: categoryID})
+		if err != nil {
+			return CouponScope{}, err
+		}
+	}
+
+	return getCouponScope(ctx, q, couponID)
+}
+
+func getCouponScope(ctx context.Context, q *Queries, couponID int64) (CouponScope, error) {
+	var scope CouponScope
+	var err error
+	scope.ProductIDs, err = q.ListCouponProducts(ctx, couponID)
+	if err != nil {
+		return scope, err
+	}
+	scope.CategoryIDs, err = q.ListCouponCategories(ctx, couponID)
+	return scope, err
+}
+
+// GetCouponScope returns the products and categories a coupon is limited to.
+func (store *Store) GetCouponScope(ctx context.Context, couponID int64) (CouponScope, error) {
+	return getCouponScope(ctx, store.Queries, couponID)
+}
+
+// couponDiscount is what a coupon takes off an order. Products holds the
+// share of Amount taken off each product's lines.
+type couponDiscount struct {
+	Coupon   Coupon
+	Amount   int64
+	Products map[int64]int64
+}
+
+// applyCoupon checks a coupon's rules against an order being placed and
+// works out its discount. lineTotals holds the cents ordered per product.
+// The coupon row stays locked until the transaction ends, so concurrent
+// orders cannot both take its last use.
+func applyCoupon(ctx context.Context, q *Queries, userID int64, code string, lineTotals map[int64]int64, now time.Time) (couponDiscount, error) {
+	var discount couponDiscount
+
+	coupon, err := q.GetCouponByCodeForUpdate(ctx, NormalizeCouponCode(code))
+	if err == sql.ErrNoRows {
+		return discount, ErrCouponNotFound
+	} else if err != nil {
+		return discount, err
+	}
+	discount.Coupon = coupon
+	notApplicable := func(reason string) error {
+		return &CouponNotApplicableError{Code: coupon.Code, Reason: reason}
+	}
+
+	switch {
+	case !coupon.Active:
+		return discount, notApplicable("is not active")
+	case coupon.StartsAt.Valid && now.Before(coupon.StartsAt.Time):
+		return discount, notApplicable("is not valid yet")
+	case coupon.EndsAt.Valid && !now.Before(coupon.EndsAt.Time):
+		return discount, notApplicable("has expired")
+	}
+
+	if coupon.UsageLimit.Valid {
+		used, err := q.CountCouponRedemptions(ctx, coupon.ID)
+		if err != nil {
+			return discount, err
+		}
+		if used >= int64(coupon.UsageLimit.Int32) {
+			return discount, notApplicable("has reached its usage limit")
+		}
+	}
+	if coupon.PerUserLimit.Valid {
+		used, err := q.CountUserCouponRedemptions(ctx, CountUserCouponRedemptionsParams{
+			CouponID: coupon.ID,
+			UserID:   userID,
+		})
+		if err != nil {
+			return discount, err
+		}
+		if used >= int64(coupon.PerUserLimit.Int32) {
+			return discount, notApplicable("has already been used the maximum number of times")
+		}
+	}
+
+	// The minimum spend counts the whole order, not only the lines the
+	// coupon applies to.
+	var subtotal int64
+	productIDs := make([]int64, 0, len(lineTotals))
+	for productID, total := range lineTotals {
+		subtotal += total
+		productIDs = append(productIDs, productID)
+	}
+	minSpend, err := utils.ParseCents(coupon.MinSpend)
+	if err != nil {
+		return discount, err
+	}
+	if subtotal < minSpend {
+		return discount, notApplicable("requires a minimum spend of " + coupon.MinSpend)
+	}
+
+	eligibleIDs := productIDs
+	scope, err := getCouponScope(ctx, q, coupon.ID)
+	if err != nil {
+		return discount, err
+	}
+	if len(scope.ProductIDs) > 0 || len(scope.CategoryIDs) > 0 {
+		eligibleIDs, err = q.ListCouponEligibleProducts(ctx, ListCouponEligibleProductsParams{
+			CouponID:   coupon.ID,
+			ProductIds: productIDs,
+		})
+		if err != nil {
+			return discount, err
+		}
+		if len(eligibleIDs) == 0 {
+			return discount, notApplicable("does not apply to any item in the order")
+		}
+	}
+	var eligible int64
+	for _, id := range eligibleIDs {
+		eligible += lineTotals[id]
+	}
+
+	value, err := utils.ParseCents(coupon.Value)
+	if err != nil {
+		return discount, err
+	}
+	switch CouponKind(coupon.Kind) {
+	case CouponPercentage:
+		// value holds hundredths of a percent; round to the nearest cent.
+		discount.Amount = (eligible*value + 5000) / 10000
+	case CouponFixed:
+		discount.Amount = min(value, eligible)
+	}
+	discount.Products = allocateDiscount(discount.Amount, eligibleIDs, lineTotals, eligible)
+	return discount, nil
+}
+
+// allocateDiscount spreads amount over productIDs in proportion to their
+// line totals, so tax can be charged on what is actually paid for each. The
+// last product by ID takes the rounding remainder.
+func allocateDiscount(amount int64, productIDs []int64, lineTotals map[int64]int64, eligible int64) map[int64]int64 {
+	shares := make(map[int64]int64, len(productIDs))
+	if amount == 0 || eligible == 0 {
+		return shares
+	}
+	ids := append([]int64(nil), productIDs...)
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	remaining := amount
+	for i, id := range ids {
+		share := amount * lineTotals[id] / eligible
+		if i == len(ids)-1 {
+			share = remaining
+		}
+		shares[id] = share
+		remaining -= share
+	}
+	return shares
+}
@@ -0,0 +1,109 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+)
+
+// MovementKind is the cause of a stock change stored in
+// inventory_movements.kind.
+type MovementKind string
+
+const (
+	MovementReceipt      MovementKind = "receipt"
+	MovementSale         MovementKind = "sale"
+	MovementReturn       MovementKind = "return"
+	MovementAdjustment   MovementKind = "adjustment"
+	MovementCancellation MovementKind = "cancellation"
+)
+
+// Valid reports whether k is a known movement kind.
+func (k MovementKind) Valid() bool {
+	switch k {
+	case MovementReceipt, MovementSale, MovementReturn, MovementAdjustment, MovementCancellation:
+		return true
+	}
+	return false
+}
+
+// recordMovement adds a ledger entry for a stock change. Nothing is
+// recorded when the quantity did not change.
+func recordMovement(ctx context.Context, q *Queries, arg CreateInventoryMovementParams) error {
+	if arg.Quantity == 0 {
+		return nil
+	}
+	_, err := q.CreateInventoryMovement(ctx, arg)
+	return err
+}
+
+type AdjustStockTxParams struct {
+	ProductID int64 `json:"product_id"`
+	// VariantID adjusts the variant's stock instead of the product's.
+	VariantID sql.NullInt64  `json:"variant_id"`
+	Kind      MovementKind   `json:"kind"`
+	Quantity  int32          `json:"quantity"`
+	Reason    sql.NullString `json:"reason"`
+	CreatedBy sql.NullInt64  `json:"created_by"`
+}
+
+// AdjustStockTx changes product or variant stock by a signed quantity and
+// records the movement in the same transaction. Stock may not go negative.
+func (store *Store) AdjustStockTx(ctx context.Context, arg AdjustStockTxParams) (InventoryMovement, error) {
+	var movement InventoryMovement
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		product, err := q.GetProductForUpdate(ctx, arg.ProductID)
+		if err == sql.ErrNoRows {
+			return &ProductNotFoundError{ProductID: arg.ProductID}
+		} else if err != nil {
+			return err
+		}
+
+		balance := product.Stock
+		if arg.VariantID.Valid {
+			variant, err := q.GetProductVariantForUpdate(ctx, arg.VariantID.Int64)
+			if err == sql.ErrNoRows || (err == nil && variant.ProductID != arg.ProductID) {
+				return &VariantNotFoundError{ProductID: arg.ProductID, VariantID: arg.VariantID.Int64}
+			} else if err != nil {
+				return err
+			}
+			balance = variant.Stock
+		}
+		if balance+arg.Quantity < 0 {
+			return &InsufficientStockError{ProductID: arg.ProductID, VariantID: arg.VariantID.Int64, Requested: -arg.Quantity, Available: balance}
+		}
+
+		if arg.VariantID.Valid {
+			variant, err := q.AddProductVariantStock(ctx, AddProductVariantStockParams{
+				Amount: arg.Quantity,
+				ID:     arg.VariantID.Int64,
+			})
+			if err != nil {
+				return err
+			}
+			balance = variant.Stock
+		} else {
+			product, err = q.AddProductStock(ctx, AddProductStockParams{
+				Amount: arg.Quantity,
+				ID:     arg.ProductID,
+			})
+			if err != nil {
+				return err
+			}
+			balance = product.Stock
+		}
+
+		movement, err = q.CreateInventoryMovement(ctx, CreateInventoryMovementParams{
+			ProductID: arg.ProductID,
+			VariantID: arg.VariantID,
+			Kind:      string(arg.Kind),
+			Quantity:  arg.Quantity,
+			Balance:   balance,
+			Reason:    arg.Reason,
+			CreatedBy: arg.CreatedBy,
+		})
+		return err
+	})
+
+	return movement, err
+}
@@ -0,0 +1,690 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/adedaryorh/ecommerceapi/payment"
+	"github.com/adedaryorh/ecommerceapi/tax"
+	"github.com/adedaryorh/ecommerceapi/utils"
+)
+
+type PlaceOrderItem struct {
+	ProductID int64 `json:"product_id"`
+	// VariantID selects a specific variant; its stock and price override are
+	// used instead of the product's.
+	VariantID sql.NullInt64 `json:"variant_id"`
+	Quantity  int32         `json:"quantity"`
+}
+
+// orderLine identifies a product, or one of its variants, within an order.
+// VariantID is zero for product-level lines.
+type orderLine struct {
+	ProductID int64
+	VariantID int64
+}
+
+// DefaultReservationTTL is how long a pending order holds its stock when
+// no TTL is given.
+const DefaultReservationTTL = 30 * time.Minute
+
+type PlaceOrderTxParams struct {
+	UserID int64            `json:"user_id"`
+	Items  []PlaceOrderItem `json:"items"`
+	// ReservationTTL is how long the order's stock stays reserved while it
+	// awaits payment.
+	ReservationTTL time.Duration `json:"reservation_ttl"`
+	// CouponCode, if set, must name a coupon whose rules the order meets.
+	CouponCode string `json:"coupon_code"`
+	// Tax works out the tax charged at TaxAddress. A nil Tax charges none.
+	Tax        tax.Calculator `json:"-"`
+	TaxAddress tax.Address    `json:"tax_address"`
+}
+
+type PlaceOrderTxResult struct {
+	Order      Order          `json:"order"`
+	OrderItems []OrderItem    `json:"order_items"`
+	TaxLines   []OrderTaxLine `json:"tax_lines"`
+}
+
+// PlaceOrderTx creates an order and its items in a single transaction.
+// Item prices come from the catalog and the ordered quantities are reserved
+// while each product's row is locked, so concurrent orders cannot oversell.
+// Stock itself only goes down once the order is paid. A coupon's discount
+// is taken off the total and its use is recorded against the order; tax is
+// then charged on the discounted lines and added to the total.
+func (store *Store) PlaceOrderTx(ctx context.Context, arg PlaceOrderTxParams) (PlaceOrderTxResult, error) {
+	var result PlaceOrderTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+		result, err = placeOrder(ctx, q, arg)
+		return err
+	})
+
+	return result, err
+}
+
+// errQuoteDryRun rolls back the order placed to work out a quote.
+var errQuoteDryRun = errors.New("quote dry run")
+
+// QuoteOrderTx prices an order exactly as PlaceOrderTx would, with the same
+// stock, coupon and tax rules, and then rolls it back. The returned order
+// and items were never stored, so their IDs mean nothing.
+func (store *Store) QuoteOrderTx(ctx context.Context, arg PlaceOrderTxParams) (PlaceOrderTxResult, error) {
+	var result PlaceOrderTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+		result, err = placeOrder(ctx, q, arg)
+		if err != nil {
+			return err
+		}
+		return errQuoteDryRun
+	})
+	if errors.Is(err, errQuoteDryRun) {
+		err = nil
+	}
+
+	return result, err
+}
+
+func placeOrder(ctx context.Context, q *Queries, arg PlaceOrderTxParams) (PlaceOrderTxResult, error) {
+	var result PlaceOrderTxResult
+
+	// Merge duplicate lines so each product or variant is locked and priced once.
+	quantities := make(map[orderLine]int32)
+	lines := []orderLine{}
+	for _, item := range arg.Items {
+		line := orderLine{ProductID: item.ProductID, VariantID: item.VariantID.Int64}
+		if _, ok := quantities[line]; !ok {
+			lines = append(lines, line)
+		}
+		quantities[line] += item.Quantity
+	}
+	if len(lines) == 0 {
+		return result, ErrEmptyOrder
+	}
+
+	// Lock rows in a stable order to avoid deadlocks between concurrent orders:
+	// products by ascending ID first, then variants by ascending ID.
+	productQuantities := make(map[int64]int32)
+	productIDs := []int64{}
+	variantLines := []orderLine{}
+	for _, line := range lines {
+		if _, ok := productQuantities[line.ProductID]; !ok {
+			productQuantities[line.ProductID] = 0
+			productIDs = append(productIDs, line.ProductID)
+		}
+		if line.VariantID == 0 {
+			productQuantities[line.ProductID] += quantities[line]
+		} else {
+			variantLines = append(variantLines, line)
+		}
+	}
+	sort.Slice(productIDs, func(i, j int) bool { return productIDs[i] < productIDs[j] })
+	sort.Slice(variantLines, func(i, j int) bool { return variantLines[i].VariantID < variantLines[j].VariantID })
+
+	products := make(map[int64]Product, len(productIDs))
+	for _, id := range productIDs {
+		product, err := q.GetProductForUpdate(ctx, id)
+		if err == sql.ErrNoRows {
+			return result, &ProductNotFoundError{ProductID: id}
+		} else if err != nil {
+			return result, err
+		}
+
+		if quantity := productQuantities[id]; quantity > 0 {
+			reserved, err := q.GetReservedProductStock(ctx, id)
+			if err != nil {
+				return result, err
+			}
+			if available := product.Stock - reserved; available < quantity {
+				return result, &InsufficientStockError{ProductID: id, Requested: quantity, Available: max(available, 0)}
+			}
+		}
+		products[id] = product
+	}
+
+	prices := make(map[orderLine]string, len(lines))
+	for _, line := range lines {
+		if line.VariantID == 0 {
+			prices[line] = products[line.ProductID].Price
+		}
+	}
+	for _, line := range variantLines {
+		variant, err := q.GetProductVariantForUpdate(ctx, line.VariantID)
+		if err == sql.ErrNoRows || (err == nil && variant.ProductID != line.ProductID) {
+			return result, &VariantNotFoundError{ProductID: line.ProductID, VariantID: line.VariantID}
+		} else if err != nil {
+			return result, err
+		}
+
+		reserved, err := q.GetReservedVariantStock(ctx, sql.NullInt64{Int64: line.VariantID, Valid: true})
+		if err != nil {
+			return result, err
+		}
+		quantity := quantities[line]
+		if available := variant.Stock - reserved; available < quantity {
+			return result, &InsufficientStockError{ProductID: line.ProductID, VariantID: line.VariantID, Requested: quantity, Available: max(available, 0)}
+		}
+
+		prices[line] = products[line.ProductID].Price
+		if variant.Price.Valid {
+			prices[line] = variant.Price.String
+		}
+	}
+
+	var total int64
+	lineTotals := make(map[int64]int64, len(productIDs))
+	for _, line := range lines {
+		price, err := utils.ParseCents(prices[line])
+		if err != nil {
+			return result, err
+		}
+		total += price * int64(quantities[line])
+		lineTotals[line.ProductID] += price * int64(quantities[line])
+	}
+
+	orderArg := CreateOrderParams{
+		UserID:         arg.UserID,
+		Status:         string(OrderStatusPending),
+		DiscountAmount: utils.FormatCents(0),
+	}
+	var discount couponDiscount
+	if arg.CouponCode != "" {
+		var err error
+		discount, err = applyCoupon(ctx, q, arg.UserID, arg.CouponCode, lineTotals, time.Now())
+		if err != nil {
+			return result, err
+		}
+		total -= discount.Amount
+		orderArg.DiscountAmount = utils.FormatCents(discount.Amount)
+		orderArg.CouponCode = sql.NullString{String: discount.Coupon.Code, Valid: true}
+	}
+
+	var taxes tax.Result
+	if arg.Tax != nil {
+		taxLines := make([]tax.Line, 0, len(productIDs))
+		for _, id := range productIDs {
+			taxLines = append(taxLines, tax.Line{
+				ProductID: id,
+				TaxClass:  products[id].TaxClass,
+				Amount:    lineTotals[id] - discount.Products[id],
+			})
+		}
+		var err error
+		taxes, err = arg.Tax.Calculate(ctx, arg.TaxAddress, taxLines)
+		if err != nil {
+			return result, err
+		}
+		total += taxes.Total
+	}
+	orderArg.TaxAmount = utils.FormatCents(taxes.Total)
+	if address := arg.TaxAddress.Normalize(); address.Country != "" {
+		orderArg.TaxCountry = sql.NullString{String: address.Country, Valid: true}
+		orderArg.TaxState = sql.NullString{String: address.State, Valid: address.State != ""}
+	}
+	orderArg.TotalAmount = utils.FormatCents(total)
+
+	order, err := q.CreateOrder(ctx, orderArg)
+	if err != nil {
+		return result, err
+	}
+	result.Order = order
+
+	if orderArg.CouponCode.Valid {
+		_, err = q.CreateCouponRedemption(ctx, CreateCouponRedemptionParams{
+			CouponID:       discount.Coupon.ID,
+			UserID:         arg.UserID,
+			OrderID:        order.ID,
+			DiscountAmount: orderArg.DiscountAmount,
+		})
+		if err != nil {
+			return result, err
+		}
+	}
+
+	result.TaxLines = []OrderTaxLine{}
+	for _, line := range taxes.Lines {
+		taxLine, err := q.CreateOrderTaxLine(ctx, CreateOrderTaxLineParams{
+			OrderID:       order.ID,
+			ProductID:     line.ProductID,
+			TaxClass:      line.TaxClass,
+			Jurisdiction:  line.Jurisdiction,
+			Rate:          line.Rate.String(),
+			TaxableAmount: utils.FormatCents(line.Taxable),
+			Amount:        utils.FormatCents(line.Amount),
+		})
+		if err != nil {
+			return result, err
+		}
+		result.TaxLines = append(result.TaxLines, taxLine)
+	}
+
+	ttl := arg.ReservationTTL
+	if ttl <= 0 {
+		ttl = DefaultReservationTTL
+	}
+	expiresAt := time.Now().Add(ttl)
+
+	result.OrderItems = []OrderItem{}
+	for _, line := range lines {
+		item, err := q.AddOrderItem(ctx, AddOrderItemParams{
+			OrderID:     order.ID,
+			ProductID:   line.ProductID,
+			Quantity:    quantities[line],
+			Price:       prices[line],
+			ProductName: products[line.ProductID].Name,
+			VariantID:   sql.NullInt64{Int64: line.VariantID, Valid: line.VariantID != 0},
+		})
+		if err != nil {
+			return result, err
+		}
+		result.OrderItems = append(result.OrderItems, item)
+
+		_, err = q.CreateStockReservation(ctx, CreateStockReservationParams{
+			OrderID:   order.ID,
+			ProductID: line.ProductID,
+			VariantID: item.VariantID,
+			Quantity:  quantities[line],
+			ExpiresAt: expiresAt,
+		})
+		if err != nil {
+			return result, err
+		}
+	}
+
+	return result, nil
+}
+
+type TransitionOrderTxParams struct {
+	OrderID   int64          `json:"order_id"`
+	Status    OrderStatus    `json:"status"`
+	ChangedBy sql.NullInt64  `json:"changed_by"`
+	Note      sql.NullString `json:"note"`
+	// Refund pays back a captured payment when the order is cancelled or
+	// refunded. Without it those transitions fail while money is held.
+	Refund RefundFunc `json:"-"`
+}
+
+type TransitionOrderTxResult struct {
+	Order   Order              `json:"order"`
+	History OrderStatusHistory `json:"history"`
+}
+
+// TransitionOrderTx moves an order to a new status if the lifecycle allows it
+// and records the change in order_status_history.
+func (store *Store) TransitionOrderTx(ctx context.Context, arg TransitionOrderTxParams) (TransitionOrderTxResult, error) {
+	var result TransitionOrderTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		order, err := q.GetOrderForUpdate(ctx, arg.OrderID)
+		if err == sql.ErrNoRows {
+			return ErrOrderNotFound
+		} else if err != nil {
+			return err
+		}
+
+		result, err = transitionOrder(ctx, q, order, arg)
+		return err
+	})
+
+	return result, err
+}
+
+// transitionOrder expects order to already be locked by the caller. Stock
+// follows the new status: paying commits the order's reservations, while
+// cancelling or expiring it gives its stock and coupon use back, as does
+// refunding an order that was never shipped. Cancelling or refunding first
+// pays back whatever the order's payment still holds.
+func transitionOrder(ctx context.Context, q *Queries, order Order, arg TransitionOrderTxParams) (TransitionOrderTxResult, error) {
+	var result TransitionOrderTxResult
+
+	from := OrderStatus(order.Status)
+	if !from.CanTransitionTo(arg.Status) {
+		return result, &InvalidTransitionError{From: from, To: arg.Status}
+	}
+	if arg.Status == OrderStatusCancelled || arg.Status == OrderStatusRefunded {
+		if err := refundOrder(ctx, q, order, arg.Refund); err != nil {
+			return result, err
+		}
+	}
+
+	updated, err := q.UpdateOrderStatus(ctx, UpdateOrderStatusParams{
+		Status: string(arg.Status),
+		ID:     order.ID,
+	})
+	if err != nil {
+		return result, err
+	}
+	result.Order = updated
+
+	switch arg.Status {
+	case OrderStatusPaid:
+		err = commitReservations(ctx, q, order.ID, arg.ChangedBy)
+	case OrderStatusCancelled, OrderStatusExpired:
+		err = releaseOrderStock(ctx, q, order.ID, CreateInventoryMovementParams{
+			Kind:      string(MovementCancellation),
+			Reason:    arg.Note,
+			OrderID:   sql.NullInt64{Int64: order.ID, Valid: true},
+			CreatedBy: arg.ChangedBy,
+		})
+		if err == nil {
+			// An order that was never fulfilled gives its coupon use back.
+			_, err = q.DeleteOrderCouponRedemption(ctx, order.ID)
+		}
+	case OrderStatusRefunded:
+		// Delivered goods come back through returns; paid ones never left.
+		if from == OrderStatusPaid {
+			err = releaseOrderStock(ctx, q, order.ID, CreateInventoryMovementParams{
+				Kind:      string(MovementCancellation),
+				Reason:    arg.Note,
+				OrderID:   sql.NullInt64{Int64: order.ID, Valid: true},
+				CreatedBy: arg.ChangedBy,
+			})
+		}
+	}
+	if err != nil {
+		return result, err
+	}
+
+	result.History, err = q.CreateOrderStatusHistory(ctx, CreateOrderStatusHistoryParams{
+		OrderID:    order.ID,
+		FromStatus: string(from),
+		ToStatus:   string(arg.Status),
+		ChangedBy:  arg.ChangedBy,
+		Note:       arg.Note,
+	})
+	return result, err
+}
+
+type CancelOrderTxParams struct {
+	OrderID int64 `json:"order_id"`
+	// OwnerID restricts the cancellation to the order's owner, who may only
+	// cancel orders that are still pending. Admin cancellations leave it unset.
+	OwnerID   sql.NullInt64  `json:"owner_id"`
+	ChangedBy sql.NullInt64  `json:"changed_by"`
+	Note      sql.NullString `json:"note"`
+	// Refund pays back the payment of a paid order being cancelled.
+	Refund RefundFunc `json:"-"`
+}
+
+type CancelOrderTxResult struct {
+	Order      Order              `json:"order"`
+	History    OrderStatusHistory `json:"history"`
+	OrderItems []OrderItem        `json:"order_items"`
+}
+
+// CancelOrderTx cancels an order and releases its reserved or taken stock
+// in the same transaction.
+func (store *Store) CancelOrderTx(ctx context.Context, arg CancelOrderTxParams) (CancelOrderTxResult, error) {
+	var result CancelOrderTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		order, err := q.GetOrderForUpdate(ctx, arg.OrderID)
+		if err == sql.ErrNoRows {
+			return ErrOrderNotFound
+		} else if err != nil {
+			return err
+		}
+
+		if arg.OwnerID.Valid {
+			if order.UserID != arg.OwnerID.Int64 {
+				return ErrOrderNotFound
+			}
+			if OrderStatus(order.Status) != OrderStatusPending {
+				return &InvalidTransitionError{From: OrderStatus(order.Status), To: OrderStatusCancelled}
+			}
+		}
+
+		transition, err := transitionOrder(ctx, q, order, TransitionOrderTxParams{
+			OrderID:   order.ID,
+			Status:    OrderStatusCancelled,
+			ChangedBy: arg.ChangedBy,
+			Note:      arg.Note,
+			Refund:    arg.Refund,
+		})
+		if err != nil {
+			return err
+		}
+		result.Order = transition.Order
+		result.History = transition.History
+
+		result.OrderItems, err = q.ListOrderItems(ctx, order.ID)
+		return err
+	})
+
+	return result, err
+}
+
+// refundOrder pays back the part of an order's captured payment that has not
+// been refunded yet and marks the payment refunded. The order must already
+// be locked by the caller.
+func refundOrder(ctx context.Context, q *Queries, order Order, refund RefundFunc) error {
+	paid, err := q.GetCapturedOrderPayment(ctx, order.ID)
+	if err == sql.ErrNoRows {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if paid.Status != payment.StatusCaptured {
+		return nil
+	}
+	return refundPayment(ctx, q, paid, refund)
+}
+
+// refundPayment pays back the part of paid that has not been refunded yet
+// and marks it refunded.
+func refundPayment(ctx context.Context, q *Queries, paid Payment, refund RefundFunc) error {
+	paidAmount, err := utils.ParseCents(paid.Amount)
+	if err != nil {
+		return err
+	}
+	refundedAmount, err := q.GetRefundedAmount(ctx, paid.ID)
+	if err != nil {
+		return err
+	}
+	refunded, err := utils.ParseCents(refundedAmount)
+	if err != nil {
+		return err
+	}
+	if remaining := paidAmount - refunded; remaining > 0 {
+		if refund == nil {
+			return ErrOrderPaymentCaptured
+		}
+		providerRefundID, err := refund(ctx, paid, remaining)
+		if err != nil {
+			return err
+		}
+		_, err = q.RecordRefund(ctx, RecordRefundParams{
+			PaymentID:        paid.ID,
+			Amount:           utils.FormatCents(remaining),
+			ProviderRefundID: providerRefundID,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = q.UpdatePaymentStatus(ctx, UpdatePaymentStatusParams{
+		Status: payment.StatusRefunded,
+		ID:     paid.ID,
+	})
+	return err
+}
+
+// restoreStock returns the quantities of the given order items to product or
+// variant stock, locking rows in the same order as placeOrder does. Each
+// restock is recorded using the kind, reason, order and user of movement.
+func restoreStock(ctx context.Context, q *Queries, items []OrderItem, movement CreateInventoryMovementParams) error {
+	productOf := make(map[int64]int64)
+	productQuantities := make(map[int64]int32)
+	variantQuantities := make(map[int64]int32)
+	productIDs := []int64{}
+	variantIDs := []int64{}
+	for _, item := range items {
+		if item.VariantID.Valid {
+			if _, ok := variantQuantities[item.VariantID.Int64]; !ok {
+				variantIDs = append(variantIDs, item.VariantID.Int64)
+			}
+			variantQuantities[item.VariantID.Int64] += item.Quantity
+			productOf[item.VariantID.Int64] = item.ProductID
+			continue
+		}
+		if _, ok := productQuantities[item.ProductID]; !ok {
+			productIDs = append(productIDs, item.ProductID)
+		}
+		productQuantities[item.ProductID] += item.Quantity
+	}
+	sort.Slice(productIDs, func(i, j int) bool { return productIDs[i] < productIDs[j] })
+	sort.Slice(variantIDs, func(i, j int) bool { return variantIDs[i] < variantIDs[j] })
+
+	for _, id := range productIDs {
+		product, err := q.AddProductStock(ctx, AddProductStockParams{
+			Amount: productQuantities[id],
+			ID:     id,
+		})
+		if err != nil {
+			return err
+		}
+		movement.ProductID = id
+		movement.VariantID = sql.NullInt64{}
+		movement.Quantity = productQuantities[id]
+		movement.Balance = product.Stock
+		if err := recordMovement(ctx, q, movement); err != nil {
+			return err
+		}
+	}
+	for _, id := range variantIDs {
+		variant, err := q.AddProductVariantStock(ctx, AddProductVariantStockParams{
+			Amount: variantQuantities[id],
+			ID:     id,
+		})
+		if err != nil {
+			return err
+		}
+		movement.ProductID = productOf[id]
+		movement.VariantID = sql.NullInt64{Int64: id, Valid: true}
+		movement.Quantity = variantQuantities[id]
+		movement.Balance = variant.Stock
+		if err := recordMovement(ctx, q, movement); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// commitReservations turns an order's active reservations into sales: the
+// reserved quantities leave stock and are recorded in the ledger. Rows are
+// locked in the same order as placeOrder does.
+func commitReservations(ctx context.Context, q *Queries, orderID int64, createdBy sql.NullInt64) error {
+	reservations, err := q.ListActiveOrderReservations(ctx, orderID)
+	if err != nil {
+		return err
+	}
+	sort.SliceStable(reservations, func(i, j int) bool {
+		a, b := reservations[i], reservations[j]
+		if a.VariantID.Valid != b.VariantID.Valid {
+			return !a.VariantID.Valid
+		}
+		if a.VariantID.Valid {
+			return a.VariantID.Int64 < b.VariantID.Int64
+		}
+		return a.ProductID < b.ProductID
+	})
+
+	for _, reservation := range reservations {
+		var balance int32
+		if reservation.VariantID.Valid {
+			variant, err := q.AddProductVariantStock(ctx, AddProductVariantStockParams{
+				Amount: -reservation.Quantity,
+				ID:     reservation.VariantID.Int64,
+			})
+			if err != nil {
+				return err
+			}
+			balance = variant.Stock
+		} else {
+			product, err := q.AddProductStock(ctx, AddProductStockParams{
+				Amount: -reservation.Quantity,
+				ID:     reservation.ProductID,
+			})
+			if err != nil {
+				return err
+			}
+			balance = product.Stock
+		}
+
+		err = recordMovement(ctx, q, CreateInventoryMovementParams{
+			ProductID: reservation.ProductID,
+			VariantID: reservation.VariantID,
+			Kind:      string(MovementSale),
+			Quantity:  -reservation.Quantity,
+			Balance:   balance,
+			OrderID:   sql.NullInt64{Int64: orderID, Valid: true},
+			CreatedBy: createdBy,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = q.SetOrderReservationsStatus(ctx, SetOrderReservationsStatusParams{
+		ToStatus: ReservationCommitted,
+		OrderID:  orderID,
+	})
+	return err
+}
+
+// releaseOrderStock gives back the stock held by an order. An unpaid order
+// only has to drop its reservations; a paid one, or one placed before
+// reservations existed, already took its items out of stock and has them
+// restored.
+func releaseOrderStock(ctx context.Context, q *Queries, orderID int64, movement CreateInventoryMovementParams) error {
+	released, err := q.SetOrderReservationsStatus(ctx, SetOrderReservationsStatusParams{
+		ToStatus: ReservationReleased,
+		OrderID:  orderID,
+	})
+	if err != nil || released > 0 {
+		return err
+	}
+
+	items, err := q.ListOrderItems(ctx, orderID)
+	if err != nil {
+		return err
+	}
+	return restoreStock(ctx, q, items, movement)
+}
+
+// ExpireOrderTx moves a pending order whose reservation ran out to Expired
+// and releases its stock. Orders that left Pending in the meantime, for
+// example by being paid, are left alone and reported as not expired.
+func (store *Store) ExpireOrderTx(ctx context.Context, orderID int64) (bool, error) {
+	expired := false
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		order, err := q.GetOrderForUpdate(ctx, orderID)
+		if err == sql.ErrNoRows {
+			return ErrOrderNotFound
+		} else if err != nil {
+			return err
+		}
+		if OrderStatus(order.Status) != OrderStatusPending {
+			return nil
+		}
+
+		_, err = transitionOrder(ctx, q, order, TransitionOrderTxParams{
+			OrderID: order.ID,
+			Status:  OrderStatusExpired,
+			Note:    sql.NullString{String: "Stock reservation expired", Valid: true},
+		})
+		expired = err == nil
+		return err
+	})
+
+	return expired, err
+}
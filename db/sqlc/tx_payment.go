@@ -0,0 +1,165 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/adedaryorh/ecommerceapi/payment"
+	"github.com/adedaryorh/ecommerceapi/utils"
+)
+
+type PayOrderTxParams struct {
+	OrderID int64 `json:"order_id"`
+	// OwnerID restricts the payment to the order's owner.
+	OwnerID               sql.NullInt64 `json:"owner_id"`
+	Provider              string        `json:"provider"`
+	ProviderTransactionID string        `json:"provider_transaction_id"`
+	Amount                string        `json:"amount"`
+	ChangedBy             sql.NullInt64 `json:"changed_by"`
+}
+
+type PayOrderTxResult struct {
+	Order Order `json:"order"`
+	// Payment is empty for an order with nothing to pay.
+	Payment Payment `json:"payment"`
+	// Replayed is set when the transaction had already been recorded
+	// against the order, in which case nothing was changed.
+	Replayed bool `json:"replayed"`
+}
+
+// PayOrderTx records a captured payment and moves the order to Paid, which
+// commits its stock reservations. Recording a provider transaction that is
+// already stored returns the earlier result instead of failing, so a client
+// retrying a payment does not see an error for money it was charged. An order
+// whose discount leaves nothing to pay is paid with an amount of zero, which
+// moves it to Paid without recording a payment.
+func (store *Store) PayOrderTx(ctx context.Context, arg PayOrderTxParams) (PayOrderTxResult, error) {
+	var result PayOrderTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+		result, err = payOrder(ctx, q, arg)
+		return err
+	})
+
+	return result, err
+}
+
+func payOrder(ctx context.Context, q *Queries, arg PayOrderTxParams) (PayOrderTxResult, error) {
+	var result PayOrderTxResult
+
+	order, err := q.GetOrderForUpdate(ctx, arg.OrderID)
+	if err == sql.ErrNoRows {
+		return result, ErrOrderNotFound
+	} else if err != nil {
+		return result, err
+	}
+	if arg.OwnerID.Valid && order.UserID != arg.OwnerID.Int64 {
+		return result, ErrOrderNotFound
+	}
+
+	amount, err := utils.ParseCents(arg.Amount)
+	if err != nil {
+		return result, err
+	}
+	total, err := utils.ParseCents(order.TotalAmount)
+	if err != nil {
+		return result, err
+	}
+	if amount == 0 && total == 0 {
+		return payFreeOrder(ctx, q, order, arg)
+	}
+
+	existing, err := q.GetPaymentByProviderTransaction(ctx, GetPaymentByProviderTransactionParams{
+		Provider:              arg.Provider,
+		ProviderTransactionID: arg.ProviderTransactionID,
+	})
+	if err == nil {
+		if existing.OrderID != order.ID {
+			return result, ErrPaymentOrderMismatch
+		}
+		if existing.Status == payment.StatusFailed {
+			return result, ErrPaymentRecorded
+		}
+		return PayOrderTxResult{Order: order, Payment: existing, Replayed: true}, nil
+	} else if err != sql.ErrNoRows {
+		return result, err
+	}
+
+	if amount != total {
+		return result, ErrPaymentAmountMismatch
+	}
+
+	transition, err := transitionOrder(ctx, q, order, TransitionOrderTxParams{
+		OrderID:   order.ID,
+		Status:    OrderStatusPaid,
+		ChangedBy: arg.ChangedBy,
+		Note:      sql.NullString{String: "Paid via " + arg.Provider, Valid: true},
+	})
+	if err != nil {
+		return result, err
+	}
+	result.Order = transition.Order
+
+	result.Payment, err = q.RecordPayment(ctx, RecordPaymentParams{
+		OrderID:               order.ID,
+		Provider:              arg.Provider,
+		ProviderTransactionID: arg.ProviderTransactionID,
+		Amount:                utils.FormatCents(amount),
+		Status:                payment.StatusCaptured,
+	})
+	return result, err
+}
+
+// payFreeOrder moves an order with nothing to pay to Paid. No money changes
+// hands, so no payment is recorded; paying it again is a replay.
+func payFreeOrder(ctx context.Context, q *Queries, order Order, arg PayOrderTxParams) (PayOrderTxResult, error) {
+	if OrderStatus(order.Status) == OrderStatusPaid {
+		return PayOrderTxResult{Order: order, Replayed: true}, nil
+	}
+	transition, err := transitionOrder(ctx, q, order, TransitionOrderTxParams{
+		OrderID:   order.ID,
+		Status:    OrderStatusPaid,
+		ChangedBy: arg.ChangedBy,
+		Note:      sql.NullString{String: "Nothing to pay", Valid: true},
+	})
+	return PayOrderTxResult{Order: transition.Order}, err
+}
+
+// settleRefunds marks paid as refunded once its refunds add up to the amount
+// taken, and moves the order to Refunded when its status allows. The order
+// must already be locked by the caller.
+func settleRefunds(ctx context.Context, q *Queries, order Order, paid Payment, changedBy sql.NullInt64, note sql.NullString) error {
+	paidAmount, err := utils.ParseCents(paid.Amount)
+	if err != nil {
+		return err
+	}
+	refundedAmount, err := q.GetRefundedAmount(ctx, paid.ID)
+	if err != nil {
+		return err
+	}
+	refunded, err := utils.ParseCents(refundedAmount)
+	if err != nil {
+		return err
+	}
+	if refunded < paidAmount || paid.Status == payment.StatusRefunded {
+		return nil
+	}
+
+	_, err = q.UpdatePaymentStatus(ctx, UpdatePaymentStatusParams{
+		Status: payment.StatusRefunded,
+		ID:     paid.ID,
+	})
+	if err != nil {
+		return err
+	}
+	if OrderStatus(order.Status).CanTransitionTo(OrderStatusRefunded) {
+		_, err = transitionOrder(ctx, q, order, TransitionOrderTxParams{
+			OrderID:   order.ID,
+			Status:    OrderStatusRefunded,
+			ChangedBy: changedBy,
+			Note:      note,
+		})
+	}
+	return err
+}
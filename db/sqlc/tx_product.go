@@ -0,0 +1,401 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+type SetProductCategoriesTxParams struct {
+	ProductID   int64   `json:"product_id"`
+	CategoryIDs []int64 `json:"category_ids"`
+}
+
+// SetProductCategoriesTx replaces the set of categories a product belongs to.
+func (store *Store) SetProductCategoriesTx(ctx context.Context, arg SetProductCategoriesTxParams) ([]Category, error) {
+	var categories []Category
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		_, err := q.GetProductForUpdate(ctx, arg.ProductID)
+		if err == sql.ErrNoRows {
+			return &ProductNotFoundError{ProductID: arg.ProductID}
+		} else if err != nil {
+			return err
+		}
+
+		if err := q.RemoveProductCategories(ctx, arg.ProductID); err != nil {
+			return err
+		}
+
+		for _, categoryID := range arg.CategoryIDs {
+			_, err := q.GetCategoryByID(ctx, categoryID)
+			if err == sql.ErrNoRows {
+				return ErrCategoryNotFound
+			} else if err != nil {
+				return err
+			}
+
+			err = q.AddProductCategory(ctx, AddProductCategoryParams{
+				ProductID:  arg.ProductID,
+				CategoryID: categoryID,
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		categories, err = q.ListProductCategories(ctx, arg.ProductID)
+		return err
+	})
+
+	return categories, err
+}
+
+// CreateProductImageTx adds an image after the product's existing ones.
+// The product row is locked while the next position is worked out, so two
+// uploads for the same product at once cannot share a position.
+func (store *Store) CreateProductImageTx(ctx context.Context, arg CreateProductImageParams) (ProductImage, error) {
+	var image ProductImage
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		_, err := q.GetProductForUpdate(ctx, arg.ProductID)
+		if err == sql.ErrNoRows {
+			return &ProductNotFoundError{ProductID: arg.ProductID}
+		} else if err != nil {
+			return err
+		}
+
+		image, err = q.CreateProductImage(ctx, arg)
+		return err
+	})
+
+	return image, err
+}
+
+const defaultImportBatchSize = 100
+
+// errImportDryRun rolls back a dry-run batch after it has been applied.
+var errImportDryRun = errors.New("import dry run")
+
+// ImportProductRow is one parsed line of a product import. Rows with a SKU
+// are matched on it; rows without one are matched on name. A matched
+// product keeps its description and stock unless the row sets them.
+type ImportProductRow struct {
+	Line           int            `json:"line"`
+	Sku            sql.NullString `json:"sku"`
+	Name           string         `json:"name"`
+	SetDescription bool           `json:"set_description"`
+	Description    sql.NullString `json:"description"`
+	Price          float64        `json:"price"`
+	SetStock       bool           `json:"set_stock"`
+	Stock          int32          `json:"stock"`
+}
+
+type ImportRowError struct {
+	Line  int    `json:"line"`
+	Error string `json:"error"`
+}
+
+type ImportProductsTxParams struct {
+	Rows []ImportProductRow `json:"rows"`
+	// DryRun applies every batch and then rolls it back, so the result
+	// reports what would change without changing anything.
+	DryRun    bool `json:"dry_run"`
+	BatchSize int  `json:"batch_size"`
+	// CreatedBy is recorded on the stock movements the import causes.
+	CreatedBy sql.NullInt64 `json:"created_by"`
+}
+
+type ImportProductsTxResult struct {
+	Created int              `json:"created"`
+	Updated int              `json:"updated"`
+	Errors  []ImportRowError `json:"errors"`
+}
+
+// ImportProductsTx creates or updates products in batches, one transaction
+// per batch. Each row runs under its own savepoint, so a failing row is
+// reported without discarding the rest of its batch.
+func (store *Store) ImportProductsTx(ctx context.Context, arg ImportProductsTxParams) (ImportProductsTxResult, error) {
+	result := ImportProductsTxResult{Errors: []ImportRowError{}}
+	batchSize := arg.BatchSize
+	if batchSize <= 0 {
+		batchSize = defaultImportBatchSize
+	}
+
+	for start := 0; start < len(arg.Rows); start += batchSize {
+		batch := arg.Rows[start:min(start+batchSize, len(arg.Rows))]
+		var batchResult ImportProductsTxResult
+
+		err := store.execTx(ctx, func(q *Queries) error {
+			batchResult = ImportProductsTxResult{}
+			for _, row := range batch {
+				created, err := importProductRow(ctx, q, row, arg.CreatedBy)
+				if err != nil {
+					batchResult.Errors = append(batchResult.Errors, ImportRowError{Line: row.Line, Error: err.Error()})
+					continue
+				}
+				if created {
+					batchResult.Created++
+				} else {
+					batchResult.Updated++
+				}
+			}
+			if arg.DryRun {
+				return errImportDryRun
+			}
+			return nil
+		})
+		if err != nil && !errors.Is(err, errImportDryRun) {
+			return result, err
+		}
+
+		result.Created += batchResult.Created
+		result.Updated += batchResult.Updated
+		result.Errors = append(result.Errors, batchResult.Errors...)
+	}
+
+	return result, nil
+}
+
+// importProductRow upserts a single row inside a savepoint and reports
+// whether a new product was created.
+func importProductRow(ctx context.Context, q *Queries, row ImportProductRow, createdBy sql.NullInt64) (bool, error) {
+	if _, err := q.db.ExecContext(ctx, "SAVEPOINT import_row"); err != nil {
+		return false, err
+	}
+
+	created, err := upsertProduct(ctx, q, row, createdBy)
+	if err != nil {
+		if _, rbErr := q.db.ExecContext(ctx, "ROLLBACK TO SAVEPOINT import_row"); rbErr != nil {
+			return false, rbErr
+		}
+		return false, err
+	}
+
+	_, err = q.db.ExecContext(ctx, "RELEASE SAVEPOINT import_row")
+	return created, err
+}
+
+func upsertProduct(ctx context.Context, q *Queries, row ImportProductRow, createdBy sql.NullInt64) (bool, error) {
+	var existing Product
+	var err error
+	if row.Sku.Valid {
+		existing, err = q.GetProductBySku(ctx, row.Sku)
+	} else {
+		existing, err = q.GetProductByName(ctx, row.Name)
+	}
+
+	if err == sql.ErrNoRows {
+		product, err := q.CreateProduct(ctx, CreateProductParams{
+			Name:        row.Name,
+			Description: row.Description,
+			Price:       row.Price,
+			Stock:       row.Stock,
+			Sku:         row.Sku,
+		})
+		if err != nil {
+			return false, err
+		}
+		err = recordMovement(ctx, q, CreateInventoryMovementParams{
+			ProductID: product.ID,
+			Kind:      string(MovementReceipt),
+			Quantity:  product.Stock,
+			Balance:   product.Stock,
+			Reason:    sql.NullString{String: "Product import", Valid: true},
+			CreatedBy: createdBy,
+		})
+		return err == nil, err
+	} else if err != nil {
+		return false, err
+	}
+	// SKUs stay reserved by archived products, so the row can neither
+	// update nor create one.
+	if existing.DeletedAt.Valid {
+		return false, fmt.Errorf("product with sku %s is archived", row.Sku.String)
+	}
+
+	existing, err = q.GetProductForUpdate(ctx, existing.ID)
+	if err != nil {
+		return false, err
+	}
+	arg := UpdateProductParams{
+		Name:        row.Name,
+		Description: existing.Description,
+		Price:       row.Price,
+		Stock:       existing.Stock,
+		UpdatedAt:   time.Now(),
+		ID:          existing.ID,
+	}
+	if row.SetDescription {
+		arg.Description = row.Description
+	}
+	if row.SetStock {
+		arg.Stock = row.Stock
+	}
+	product, err := q.UpdateProduct(ctx, arg)
+	if err != nil {
+		return false, err
+	}
+	return false, recordStockSet(ctx, q, existing.Stock, product, "Product import", createdBy)
+}
+
+// recordStockSet records the difference when a write replaced a product's
+// stock with an absolute value.
+func recordStockSet(ctx context.Context, q *Queries, before int32, product Product, reason string, createdBy sql.NullInt64) error {
+	return recordMovement(ctx, q, CreateInventoryMovementParams{
+		ProductID: product.ID,
+		Kind:      string(MovementAdjustment),
+		Quantity:  product.Stock - before,
+		Balance:   product.Stock,
+		Reason:    sql.NullString{String: reason, Valid: true},
+		CreatedBy: createdBy,
+	})
+}
+
+type CreateProductTxParams struct {
+	CreateProductParams
+	CreatedBy sql.NullInt64 `json:"created_by"`
+}
+
+// CreateProductTx creates a product and records its initial stock as a
+// receipt.
+func (store *Store) CreateProductTx(ctx context.Context, arg CreateProductTxParams) (Product, error) {
+	var product Product
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+		product, err = q.CreateProduct(ctx, arg.CreateProductParams)
+		if err != nil {
+			return err
+		}
+		return recordMovement(ctx, q, CreateInventoryMovementParams{
+			ProductID: product.ID,
+			Kind:      string(MovementReceipt),
+			Quantity:  product.Stock,
+			Balance:   product.Stock,
+			Reason:    sql.NullString{String: "Initial stock", Valid: true},
+			CreatedBy: arg.CreatedBy,
+		})
+	})
+
+	return product, err
+}
+
+type UpdateProductTxParams struct {
+	UpdateProductParams
+	UpdatedBy sql.NullInt64 `json:"updated_by"`
+}
+
+// UpdateProductTx replaces a product and records any stock change as an
+// adjustment. Like UpdateProduct it returns sql.ErrNoRows when the product
+// is missing or the expected version is stale.
+func (store *Store) UpdateProductTx(ctx context.Context, arg UpdateProductTxParams) (Product, error) {
+	var product Product
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		before, err := q.GetProductForUpdate(ctx, arg.ID)
+		if err != nil {
+			return err
+		}
+		product, err = q.UpdateProduct(ctx, arg.UpdateProductParams)
+		if err != nil {
+			return err
+		}
+		return recordStockSet(ctx, q, before.Stock, product, "Product update", arg.UpdatedBy)
+	})
+
+	return product, err
+}
+
+type PatchProductTxParams struct {
+	PatchProductParams
+	UpdatedBy sql.NullInt64 `json:"updated_by"`
+}
+
+// PatchProductTx is UpdateProductTx for partial updates.
+func (store *Store) PatchProductTx(ctx context.Context, arg PatchProductTxParams) (Product, error) {
+	var product Product
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		before, err := q.GetProductForUpdate(ctx, arg.ID)
+		if err != nil {
+			return err
+		}
+		product, err = q.PatchProduct(ctx, arg.PatchProductParams)
+		if err != nil {
+			return err
+		}
+		return recordStockSet(ctx, q, before.Stock, product, "Product update", arg.UpdatedBy)
+	})
+
+	return product, err
+}
+
+type CreateProductVariantTxParams struct {
+	CreateProductVariantParams
+	CreatedBy sql.NullInt64 `json:"created_by"`
+}
+
+// CreateProductVariantTx creates a variant and records its initial stock as
+// a receipt.
+func (store *Store) CreateProductVariantTx(ctx context.Context, arg CreateProductVariantTxParams) (ProductVariant, error) {
+	var variant ProductVariant
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+		variant, err = q.CreateProductVariant(ctx, arg.CreateProductVariantParams)
+		if err != nil {
+			return err
+		}
+		return recordMovement(ctx, q, CreateInventoryMovementParams{
+			ProductID: variant.ProductID,
+			VariantID: sql.NullInt64{Int64: variant.ID, Valid: true},
+			Kind:      string(MovementReceipt),
+			Quantity:  variant.Stock,
+			Balance:   variant.Stock,
+			Reason:    sql.NullString{String: "Initial stock", Valid: true},
+			CreatedBy: arg.CreatedBy,
+		})
+	})
+
+	return variant, err
+}
+
+type UpdateProductVariantTxParams struct {
+	UpdateProductVariantParams
+	UpdatedBy sql.NullInt64 `json:"updated_by"`
+}
+
+// UpdateProductVariantTx replaces a variant and records any stock change as
+// an adjustment. It returns sql.ErrNoRows when the variant does not belong
+// to the product.
+func (store *Store) UpdateProductVariantTx(ctx context.Context, arg UpdateProductVariantTxParams) (ProductVariant, error) {
+	var variant ProductVariant
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		before, err := q.GetProductVariantForUpdate(ctx, arg.ID)
+		if err != nil {
+			return err
+		}
+		if before.ProductID != arg.ProductID {
+			return sql.ErrNoRows
+		}
+		variant, err = q.UpdateProductVariant(ctx, arg.UpdateProductVariantParams)
+		if err != nil {
+			return err
+		}
+		return recordMovement(ctx, q, CreateInventoryMovementParams{
+			ProductID: variant.ProductID,
+			VariantID: sql.NullInt64{Int64: variant.ID, Valid: true},
+			Kind:      string(MovementAdjustment),
+			Quantity:  variant.Stock - before.Stock,
+			Balance:   variant.Stock,
+			Reason:    sql.NullString{String: "Variant update", Valid: true},
+			CreatedBy: arg.UpdatedBy,
+		})
+	})
+
+	return variant, err
+}
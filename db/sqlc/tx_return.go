@@ -0,0 +1,358 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/adedaryorh/ecommerceapi/utils"
+)
+
+type ReturnItemParams struct {
+	OrderItemID int64 `json:"order_item_id"`
+	Quantity    int32 `json:"quantity"`
+}
+
+type RequestReturnTxParams struct {
+	OrderID int64 `json:"order_id"`
+	// UserID must own the order.
+	UserID int64              `json:"user_id"`
+	Reason string             `json:"reason"`
+	Items  []ReturnItemParams `json:"items"`
+}
+
+type ReturnTxResult struct {
+	Return Return               `json:"return"`
+	Items  []ListReturnItemsRow `json:"items"`
+	// Refund is set when approving the return paid money back.
+	Refund *Refund `json:"refund,omitempty"`
+}
+
+// RequestReturnTx opens a return for units of a delivered order. Units
+// already covered by another return that was not rejected cannot be
+// returned again.
+func (store *Store) RequestReturnTx(ctx context.Context, arg RequestReturnTxParams) (ReturnTxResult, error) {
+	var result ReturnTxResult
+	if len(arg.Items) == 0 {
+		return result, ErrEmptyReturn
+	}
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		// Locking the order serialises returns of it, so two requests
+		// cannot both claim the same units.
+		order, err := q.GetOrderForUpdate(ctx, arg.OrderID)
+		if err == sql.ErrNoRows || (err == nil && order.UserID != arg.UserID) {
+			return ErrOrderNotFound
+		} else if err != nil {
+			return err
+		}
+		if OrderStatus(order.Status) != OrderStatusDelivered {
+			return ErrOrderNotReturnable
+		}
+
+		orderItems, err := q.ListOrderItems(ctx, order.ID)
+		if err != nil {
+			return err
+		}
+		ordered := make(map[int64]int32, len(orderItems))
+		for _, item := range orderItems {
+			ordered[item.ID] = item.Quantity
+		}
+		returnedRows, err := q.ListReturnedQuantities(ctx, order.ID)
+		if err != nil {
+			return err
+		}
+		returned := make(map[int64]int32, len(returnedRows))
+		for _, row := range returnedRows {
+			returned[row.OrderItemID] = row.Returned
+		}
+
+		// Repeated lines for the same item are merged.
+		requested := make(map[int64]int32)
+		lines := []int64{}
+		for _, item := range arg.Items {
+			if _, ok := requested[item.OrderItemID]; !ok {
+				lines = append(lines, item.OrderItemID)
+			}
+			requested[item.OrderItemID] += item.Quantity
+		}
+		for _, id := range lines {
+			returnable := ordered[id] - returned[id]
+			if requested[id] > returnable {
+				return &ReturnQuantityError{OrderItemID: id, Requested: requested[id], Returnable: returnable}
+			}
+		}
+
+		result.Return, err = q.CreateReturn(ctx, CreateReturnParams{
+			OrderID: order.ID,
+			UserID:  arg.UserID,
+			Reason:  arg.Reason,
+		})
+		if err != nil {
+			return err
+		}
+		for _, id := range lines {
+			_, err = q.CreateReturnItem(ctx, CreateReturnItemParams{
+				ReturnID:    result.Return.ID,
+				OrderItemID: id,
+				Quantity:    requested[id],
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		result.Items, err = q.ListReturnItems(ctx, result.Return.ID)
+		return err
+	})
+
+	return result, err
+}
+
+// RefundFunc pays amount cents of payment back through the provider that
+// took it and returns the provider's refund ID. It must be safe to call
+// again for the same return or order.
+//
+// It is called inside the transaction, with the order locked, so that a
+// failed refund rolls back the change that asked for it and nothing is
+// recorded as refunded that was not. The lock and a pooled connection are
+// held while it runs, so it must give up within a bounded time; providers
+// from payment.NewProvider stop after payment.RequestTimeout.
+type RefundFunc func(ctx context.Context, payment Payment, amount int64) (string, error)
+
+type ReviewReturnTxParams struct {
+	ReturnID   int64          `json:"return_id"`
+	ReviewedBy sql.NullInt64  `json:"reviewed_by"`
+	Note       sql.NullString `json:"note"`
+	// Refund is used when approving a return of an order paid through a
+	// payment provider.
+	Refund RefundFunc `json:"-"`
+}
+
+// ApproveReturnTx accepts a requested return and refunds its share of the
+// order's payment. When the refund is called but the transaction then fails,
+// retrying is safe because RefundFunc is idempotent per return.
+func (store *Store) ApproveReturnTx(ctx context.Context, arg ReviewReturnTxParams) (ReturnTxResult, error) {
+	var result ReturnTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		order, ret, err := lockReturn(ctx, q, arg.ReturnID, ReturnApproved)
+		if err != nil {
+			return err
+		}
+
+		result.Items, err = q.ListReturnItems(ctx, ret.ID)
+		if err != nil {
+			return err
+		}
+		result.Refund, err = refundReturn(ctx, q, order, ret, result.Items, arg)
+		if err != nil {
+			return err
+		}
+
+		result.Return, err = q.UpdateReturnStatus(ctx, UpdateReturnStatusParams{
+			Status:     string(ReturnApproved),
+			ReviewedBy: arg.ReviewedBy,
+			AdminNote:  arg.Note,
+			ID:         ret.ID,
+		})
+		return err
+	})
+
+	return result, err
+}
+
+// RejectReturnTx declines a requested return. Its units become returnable
+// again.
+func (store *Store) RejectReturnTx(ctx context.Context, arg ReviewReturnTxParams) (ReturnTxResult, error) {
+	var result ReturnTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		_, ret, err := lockReturn(ctx, q, arg.ReturnID, ReturnRejected)
+		if err != nil {
+			return err
+		}
+
+		result.Return, err = q.UpdateReturnStatus(ctx, UpdateReturnStatusParams{
+			Status:     string(ReturnRejected),
+			ReviewedBy: arg.ReviewedBy,
+			AdminNote:  arg.Note,
+			ID:         ret.ID,
+		})
+		if err != nil {
+			return err
+		}
+
+		result.Items, err = q.ListReturnItems(ctx, ret.ID)
+		return err
+	})
+
+	return result, err
+}
+
+// ReceiveReturnTx marks the goods of an approved return as back in the
+// warehouse and returns them to stock.
+func (store *Store) ReceiveReturnTx(ctx context.Context, arg ReviewReturnTxParams) (ReturnTxResult, error) {
+	var result ReturnTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		order, ret, err := lockReturn(ctx, q, arg.ReturnID, ReturnReceived)
+		if err != nil {
+			return err
+		}
+
+		result.Items, err = q.ListReturnItems(ctx, ret.ID)
+		if err != nil {
+			return err
+		}
+		restock := make([]OrderItem, 0, len(result.Items))
+		for _, item := range result.Items {
+			restock = append(restock, OrderItem{
+				ProductID: item.ProductID,
+				VariantID: item.VariantID,
+				Quantity:  item.Quantity,
+			})
+		}
+		err = restoreStock(ctx, q, restock, CreateInventoryMovementParams{
+			Kind:      string(MovementReturn),
+			Reason:    sql.NullString{String: fmt.Sprintf("Return #%d", ret.ID), Valid: true},
+			OrderID:   sql.NullInt64{Int64: order.ID, Valid: true},
+			CreatedBy: arg.ReviewedBy,
+		})
+		if err != nil {
+			return err
+		}
+
+		result.Return, err = q.UpdateReturnStatus(ctx, UpdateReturnStatusParams{
+			Status:     string(ReturnReceived),
+			ReviewedBy: arg.ReviewedBy,
+			AdminNote:  arg.Note,
+			ID:         ret.ID,
+		})
+		return err
+	})
+
+	return result, err
+}
+
+// lockReturn locks a return and its order, in the same order as
+// RequestReturnTx does, and checks that the return may move to next.
+func lockReturn(ctx context.Context, q *Queries, returnID int64, next ReturnStatus) (Order, Return, error) {
+	ret, err := q.GetReturnByID(ctx, returnID)
+	if err == sql.ErrNoRows {
+		return Order{}, Return{}, ErrReturnNotFound
+	} else if err != nil {
+		return Order{}, Return{}, err
+	}
+	order, err := q.GetOrderForUpdate(ctx, ret.OrderID)
+	if err != nil {
+		return Order{}, Return{}, err
+	}
+	ret, err = q.GetReturnForUpdate(ctx, returnID)
+	if err != nil {
+		return Order{}, Return{}, err
+	}
+
+	from := ReturnStatus(ret.Status)
+	if !from.CanTransitionTo(next) {
+		return Order{}, Return{}, &InvalidReturnTransitionError{From: from, To: next}
+	}
+	return order, ret, nil
+}
+
+// refundReturn pays back the returned lines, including their share of the
+// order's tax, against the order's captured payment. The approval that
+// covers every unit of the order refunds whatever is left so rounding cannot strand
+// cents. Orders without a provider payment are not refunded.
+func refundReturn(ctx context.Context, q *Queries, order Order, ret Return, items []ListReturnItemsRow, arg ReviewReturnTxParams) (*Refund, error) {
+	paid, err := q.GetCapturedOrderPayment(ctx, order.ID)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	orderItems, err := q.ListOrderItems(ctx, order.ID)
+	if err != nil {
+		return nil, err
+	}
+	approvedRows, err := q.ListApprovedReturnQuantities(ctx, order.ID)
+	if err != nil {
+		return nil, err
+	}
+	returned := make(map[int64]int32, len(approvedRows))
+	for _, row := range approvedRows {
+		returned[row.OrderItemID] = row.Returned
+	}
+	for _, item := range items {
+		returned[item.OrderItemID] += item.Quantity
+	}
+
+	var subtotal int64
+	complete := true
+	for _, item := range orderItems {
+		price, err := utils.ParseCents(item.Price)
+		if err != nil {
+			return nil, err
+		}
+		subtotal += price * int64(item.Quantity)
+		complete = complete && returned[item.ID] >= item.Quantity
+	}
+	var lines int64
+	for _, item := range items {
+		price, err := utils.ParseCents(item.Price)
+		if err != nil {
+			return nil, err
+		}
+		lines += price * int64(item.Quantity)
+	}
+
+	total, err := utils.ParseCents(order.TotalAmount)
+	if err != nil {
+		return nil, err
+	}
+	amount := lines
+	if subtotal > 0 {
+		amount = lines * total / subtotal
+	}
+
+	paidAmount, err := utils.ParseCents(paid.Amount)
+	if err != nil {
+		return nil, err
+	}
+	refundedAmount, err := q.GetRefundedAmount(ctx, paid.ID)
+	if err != nil {
+		return nil, err
+	}
+	refunded, err := utils.ParseCents(refundedAmount)
+	if err != nil {
+		return nil, err
+	}
+	remaining := paidAmount - refunded
+	if complete || amount > remaining {
+		amount = remaining
+	}
+	if amount <= 0 {
+		return nil, nil
+	}
+
+	providerRefundID, err := arg.Refund(ctx, paid, amount)
+	if err != nil {
+		return nil, err
+	}
+	refund, err := q.RecordRefund(ctx, RecordRefundParams{
+		PaymentID:        paid.ID,
+		ReturnID:         sql.NullInt64{Int64: ret.ID, Valid: true},
+		Amount:           utils.FormatCents(amount),
+		ProviderRefundID: providerRefundID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	note := sql.NullString{String: fmt.Sprintf("Refunded by return #%d", ret.ID), Valid: true}
+	if err := settleRefunds(ctx, q, order, paid, arg.ReviewedBy, note); err != nil {
+		return nil, err
+	}
+	return &refund, nil
+}
@@ -0,0 +1,186 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/adedaryorh/ecommerceapi/payment"
+	"github.com/adedaryorh/ecommerceapi/utils"
+)
+
+type ProcessPaymentEventTxParams struct {
+	Provider string               `json:"provider"`
+	Event    payment.WebhookEvent `json:"event"`
+	// Payload is the event as delivered, kept for auditing.
+	Payload json.RawMessage `json:"payload"`
+	// Refund pays back a payment captured for an order that cannot take
+	// it, such as one that was cancelled, expired or already paid.
+	Refund RefundFunc `json:"-"`
+}
+
+type ProcessPaymentEventTxResult struct {
+	// Duplicate is set when the event had already been received, in which
+	// case it was not applied again.
+	Duplicate bool `json:"duplicate"`
+}
+
+// ProcessPaymentEventTx records a provider's payment event and applies it to
+// the order it concerns. Events of unknown types are recorded and otherwise
+// ignored. A capture the order cannot take, because the order was cancelled,
+// expired or already paid by another transaction or because the amount does
+// not match its total, is recorded and refunded. If applying the event fails nothing is recorded, so
+// the provider's redelivery gets another chance.
+func (store *Store) ProcessPaymentEventTx(ctx context.Context, arg ProcessPaymentEventTxParams) (ProcessPaymentEventTxResult, error) {
+	var result ProcessPaymentEventTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		inserted, err := q.RecordWebhookEvent(ctx, RecordWebhookEventParams{
+			Provider: arg.Provider,
+			EventID:  arg.Event.ID,
+			Type:     arg.Event.Type,
+			Payload:  arg.Payload,
+		})
+		if err != nil {
+			return err
+		}
+		if inserted == 0 {
+			result.Duplicate = true
+			return nil
+		}
+
+		data := arg.Event.Data
+		switch arg.Event.Type {
+		case payment.EventPaymentCaptured:
+			_, err = payOrder(ctx, q, PayOrderTxParams{
+				OrderID:               data.OrderID,
+				Provider:              arg.Provider,
+				ProviderTransactionID: data.TransactionID,
+				Amount:                data.Amount,
+			})
+			var transitionErr *InvalidTransitionError
+			if errors.As(err, &transitionErr) || errors.Is(err, ErrPaymentAmountMismatch) {
+				return refundLateCapture(ctx, q, arg.Provider, data, arg.Refund)
+			}
+			return err
+
+		case payment.EventPaymentFailed:
+			// The order stays pending so the customer can pay again.
+			_, err = q.GetOrderByID(ctx, data.OrderID)
+			if err == sql.ErrNoRows {
+				return ErrOrderNotFound
+			} else if err != nil {
+				return err
+			}
+			_, err = q.RecordPayment(ctx, RecordPaymentParams{
+				OrderID:               data.OrderID,
+				Provider:              arg.Provider,
+				ProviderTransactionID: data.TransactionID,
+				Amount:                data.Amount,
+				Status:                payment.StatusFailed,
+				FailureReason:         sql.NullString{String: data.Reason, Valid: data.Reason != ""},
+			})
+			return err
+
+		case payment.EventPaymentRefunded:
+			return recordProviderRefund(ctx, q, arg.Provider, data)
+		}
+		return nil
+	})
+
+	return result, err
+}
+
+// refundLateCapture records a payment captured for an order that cannot
+// take it and refunds it in full, so the money is not kept and the event is
+// not refused on every redelivery.
+func refundLateCapture(ctx context.Context, q *Queries, provider string, data payment.WebhookEventData, refund RefundFunc) error {
+	amount, err := utils.ParseCents(data.Amount)
+	if err != nil {
+		return err
+	}
+	if amount <= 0 {
+		return ErrInvalidEventAmount
+	}
+	paid, err := q.RecordPayment(ctx, RecordPaymentParams{
+		OrderID:               data.OrderID,
+		Provider:              provider,
+		ProviderTransactionID: data.TransactionID,
+		Amount:                utils.FormatCents(amount),
+		Status:                payment.StatusCaptured,
+	})
+	if err != nil {
+		return err
+	}
+	return refundPayment(ctx, q, paid, refund)
+}
+
+// recordProviderRefund stores a refund made at the provider, such as one
+// issued from its dashboard. Refunds we started ourselves are already stored
+// and are left as they are. A new refund may not take the total refunded
+// above the amount captured.
+func recordProviderRefund(ctx context.Context, q *Queries, provider string, data payment.WebhookEventData) error {
+	paid, err := q.GetPaymentByProviderTransaction(ctx, GetPaymentByProviderTransactionParams{
+		Provider:              provider,
+		ProviderTransactionID: data.TransactionID,
+	})
+	if err == sql.ErrNoRows {
+		return ErrPaymentNotFound
+	} else if err != nil {
+		return err
+	}
+	order, err := q.GetOrderForUpdate(ctx, paid.OrderID)
+	if err != nil {
+		return err
+	}
+
+	_, err = q.GetRefundByProviderRefundID(ctx, GetRefundByProviderRefundIDParams{
+		PaymentID:        paid.ID,
+		ProviderRefundID: data.RefundID,
+	})
+	if err == sql.ErrNoRows {
+		if err = checkProviderRefund(ctx, q, paid, data.Amount); err != nil {
+			return err
+		}
+		_, err = q.RecordRefund(ctx, RecordRefundParams{
+			PaymentID:        paid.ID,
+			Amount:           data.Amount,
+			ProviderRefundID: data.RefundID,
+		})
+	}
+	if err != nil {
+		return err
+	}
+	note := sql.NullString{String: fmt.Sprintf("Refunded at %s", provider), Valid: true}
+	return settleRefunds(ctx, q, order, paid, sql.NullInt64{}, note)
+}
+
+// checkProviderRefund makes sure a refund reported by the provider is
+// positive and fits in what is left of the payment.
+func checkProviderRefund(ctx context.Context, q *Queries, paid Payment, amount string) error {
+	cents, err := utils.ParseCents(amount)
+	if err != nil {
+		return err
+	}
+	if cents <= 0 {
+		return ErrInvalidEventAmount
+	}
+	paidAmount, err := utils.ParseCents(paid.Amount)
+	if err != nil {
+		return err
+	}
+	refundedAmount, err := q.GetRefundedAmount(ctx, paid.ID)
+	if err != nil {
+		return err
+	}
+	refunded, err := utils.ParseCents(refundedAmount)
+	if err != nil {
+		return err
+	}
+	if refunded+cents > paidAmount {
+		return ErrRefundExceedsPayment
+	}
+	return nil
+}
@@ -8,14 +8,29 @@ package db
 import (
 	"context"
 	"time"
+
+	"github.com/lib/pq"
 )
 
+const archiveUser = `-- name: ArchiveUser :execrows
+UPDATE users SET deleted_at = NOW(), updated_at = NOW()
+WHERE id = $1 AND deleted_at IS NULL
+`
+
+func (q *Queries) ArchiveUser(ctx context.Context, id int64) (int64, error) {
+	result, err := q.db.ExecContext(ctx, archiveUser, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const createUser = `-- name: CreateUser :one
 INSERT INTO users (
     email,
     hashed_password,
     username
-) VALUES ($1, $2, $3) RETURNING id, email, hashed_password, username, role, created_at, updated_at
+) VALUES ($1, $2, $3) RETURNING id, email, hashed_password, username, role, created_at, updated_at, deleted_at
 `
 
 type CreateUserParams struct {
@@ -36,6 +51,7 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.Role,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.DeletedAt,
 	)
 	return i, err
 }
@@ -49,17 +65,17 @@ func (q *Queries) DeleteAllUsers(ctx context.Context) error {
 	return err
 }
 
-const deleteUser = `-- name: DeleteUser :exec
-DELETE FROM users WHERE id = $1
+const deleteUsers = `-- name: DeleteUsers :exec
+DELETE FROM users WHERE id = ANY($1::bigint[])
 `
 
-func (q *Queries) DeleteUser(ctx context.Context, id int64) error {
-	_, err := q.db.ExecContext(ctx, deleteUser, id)
+func (q *Queries) DeleteUsers(ctx context.Context, ids []int64) error {
+	_, err := q.db.ExecContext(ctx, deleteUsers, pq.Array(ids))
 	return err
 }
 
 const getUserByEmail = `-- name: GetUserByEmail :one
-SELECT id, email, hashed_password, username, role, created_at, updated_at FROM users WHERE email= $1
+SELECT id, email, hashed_password, username, role, created_at, updated_at, deleted_at FROM users WHERE email= $1 AND deleted_at IS NULL
 `
 
 func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error) {
@@ -73,12 +89,13 @@ func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error
 		&i.Role,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.DeletedAt,
 	)
 	return i, err
 }
 
 const getUserByID = `-- name: GetUserByID :one
-SELECT id, email, hashed_password, username, role, created_at, updated_at FROM users WHERE id= $1
+SELECT id, email, hashed_password, username, role, created_at, updated_at, deleted_at FROM users WHERE id= $1 AND deleted_at IS NULL
 `
 
 func (q *Queries) GetUserByID(ctx context.Context, id int64) (User, error) {
@@ -92,12 +109,13 @@ func (q *Queries) GetUserByID(ctx context.Context, id int64) (User, error) {
 		&i.Role,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.DeletedAt,
 	)
 	return i, err
 }
 
 const listUser = `-- name: ListUser :many
-SELECT id, email, hashed_password, username, role, created_at, updated_at FROM users ORDER BY id
+SELECT id, email, hashed_password, username, role, created_at, updated_at, deleted_at FROM users WHERE deleted_at IS NULL ORDER BY id
     LIMIT $1 OFFSET $2
 `
 
@@ -123,6 +141,7 @@ func (q *Queries) ListUser(ctx context.Context, arg ListUserParams) ([]User, err
 			&i.Role,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.DeletedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -137,9 +156,30 @@ func (q *Queries) ListUser(ctx context.Context, arg ListUserParams) ([]User, err
 	return items, nil
 }
 
+const restoreUser = `-- name: RestoreUser :one
+UPDATE users SET deleted_at = NULL, updated_at = NOW()
+WHERE id = $1 AND deleted_at IS NOT NULL RETURNING id, email, hashed_password, username, role, created_at, updated_at, deleted_at
+`
+
+func (q *Queries) RestoreUser(ctx context.Context, id int64) (User, error) {
+	row := q.db.QueryRowContext(ctx, restoreUser, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Email,
+		&i.HashedPassword,
+		&i.Username,
+		&i.Role,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
 const updateUserPassword = `-- name: UpdateUserPassword :one
 UPDATE users SET hashed_password = $1, updated_at = $2
-WHERE id = $3 RETURNING id, email, hashed_password, username, role, created_at, updated_at
+WHERE id = $3 AND deleted_at IS NULL RETURNING id, email, hashed_password, username, role, created_at, updated_at, deleted_at
 `
 
 type UpdateUserPasswordParams struct {
@@ -159,6 +199,7 @@ func (q *Queries) UpdateUserPassword(ctx context.Context, arg UpdateUserPassword
 		&i.Role,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.DeletedAt,
 	)
 	return i, err
 }
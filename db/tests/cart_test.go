@@ -0,0 +1,95 @@
+package db_test
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"testing"
+
+	db "github.com/adedaryorh/ecommerceapi/db/sqlc"
+	"github.com/adedaryorh/ecommerceapi/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckoutCartTx(t *testing.T) {
+	user := createRandomUser(t)
+	product := createRandomProduct(t, 4.10, 10)
+
+	cart, err := testQuery.GetOrCreateCart(context.Background(), user.ID)
+	assert.NoError(t, err)
+
+	_, err = testQuery.UpsertCartItem(context.Background(), db.UpsertCartItemParams{
+		CartID:    cart.ID,
+		ProductID: product.ID,
+		Quantity:  3,
+	})
+	assert.NoError(t, err)
+
+	result, err := testStore.CheckoutCartTx(context.Background(), db.CheckoutCartTxParams{UserID: user.ID})
+	assert.NoError(t, err)
+	assert.Equal(t, "12.30", result.Order.TotalAmount)
+	assert.Len(t, result.OrderItems, 1)
+
+	items, err := testQuery.ListCartItems(context.Background(), cart.ID)
+	assert.NoError(t, err)
+	assert.Empty(t, items)
+
+	_, err = testStore.CheckoutCartTx(context.Background(), db.CheckoutCartTxParams{UserID: user.ID})
+	assert.ErrorIs(t, err, db.ErrEmptyCart)
+}
+
+func TestCheckoutCartTxVariant(t *testing.T) {
+	user := createRandomUser(t)
+	product := createRandomProduct(t, 20, 7)
+	variant, err := testQuery.CreateProductVariant(context.Background(), db.CreateProductVariantParams{
+		ProductID:  product.ID,
+		Sku:        utils.RandomString(12),
+		Attributes: json.RawMessage(`{"size":"M"}`),
+		Price:      sql.NullString{String: "24.00", Valid: true},
+		Stock:      2,
+	})
+	assert.NoError(t, err)
+
+	cart, err := testQuery.GetOrCreateCart(context.Background(), user.ID)
+	assert.NoError(t, err)
+	variantID := sql.NullInt64{Int64: variant.ID, Valid: true}
+	// The variant and the plain product are separate lines, and setting a
+	// line again replaces its quantity.
+	for _, arg := range []db.UpsertCartItemParams{
+		{CartID: cart.ID, ProductID: product.ID, VariantID: variantID, Quantity: 1},
+		{CartID: cart.ID, ProductID: product.ID, Quantity: 1},
+		{CartID: cart.ID, ProductID: product.ID, VariantID: variantID, Quantity: 3},
+	} {
+		_, err = testQuery.UpsertCartItem(context.Background(), arg)
+		assert.NoError(t, err)
+	}
+
+	items, err := testQuery.ListCartItems(context.Background(), cart.ID)
+	assert.NoError(t, err)
+	assert.Len(t, items, 2)
+	assert.Equal(t, variantID, items[0].VariantID)
+	assert.Equal(t, "24.00", items[0].Price)
+	assert.Equal(t, int32(2), items[0].Stock)
+	assert.Equal(t, variant.Sku, items[0].VariantSku.String)
+
+	// Checkout checks the variant's stock, not the product's.
+	_, err = testStore.CheckoutCartTx(context.Background(), db.CheckoutCartTxParams{UserID: user.ID})
+	var stockErr *db.InsufficientStockError
+	assert.ErrorAs(t, err, &stockErr)
+	assert.Equal(t, variant.ID, stockErr.VariantID)
+
+	_, err = testQuery.UpsertCartItem(context.Background(), db.UpsertCartItemParams{
+		CartID: cart.ID, ProductID: product.ID, VariantID: variantID, Quantity: 2,
+	})
+	assert.NoError(t, err)
+	result, err := testStore.CheckoutCartTx(context.Background(), db.CheckoutCartTxParams{UserID: user.ID})
+	assert.NoError(t, err)
+	assert.Equal(t, "68.00", result.Order.TotalAmount)
+	assert.Equal(t, variantID, result.OrderItems[0].VariantID)
+
+	removed, err := testQuery.RemoveCartItem(context.Background(), db.RemoveCartItemParams{
+		CartID: cart.ID, ProductID: product.ID, VariantID: variantID,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), removed)
+}
@@ -0,0 +1,164 @@
+package db_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	db "github.com/adedaryorh/ecommerceapi/db/sqlc"
+	"github.com/adedaryorh/ecommerceapi/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func createRandomCoupon(t *testing.T, arg db.CreateCouponParams, scope db.CouponScope) db.Coupon {
+	arg.Code = db.NormalizeCouponCode("save-" + utils.RandomString(8))
+	if arg.MinSpend == "" {
+		arg.MinSpend = "0.00"
+	}
+	arg.Active = true
+	result, err := testStore.CreateCouponTx(context.Background(), db.CreateCouponTxParams{
+		CreateCouponParams: arg,
+		Scope:              scope,
+	})
+	assert.NoError(t, err)
+	return result.Coupon
+}
+
+func TestPlaceOrderTxCoupon(t *testing.T) {
+	ctx := context.Background()
+	suffix := utils.RandomString(8)
+	parent, err := testQuery.CreateCategory(ctx, db.CreateCategoryParams{Name: "Garden " + suffix, Slug: "garden-" + suffix})
+	assert.NoError(t, err)
+	child, err := testQuery.CreateCategory(ctx, db.CreateCategoryParams{
+		Name:     "Tools " + suffix,
+		Slug:     "tools-" + suffix,
+		ParentID: sql.NullInt64{Int64: parent.ID, Valid: true},
+	})
+	assert.NoError(t, err)
+
+	tool := createRandomProduct(t, 40, 5)
+	other := createRandomProduct(t, 10, 5)
+	_, err = testStore.SetProductCategoriesTx(ctx, db.SetProductCategoriesTxParams{ProductID: tool.ID, CategoryIDs: []int64{child.ID}})
+	assert.NoError(t, err)
+
+	// 25% off the garden category, which covers the tool through its subcategory.
+	coupon := createRandomCoupon(t, db.CreateCouponParams{
+		Kind:         string(db.CouponPercentage),
+		Value:        "25.00",
+		MinSpend:     "50.00",
+		PerUserLimit: sql.NullInt32{Int32: 1, Valid: true},
+	}, db.CouponScope{CategoryIDs: []int64{parent.ID}})
+
+	user := createRandomUser(t)
+	items := []db.PlaceOrderItem{{ProductID: tool.ID, Quantity: 1}, {ProductID: other.ID, Quantity: 1}}
+
+	// Codes are matched regardless of case.
+	placed, err := testStore.PlaceOrderTx(ctx, db.PlaceOrderTxParams{UserID: user.ID, Items: items, CouponCode: "  " + coupon.Code + " "})
+	assert.NoError(t, err)
+	assert.Equal(t, "10.00", placed.Order.DiscountAmount)
+	assert.Equal(t, "40.00", placed.Order.TotalAmount)
+	assert.Equal(t, coupon.Code, placed.Order.CouponCode.String)
+
+	_, err = testStore.PlaceOrderTx(ctx, db.PlaceOrderTxParams{UserID: user.ID, Items: items, CouponCode: coupon.Code})
+	var notApplicable *db.CouponNotApplicableError
+	assert.ErrorAs(t, err, &notApplicable)
+
+	// Cancelling the order gives the use back.
+	_, err = testStore.CancelOrderTx(ctx, db.CancelOrderTxParams{OrderID: placed.Order.ID})
+	assert.NoError(t, err)
+	_, err = testStore.PlaceOrderTx(ctx, db.PlaceOrderTxParams{UserID: user.ID, Items: items, CouponCode: coupon.Code})
+	assert.NoError(t, err)
+
+	// Below the minimum spend.
+	another := createRandomUser(t)
+	_, err = testStore.PlaceOrderTx(ctx, db.PlaceOrderTxParams{
+		UserID:     another.ID,
+		Items:      []db.PlaceOrderItem{{ProductID: other.ID, Quantity: 1}},
+		CouponCode: coupon.Code,
+	})
+	assert.ErrorAs(t, err, &notApplicable)
+
+	// In scope of nothing ordered.
+	_, err = testStore.PlaceOrderTx(ctx, db.PlaceOrderTxParams{
+		UserID:     another.ID,
+		Items:      []db.PlaceOrderItem{{ProductID: other.ID, Quantity: 5}},
+		CouponCode: coupon.Code,
+	})
+	assert.ErrorAs(t, err, &notApplicable)
+
+	_, err = testStore.PlaceOrderTx(ctx, db.PlaceOrderTxParams{UserID: another.ID, Items: items, CouponCode: "NO-SUCH-CODE"})
+	assert.ErrorIs(t, err, db.ErrCouponNotFound)
+}
+
+func TestPlaceOrderTxCouponLimits(t *testing.T) {
+	ctx := context.Background()
+	product := createRandomProduct(t, 20, 10)
+	items := []db.PlaceOrderItem{{ProductID: product.ID, Quantity: 1}}
+
+	// Fixed discounts never take the total below zero.
+	fixed := createRandomCoupon(t, db.CreateCouponParams{
+		Kind:       string(db.CouponFixed),
+		Value:      "50.00",
+		UsageLimit: sql.NullInt32{Int32: 1, Valid: true},
+	}, db.CouponScope{})
+	placed, err := testStore.PlaceOrderTx(ctx, db.PlaceOrderTxParams{UserID: createRandomUser(t).ID, Items: items, CouponCode: fixed.Code})
+	assert.NoError(t, err)
+	assert.Equal(t, "20.00", placed.Order.DiscountAmount)
+	assert.Equal(t, "0.00", placed.Order.TotalAmount)
+
+	var notApplicable *db.CouponNotApplicableError
+	_, err = testStore.PlaceOrderTx(ctx, db.PlaceOrderTxParams{UserID: createRandomUser(t).ID, Items: items, CouponCode: fixed.Code})
+	assert.ErrorAs(t, err, &notApplicable)
+
+	expired := createRandomCoupon(t, db.CreateCouponParams{
+		Kind:     string(db.CouponFixed),
+		Value:    "5.00",
+		StartsAt: sql.NullTime{Time: time.Now().Add(-2 * time.Hour), Valid: true},
+		EndsAt:   sql.NullTime{Time: time.Now().Add(-time.Hour), Valid: true},
+	}, db.CouponScope{})
+	_, err = testStore.PlaceOrderTx(ctx, db.PlaceOrderTxParams{UserID: createRandomUser(t).ID, Items: items, CouponCode: expired.Code})
+	assert.ErrorAs(t, err, &notApplicable)
+}
+
+func TestPayOrderTxFullDiscount(t *testing.T) {
+	ctx := context.Background()
+	user := createRandomUser(t)
+	product := createRandomProduct(t, 20, 10)
+	coupon := createRandomCoupon(t, db.CreateCouponParams{
+		Kind:  string(db.CouponPercentage),
+		Value: "100.00",
+	}, db.CouponScope{})
+	placed, err := testStore.PlaceOrderTx(ctx, db.PlaceOrderTxParams{
+		UserID:     user.ID,
+		Items:      []db.PlaceOrderItem{{ProductID: product.ID, Quantity: 1}},
+		CouponCode: coupon.Code,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "20.00", placed.Order.DiscountAmount)
+	assert.Equal(t, "0.00", placed.Order.TotalAmount)
+
+	// Nothing is owed, so the order is paid without recording a payment.
+	arg := db.PayOrderTxParams{
+		OrderID:  placed.Order.ID,
+		OwnerID:  sql.NullInt64{Int64: user.ID, Valid: true},
+		Provider: "mock",
+		Amount:   "0.00",
+	}
+	result, err := testStore.PayOrderTx(ctx, arg)
+	assert.NoError(t, err)
+	assert.False(t, result.Replayed)
+	assert.Equal(t, string(db.OrderStatusPaid), result.Order.Status)
+	assert.Zero(t, result.Payment.ID)
+	payments, err := testQuery.ListOrderPayments(ctx, placed.Order.ID)
+	assert.NoError(t, err)
+	assert.Empty(t, payments)
+
+	result, err = testStore.PayOrderTx(ctx, arg)
+	assert.NoError(t, err)
+	assert.True(t, result.Replayed)
+
+	// With no payment to refund, cancelling needs no provider.
+	_, err = testStore.CancelOrderTx(ctx, db.CancelOrderTxParams{OrderID: placed.Order.ID})
+	assert.NoError(t, err)
+}
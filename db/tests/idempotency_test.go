@@ -0,0 +1,79 @@
+package db_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	db "github.com/adedaryorh/ecommerceapi/db/sqlc"
+	"github.com/adedaryorh/ecommerceapi/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIdempotencyKeys(t *testing.T) {
+	ctx := context.Background()
+	user := createRandomUser(t)
+	claim := db.ClaimIdempotencyKeyParams{
+		UserID:      user.ID,
+		Key:         utils.RandomString(16),
+		RequestHash: utils.RandomString(64),
+		StaleBefore: time.Now().Add(-time.Minute),
+	}
+
+	claimed, err := testQuery.ClaimIdempotencyKey(ctx, claim)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, claimed)
+
+	// A request in progress cannot be claimed again until it goes stale,
+	// and only by the same request.
+	claimed, err = testQuery.ClaimIdempotencyKey(ctx, claim)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 0, claimed)
+
+	other := claim
+	other.RequestHash = utils.RandomString(64)
+	other.StaleBefore = time.Now().Add(time.Minute)
+	claimed, err = testQuery.ClaimIdempotencyKey(ctx, other)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 0, claimed)
+
+	stale := claim
+	stale.StaleBefore = time.Now().Add(time.Minute)
+	claimed, err = testQuery.ClaimIdempotencyKey(ctx, stale)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, claimed)
+
+	err = testQuery.CompleteIdempotencyKey(ctx, db.CompleteIdempotencyKeyParams{
+		StatusCode:   sql.NullInt32{Int32: 201, Valid: true},
+		ResponseBody: []byte(`{"id":1}`),
+		UserID:       user.ID,
+		Key:          claim.Key,
+	})
+	assert.NoError(t, err)
+
+	// Completed keys are never taken over.
+	claimed, err = testQuery.ClaimIdempotencyKey(ctx, stale)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 0, claimed)
+
+	stored, err := testQuery.GetIdempotencyKey(ctx, db.GetIdempotencyKeyParams{UserID: user.ID, Key: claim.Key})
+	assert.NoError(t, err)
+	assert.Equal(t, claim.RequestHash, stored.RequestHash)
+	assert.EqualValues(t, 201, stored.StatusCode.Int32)
+	assert.JSONEq(t, `{"id":1}`, string(stored.ResponseBody))
+	assert.True(t, stored.CompletedAt.Valid)
+
+	// Keys belong to a user, so another user may use the same one.
+	another := createRandomUser(t)
+	shared := claim
+	shared.UserID = another.ID
+	claimed, err = testQuery.ClaimIdempotencyKey(ctx, shared)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, claimed)
+
+	err = testQuery.DeleteIdempotencyKey(ctx, db.DeleteIdempotencyKeyParams{UserID: another.ID, Key: claim.Key})
+	assert.NoError(t, err)
+	_, err = testQuery.GetIdempotencyKey(ctx, db.GetIdempotencyKeyParams{UserID: another.ID, Key: claim.Key})
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+}
@@ -0,0 +1,176 @@
+package db_test
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"testing"
+
+	db "github.com/adedaryorh/ecommerceapi/db/sqlc"
+	"github.com/adedaryorh/ecommerceapi/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInventoryLedger(t *testing.T) {
+	user := createRandomUser(t)
+	product, err := testStore.CreateProductTx(context.Background(), db.CreateProductTxParams{
+		CreateProductParams: db.CreateProductParams{
+			Name:  utils.RandomString(10),
+			Price: 4,
+			Stock: 5,
+		},
+	})
+	assert.NoError(t, err)
+
+	movement, err := testStore.AdjustStockTx(context.Background(), db.AdjustStockTxParams{
+		ProductID: product.ID,
+		Kind:      db.MovementAdjustment,
+		Quantity:  -2,
+		Reason:    sql.NullString{String: "damaged", Valid: true},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int32(3), movement.Balance)
+
+	_, err = testStore.AdjustStockTx(context.Background(), db.AdjustStockTxParams{
+		ProductID: product.ID,
+		Kind:      db.MovementAdjustment,
+		Quantity:  -4,
+	})
+	var noStock *db.InsufficientStockError
+	assert.ErrorAs(t, err, &noStock)
+
+	placed, err := testStore.PlaceOrderTx(context.Background(), db.PlaceOrderTxParams{
+		UserID: user.ID,
+		Items:  []db.PlaceOrderItem{{ProductID: product.ID, Quantity: 1}},
+	})
+	assert.NoError(t, err)
+	_, err = testStore.TransitionOrderTx(context.Background(), db.TransitionOrderTxParams{
+		OrderID: placed.Order.ID,
+		Status:  db.OrderStatusPaid,
+	})
+	assert.NoError(t, err)
+
+	movements, err := testQuery.ListInventoryMovements(context.Background(), db.ListInventoryMovementsParams{
+		ProductID: product.ID,
+		PageLimit: 10,
+	})
+	assert.NoError(t, err)
+	assert.Len(t, movements, 3)
+
+	// Newest first, and the ledger adds up to the current stock.
+	assert.Equal(t, string(db.MovementSale), movements[0].Kind)
+	assert.Equal(t, int32(2), movements[0].Balance)
+	var total int32
+	for _, m := range movements {
+		total += m.Quantity
+	}
+	assert.Equal(t, movements[0].Balance, total)
+}
+
+func TestCheckLowStockSales(t *testing.T) {
+	user := createRandomUser(t)
+	product := createRandomProduct(t, 3, 6)
+	_, err := testQuery.PatchProduct(context.Background(), db.PatchProductParams{
+		ReorderThreshold: sql.NullInt32{Int32: 4, Valid: true},
+		ID:               product.ID,
+	})
+	assert.NoError(t, err)
+
+	// Paid sales take 6 -> 5, above the threshold, then 5 -> 3, crossing it,
+	// then 3 -> 2, already below.
+	for _, quantity := range []int32{1, 2, 1} {
+		placed, err := testStore.PlaceOrderTx(context.Background(), db.PlaceOrderTxParams{
+			UserID: user.ID,
+			Items:  []db.PlaceOrderItem{{ProductID: product.ID, Quantity: quantity}},
+		})
+		assert.NoError(t, err)
+		_, err = testStore.TransitionOrderTx(context.Background(), db.TransitionOrderTxParams{
+			OrderID: placed.Order.ID,
+			Status:  db.OrderStatusPaid,
+		})
+		assert.NoError(t, err)
+	}
+
+	// Other tests' sales may be waiting too, so check until none are left.
+	var checked []db.CheckLowStockSalesRow
+	for {
+		sales, err := testQuery.CheckLowStockSales(context.Background(), 100)
+		assert.NoError(t, err)
+		for _, sale := range sales {
+			if sale.ProductID == product.ID {
+				checked = append(checked, sale)
+			}
+		}
+		if err != nil || len(sales) < 100 {
+			break
+		}
+	}
+	if assert.Len(t, checked, 3) {
+		assert.False(t, checked[0].CrossedThreshold)
+		assert.True(t, checked[1].CrossedThreshold)
+		assert.Equal(t, int32(3), checked[1].Balance)
+		assert.False(t, checked[2].CrossedThreshold)
+	}
+
+	// Checked sales are not returned again.
+	sales, err := testQuery.CheckLowStockSales(context.Background(), 100)
+	assert.NoError(t, err)
+	for _, sale := range sales {
+		assert.NotEqual(t, product.ID, sale.ProductID)
+	}
+}
+
+func TestCheckLowStockSalesVariant(t *testing.T) {
+	user := createRandomUser(t)
+	product := createRandomProduct(t, 3, 1)
+	_, err := testQuery.PatchProduct(context.Background(), db.PatchProductParams{
+		ReorderThreshold: sql.NullInt32{Int32: 3, Valid: true},
+		ID:               product.ID,
+	})
+	assert.NoError(t, err)
+	variant, err := testQuery.CreateProductVariant(context.Background(), db.CreateProductVariantParams{
+		ProductID:  product.ID,
+		Sku:        utils.RandomString(12),
+		Attributes: json.RawMessage(`{"size":"S"}`),
+		Stock:      4,
+	})
+	assert.NoError(t, err)
+
+	// The product holds 1 unit and the variant 4, so selling 2 of the
+	// variant takes the product from 5 to 3, still at the threshold, and
+	// selling 1 more takes it to 2, below.
+	for _, quantity := range []int32{2, 1} {
+		placed, err := testStore.PlaceOrderTx(context.Background(), db.PlaceOrderTxParams{
+			UserID: user.ID,
+			Items:  []db.PlaceOrderItem{{ProductID: product.ID, VariantID: sql.NullInt64{Int64: variant.ID, Valid: true}, Quantity: quantity}},
+		})
+		assert.NoError(t, err)
+		_, err = testStore.TransitionOrderTx(context.Background(), db.TransitionOrderTxParams{
+			OrderID: placed.Order.ID,
+			Status:  db.OrderStatusPaid,
+		})
+		assert.NoError(t, err)
+	}
+
+	var checked []db.CheckLowStockSalesRow
+	for {
+		sales, err := testQuery.CheckLowStockSales(context.Background(), 100)
+		assert.NoError(t, err)
+		for _, sale := range sales {
+			if sale.ProductID == product.ID {
+				checked = append(checked, sale)
+			}
+		}
+		if err != nil || len(sales) < 100 {
+			break
+		}
+	}
+	if assert.Len(t, checked, 2) {
+		assert.Equal(t, variant.ID, checked[0].VariantID.Int64)
+		assert.False(t, checked[0].CrossedThreshold)
+		assert.Equal(t, int32(3), checked[0].TotalStock)
+		assert.True(t, checked[1].CrossedThreshold)
+		assert.Equal(t, int32(1), checked[1].Balance)
+		assert.Equal(t, int32(2), checked[1].TotalStock)
+	}
+}
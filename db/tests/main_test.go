@@ -11,6 +11,8 @@ import (
 )
 
 var testQuery *db.Queries
+var testStore *db.Store
+var testDB *sql.DB
 
 func TestMain(m *testing.M) {
 	config, err := utils.LoadConfig("../..")
@@ -23,6 +25,8 @@ func TestMain(m *testing.M) {
 		log.Fatal("error connecting to postgres:", err)
 	}
 
-	testQuery = db.New(conn)
+	testDB = conn
+	testStore = db.NewStore(conn)
+	testQuery = testStore.Queries
 	os.Exit(m.Run())
 }
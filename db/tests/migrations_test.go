@@ -0,0 +1,35 @@
+package db_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/adedaryorh/ecommerceapi/db/migrations"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadMigrations(t *testing.T) {
+	loaded, err := migrations.Load()
+	assert.NoError(t, err)
+	assert.NotEmpty(t, loaded)
+
+	for i, migration := range loaded {
+		assert.NotEmpty(t, migration.Up, migration.Name)
+		assert.NotEmpty(t, migration.Down, migration.Name)
+		if i > 0 {
+			assert.Greater(t, migration.Version, loaded[i-1].Version)
+		}
+	}
+}
+
+func TestMigratorStatus(t *testing.T) {
+	migrator, err := migrations.NewMigrator(testDB)
+	assert.NoError(t, err)
+
+	// The test database is migrated before the suite runs.
+	status, err := migrator.Status(context.Background())
+	assert.NoError(t, err)
+	assert.False(t, status.Dirty)
+	loaded := migrator.Migrations()
+	assert.Equal(t, loaded[len(loaded)-1].Version, status.Version)
+}
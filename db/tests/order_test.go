@@ -0,0 +1,320 @@
+package db_test
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	db "github.com/adedaryorh/ecommerceapi/db/sqlc"
+	"github.com/adedaryorh/ecommerceapi/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func createRandomProduct(t *testing.T, price float64, stock int32) db.Product {
+	arg := db.CreateProductParams{
+		Name:        utils.RandomString(10),
+		Description: sql.NullString{String: utils.RandomString(20), Valid: true},
+		Price:       price,
+		Stock:       stock,
+	}
+
+	product, err := testQuery.CreateProduct(context.Background(), arg)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, product)
+	assert.Equal(t, arg.Name, product.Name)
+	assert.Equal(t, arg.Stock, product.Stock)
+
+	return product
+}
+
+func TestPlaceOrderTx(t *testing.T) {
+	user := createRandomUser(t)
+	product1 := createRandomProduct(t, 10.50, 5)
+	product2 := createRandomProduct(t, 2.25, 10)
+
+	result, err := testStore.PlaceOrderTx(context.Background(), db.PlaceOrderTxParams{
+		UserID: user.ID,
+		Items: []db.PlaceOrderItem{
+			{ProductID: product1.ID, Quantity: 2},
+			{ProductID: product2.ID, Quantity: 3},
+			{ProductID: product1.ID, Quantity: 1},
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, user.ID, result.Order.UserID)
+	assert.Equal(t, "Pending", result.Order.Status)
+	assert.Equal(t, "38.25", result.Order.TotalAmount)
+	assert.Len(t, result.OrderItems, 2)
+	assert.Equal(t, int32(3), result.OrderItems[0].Quantity)
+	assert.Equal(t, "10.50", result.OrderItems[0].Price)
+	assert.Equal(t, product1.Name, result.OrderItems[0].ProductName)
+
+	// Stock stays put until payment; the order only reserves it.
+	updated1, err := testQuery.GetProductByID(context.Background(), product1.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(5), updated1.Stock)
+	reserved1, err := testQuery.GetReservedProductStock(context.Background(), product1.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(3), reserved1)
+
+	reserved2, err := testQuery.GetReservedProductStock(context.Background(), product2.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(3), reserved2)
+}
+
+func TestPlaceOrderTxInsufficientStock(t *testing.T) {
+	user := createRandomUser(t)
+	product1 := createRandomProduct(t, 5, 10)
+	product2 := createRandomProduct(t, 5, 1)
+
+	_, err := testStore.PlaceOrderTx(context.Background(), db.PlaceOrderTxParams{
+		UserID: user.ID,
+		Items: []db.PlaceOrderItem{
+			{ProductID: product1.ID, Quantity: 4},
+			{ProductID: product2.ID, Quantity: 2},
+		},
+	})
+	var stockErr *db.InsufficientStockError
+	assert.ErrorAs(t, err, &stockErr)
+	assert.Equal(t, product2.ID, stockErr.ProductID)
+
+	// The whole order is rolled back, including the first product's reservation.
+	reserved, err := testQuery.GetReservedProductStock(context.Background(), product1.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(0), reserved)
+}
+
+func TestPlaceOrderTxConcurrent(t *testing.T) {
+	user := createRandomUser(t)
+	product := createRandomProduct(t, 1, 5)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := testStore.PlaceOrderTx(context.Background(), db.PlaceOrderTxParams{
+				UserID: user.ID,
+				Items:  []db.PlaceOrderItem{{ProductID: product.ID, Quantity: 1}},
+			})
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	succeeded := 0
+	for err := range errs {
+		if err == nil {
+			succeeded++
+		}
+	}
+	assert.Equal(t, 5, succeeded)
+
+	reserved, err := testQuery.GetReservedProductStock(context.Background(), product.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(5), reserved)
+}
+
+func TestTransitionOrderTx(t *testing.T) {
+	user := createRandomUser(t)
+	product := createRandomProduct(t, 3, 5)
+
+	placed, err := testStore.PlaceOrderTx(context.Background(), db.PlaceOrderTxParams{
+		UserID: user.ID,
+		Items:  []db.PlaceOrderItem{{ProductID: product.ID, Quantity: 1}},
+	})
+	assert.NoError(t, err)
+
+	result, err := testStore.TransitionOrderTx(context.Background(), db.TransitionOrderTxParams{
+		OrderID:   placed.Order.ID,
+		Status:    db.OrderStatusPaid,
+		ChangedBy: sql.NullInt64{Int64: user.ID, Valid: true},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, string(db.OrderStatusPaid), result.Order.Status)
+	assert.Equal(t, string(db.OrderStatusPending), result.History.FromStatus)
+	assert.Equal(t, user.ID, result.History.ChangedBy.Int64)
+
+	// Paying commits the reservation to a stock decrement.
+	paid, err := testQuery.GetProductByID(context.Background(), product.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(4), paid.Stock)
+	reserved, err := testQuery.GetReservedProductStock(context.Background(), product.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(0), reserved)
+
+	_, err = testStore.TransitionOrderTx(context.Background(), db.TransitionOrderTxParams{
+		OrderID: placed.Order.ID,
+		Status:  db.OrderStatusDelivered,
+	})
+	var transitionErr *db.InvalidTransitionError
+	assert.ErrorAs(t, err, &transitionErr)
+
+	history, err := testQuery.ListOrderStatusHistory(context.Background(), placed.Order.ID)
+	assert.NoError(t, err)
+	assert.Len(t, history, 1)
+}
+
+func TestOrderStatusTransitions(t *testing.T) {
+	assert.True(t, db.OrderStatusPending.CanTransitionTo(db.OrderStatusPaid))
+	assert.True(t, db.OrderStatusShipped.CanTransitionTo(db.OrderStatusDelivered))
+	assert.False(t, db.OrderStatusPending.CanTransitionTo(db.OrderStatusShipped))
+	assert.False(t, db.OrderStatusCancelled.CanTransitionTo(db.OrderStatusCancelled))
+	assert.False(t, db.OrderStatus("Lost").Valid())
+}
+
+func TestCancelOrderTxRestoresStock(t *testing.T) {
+	owner := createRandomUser(t)
+	other := createRandomUser(t)
+	product := createRandomProduct(t, 7, 4)
+
+	placed, err := testStore.PlaceOrderTx(context.Background(), db.PlaceOrderTxParams{
+		UserID: owner.ID,
+		Items:  []db.PlaceOrderItem{{ProductID: product.ID, Quantity: 3}},
+	})
+	assert.NoError(t, err)
+
+	_, err = testStore.CancelOrderTx(context.Background(), db.CancelOrderTxParams{
+		OrderID: placed.Order.ID,
+		OwnerID: sql.NullInt64{Int64: other.ID, Valid: true},
+	})
+	assert.ErrorIs(t, err, db.ErrOrderNotFound)
+
+	result, err := testStore.CancelOrderTx(context.Background(), db.CancelOrderTxParams{
+		OrderID:   placed.Order.ID,
+		OwnerID:   sql.NullInt64{Int64: owner.ID, Valid: true},
+		ChangedBy: sql.NullInt64{Int64: owner.ID, Valid: true},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, string(db.OrderStatusCancelled), result.Order.Status)
+
+	restored, err := testQuery.GetProductByID(context.Background(), product.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(4), restored.Stock)
+	reserved, err := testQuery.GetReservedProductStock(context.Background(), product.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(0), reserved)
+
+	_, err = testStore.CancelOrderTx(context.Background(), db.CancelOrderTxParams{
+		OrderID: placed.Order.ID,
+	})
+	var transitionErr *db.InvalidTransitionError
+	assert.ErrorAs(t, err, &transitionErr)
+}
+
+func TestSearchOrdersKeyset(t *testing.T) {
+	user := createRandomUser(t)
+	product := createRandomProduct(t, 1, 10)
+
+	for i := 0; i < 3; i++ {
+		_, err := testStore.PlaceOrderTx(context.Background(), db.PlaceOrderTxParams{
+			UserID: user.ID,
+			Items:  []db.PlaceOrderItem{{ProductID: product.ID, Quantity: int32(i + 1)}},
+		})
+		assert.NoError(t, err)
+	}
+
+	arg := db.SearchOrdersParams{
+		UserID:     sql.NullInt64{Int64: user.ID, Valid: true},
+		SortBy:     "total_amount",
+		Descending: true,
+		PageSize:   2,
+	}
+	page1, err := testQuery.SearchOrders(context.Background(), arg)
+	assert.NoError(t, err)
+	assert.Len(t, page1, 2)
+	assert.Equal(t, "3.00", page1[0].TotalAmount)
+	assert.Equal(t, "2.00", page1[1].TotalAmount)
+
+	last := page1[len(page1)-1]
+	arg.CursorID = sql.NullInt64{Int64: last.ID, Valid: true}
+	arg.CursorAmount = sql.NullString{String: last.TotalAmount, Valid: true}
+	page2, err := testQuery.SearchOrders(context.Background(), arg)
+	assert.NoError(t, err)
+	assert.Len(t, page2, 1)
+	assert.Equal(t, "1.00", page2[0].TotalAmount)
+}
+
+func TestPlaceOrderTxVariant(t *testing.T) {
+	user := createRandomUser(t)
+	product := createRandomProduct(t, 20, 7)
+
+	variant, err := testQuery.CreateProductVariant(context.Background(), db.CreateProductVariantParams{
+		ProductID:  product.ID,
+		Sku:        utils.RandomString(12),
+		Attributes: json.RawMessage(`{"size":"L","color":"red"}`),
+		Price:      sql.NullString{String: "24.00", Valid: true},
+		Stock:      2,
+	})
+	assert.NoError(t, err)
+
+	result, err := testStore.PlaceOrderTx(context.Background(), db.PlaceOrderTxParams{
+		UserID: user.ID,
+		Items: []db.PlaceOrderItem{
+			{ProductID: product.ID, VariantID: sql.NullInt64{Int64: variant.ID, Valid: true}, Quantity: 2},
+			{ProductID: product.ID, Quantity: 1},
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "68.00", result.Order.TotalAmount)
+	assert.Len(t, result.OrderItems, 2)
+	assert.Equal(t, variant.ID, result.OrderItems[0].VariantID.Int64)
+	assert.Equal(t, "24.00", result.OrderItems[0].Price)
+
+	// Variant lines reserve variant stock; only the plain line touches the product.
+	reservedVariant, err := testQuery.GetReservedVariantStock(context.Background(), sql.NullInt64{Int64: variant.ID, Valid: true})
+	assert.NoError(t, err)
+	assert.Equal(t, int32(2), reservedVariant)
+	reservedProduct, err := testQuery.GetReservedProductStock(context.Background(), product.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(1), reservedProduct)
+
+	_, err = testStore.PlaceOrderTx(context.Background(), db.PlaceOrderTxParams{
+		UserID: user.ID,
+		Items:  []db.PlaceOrderItem{{ProductID: product.ID, VariantID: sql.NullInt64{Int64: variant.ID, Valid: true}, Quantity: 1}},
+	})
+	var stockErr *db.InsufficientStockError
+	assert.ErrorAs(t, err, &stockErr)
+	assert.Equal(t, variant.ID, stockErr.VariantID)
+}
+
+func TestExpireOrderTx(t *testing.T) {
+	user := createRandomUser(t)
+	product := createRandomProduct(t, 2, 3)
+
+	placed, err := testStore.PlaceOrderTx(context.Background(), db.PlaceOrderTxParams{
+		UserID:         user.ID,
+		Items:          []db.PlaceOrderItem{{ProductID: product.ID, Quantity: 3}},
+		ReservationTTL: time.Millisecond,
+	})
+	assert.NoError(t, err)
+	time.Sleep(10 * time.Millisecond)
+
+	expiredIDs, err := testQuery.ListExpiredPendingOrders(context.Background(), db.ListExpiredPendingOrdersParams{
+		StaleBefore: time.Now().Add(-time.Hour),
+		PageLimit:   1000,
+	})
+	assert.NoError(t, err)
+	assert.Contains(t, expiredIDs, placed.Order.ID)
+
+	expired, err := testStore.ExpireOrderTx(context.Background(), placed.Order.ID)
+	assert.NoError(t, err)
+	assert.True(t, expired)
+
+	order, err := testQuery.GetOrderByID(context.Background(), placed.Order.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, string(db.OrderStatusExpired), order.Status)
+	reserved, err := testQuery.GetReservedProductStock(context.Background(), product.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(0), reserved)
+
+	// An order that is no longer pending is left alone.
+	expired, err = testStore.ExpireOrderTx(context.Background(), placed.Order.ID)
+	assert.NoError(t, err)
+	assert.False(t, expired)
+}
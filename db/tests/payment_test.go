@@ -0,0 +1,155 @@
+package db_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	db "github.com/adedaryorh/ecommerceapi/db/sqlc"
+	"github.com/adedaryorh/ecommerceapi/payment"
+	"github.com/adedaryorh/ecommerceapi/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPayOrderTx(t *testing.T) {
+	user := createRandomUser(t)
+	product := createRandomProduct(t, 4, 5)
+
+	placed, err := testStore.PlaceOrderTx(context.Background(), db.PlaceOrderTxParams{
+		UserID: user.ID,
+		Items:  []db.PlaceOrderItem{{ProductID: product.ID, Quantity: 2}},
+	})
+	assert.NoError(t, err)
+
+	arg := db.PayOrderTxParams{
+		OrderID:               placed.Order.ID,
+		Provider:              "mock",
+		ProviderTransactionID: "mock_ch_" + utils.RandomString(12),
+		Amount:                placed.Order.TotalAmount,
+	}
+
+	_, err = testStore.PayOrderTx(context.Background(), db.PayOrderTxParams{
+		OrderID:               arg.OrderID,
+		Provider:              arg.Provider,
+		ProviderTransactionID: arg.ProviderTransactionID,
+		Amount:                "0.01",
+	})
+	assert.ErrorIs(t, err, db.ErrPaymentAmountMismatch)
+
+	result, err := testStore.PayOrderTx(context.Background(), arg)
+	assert.NoError(t, err)
+	assert.False(t, result.Replayed)
+	assert.Equal(t, string(db.OrderStatusPaid), result.Order.Status)
+	assert.Equal(t, payment.StatusCaptured, result.Payment.Status)
+	assert.Equal(t, arg.ProviderTransactionID, result.Payment.ProviderTransactionID)
+
+	// Paying commits the reservation.
+	paid, err := testQuery.GetProductByID(context.Background(), product.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(3), paid.Stock)
+
+	// Recording the same transaction again changes nothing.
+	replay, err := testStore.PayOrderTx(context.Background(), arg)
+	assert.NoError(t, err)
+	assert.True(t, replay.Replayed)
+	assert.Equal(t, result.Payment.ID, replay.Payment.ID)
+
+	payments, err := testQuery.ListOrderPayments(context.Background(), placed.Order.ID)
+	assert.NoError(t, err)
+	assert.Len(t, payments, 1)
+
+	// A second, different payment for a paid order is refused.
+	arg.ProviderTransactionID = "mock_ch_" + utils.RandomString(12)
+	_, err = testStore.PayOrderTx(context.Background(), arg)
+	var transitionErr *db.InvalidTransitionError
+	assert.ErrorAs(t, err, &transitionErr)
+}
+
+func TestCancelOrderTxRefundsPayment(t *testing.T) {
+	user := createRandomUser(t)
+	product := createRandomProduct(t, 4, 5)
+
+	placed, err := testStore.PlaceOrderTx(context.Background(), db.PlaceOrderTxParams{
+		UserID: user.ID,
+		Items:  []db.PlaceOrderItem{{ProductID: product.ID, Quantity: 2}},
+	})
+	assert.NoError(t, err)
+	paid, err := testStore.PayOrderTx(context.Background(), db.PayOrderTxParams{
+		OrderID:               placed.Order.ID,
+		Provider:              "mock",
+		ProviderTransactionID: "mock_ch_" + utils.RandomString(12),
+		Amount:                placed.Order.TotalAmount,
+	})
+	assert.NoError(t, err)
+
+	// Money is held, so the order cannot be cancelled without a refund.
+	_, err = testStore.CancelOrderTx(context.Background(), db.CancelOrderTxParams{OrderID: placed.Order.ID})
+	assert.ErrorIs(t, err, db.ErrOrderPaymentCaptured)
+
+	refunds := []int64{}
+	result, err := testStore.CancelOrderTx(context.Background(), db.CancelOrderTxParams{
+		OrderID: placed.Order.ID,
+		Refund: func(ctx context.Context, payment db.Payment, amount int64) (string, error) {
+			refunds = append(refunds, amount)
+			return "mock_re_" + utils.RandomString(12), nil
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, string(db.OrderStatusCancelled), result.Order.Status)
+	assert.Equal(t, []int64{800}, refunds)
+
+	refunded, err := testQuery.GetRefundedAmount(context.Background(), paid.Payment.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "8.00", refunded)
+	payments, err := testQuery.ListOrderPayments(context.Background(), placed.Order.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, payment.StatusRefunded, payments[0].Status)
+
+	restocked, err := testQuery.GetProductByID(context.Background(), product.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(5), restocked.Stock)
+}
+
+func TestCancelOrderTxRefundFailure(t *testing.T) {
+	user := createRandomUser(t)
+	product := createRandomProduct(t, 4, 5)
+
+	placed, err := testStore.PlaceOrderTx(context.Background(), db.PlaceOrderTxParams{
+		UserID: user.ID,
+		Items:  []db.PlaceOrderItem{{ProductID: product.ID, Quantity: 2}},
+	})
+	assert.NoError(t, err)
+	paid, err := testStore.PayOrderTx(context.Background(), db.PayOrderTxParams{
+		OrderID:               placed.Order.ID,
+		Provider:              "mock",
+		ProviderTransactionID: "mock_ch_" + utils.RandomString(12),
+		Amount:                placed.Order.TotalAmount,
+	})
+	assert.NoError(t, err)
+
+	// A refund the provider refuses leaves the order as it was.
+	providerErr := errors.New("provider unavailable")
+	_, err = testStore.CancelOrderTx(context.Background(), db.CancelOrderTxParams{
+		OrderID: placed.Order.ID,
+		Refund: func(ctx context.Context, payment db.Payment, amount int64) (string, error) {
+			return "", providerErr
+		},
+	})
+	assert.ErrorIs(t, err, providerErr)
+
+	order, err := testQuery.GetOrderByID(context.Background(), placed.Order.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, string(db.OrderStatusPaid), order.Status)
+	refunded, err := testQuery.GetRefundedAmount(context.Background(), paid.Payment.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "0.00", refunded)
+	payments, err := testQuery.ListOrderPayments(context.Background(), placed.Order.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, payment.StatusCaptured, payments[0].Status)
+	stock, err := testQuery.GetProductByID(context.Background(), product.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(3), stock.Stock)
+	history, err := testQuery.ListOrderStatusHistory(context.Background(), placed.Order.ID)
+	assert.NoError(t, err)
+	assert.Len(t, history, 1)
+}
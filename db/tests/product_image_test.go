@@ -0,0 +1,52 @@
+package db_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	db "github.com/adedaryorh/ecommerceapi/db/sqlc"
+	"github.com/adedaryorh/ecommerceapi/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateProductImageTxConcurrent(t *testing.T) {
+	product := createRandomProduct(t, 5, 1)
+
+	var wg sync.WaitGroup
+	positions := make(chan int32, 5)
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			key := utils.RandomString(16)
+			image, err := testStore.CreateProductImageTx(context.Background(), db.CreateProductImageParams{
+				ProductID:    product.ID,
+				StorageKey:   key + ".png",
+				ThumbnailKey: key + "_thumb.png",
+				ContentType:  "image/png",
+			})
+			assert.NoError(t, err)
+			positions <- image.Position
+		}()
+	}
+	wg.Wait()
+	close(positions)
+
+	// Every upload gets its own position.
+	seen := map[int32]bool{}
+	for position := range positions {
+		assert.False(t, seen[position], "position %d was given twice", position)
+		seen[position] = true
+	}
+	assert.Len(t, seen, 5)
+
+	_, err := testStore.CreateProductImageTx(context.Background(), db.CreateProductImageParams{
+		ProductID:    -1,
+		StorageKey:   "missing.png",
+		ThumbnailKey: "missing_thumb.png",
+		ContentType:  "image/png",
+	})
+	var notFoundErr *db.ProductNotFoundError
+	assert.ErrorAs(t, err, &notFoundErr)
+}
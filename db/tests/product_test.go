@@ -0,0 +1,202 @@
+package db_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	db "github.com/adedaryorh/ecommerceapi/db/sqlc"
+	"github.com/adedaryorh/ecommerceapi/utils"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSearchProducts(t *testing.T) {
+	word := utils.RandomString(12)
+	inStock, err := testQuery.CreateProduct(context.Background(), db.CreateProductParams{
+		Name:        word + " lamp",
+		Description: sql.NullString{String: "a bright desk lamp", Valid: true},
+		Price:       25,
+		Stock:       3,
+	})
+	assert.NoError(t, err)
+	_, err = testQuery.CreateProduct(context.Background(), db.CreateProductParams{
+		Name:        "plain chair",
+		Description: sql.NullString{String: "goes well with " + word, Valid: true},
+		Price:       40,
+		Stock:       0,
+	})
+	assert.NoError(t, err)
+
+	rows, err := testQuery.SearchProducts(context.Background(), db.SearchProductsParams{
+		Query:     word,
+		SortBy:    "relevance",
+		PageLimit: 10,
+	})
+	assert.NoError(t, err)
+	assert.Len(t, rows, 2)
+	assert.Equal(t, int64(2), rows[0].TotalCount)
+	// Name matches are weighted above description matches.
+	assert.Equal(t, inStock.ID, rows[0].ID)
+
+	rows, err = testQuery.SearchProducts(context.Background(), db.SearchProductsParams{
+		Query:       word,
+		InStockOnly: true,
+		MaxPrice:    sql.NullString{String: "30.00", Valid: true},
+		SortBy:      "price_asc",
+		PageLimit:   10,
+	})
+	assert.NoError(t, err)
+	assert.Len(t, rows, 1)
+	assert.Equal(t, inStock.ID, rows[0].ID)
+
+	// Past the last page there is no row to carry the window count.
+	rows, err = testQuery.SearchProducts(context.Background(), db.SearchProductsParams{
+		Query:      word,
+		SortBy:     "relevance",
+		PageLimit:  10,
+		PageOffset: 10,
+	})
+	assert.NoError(t, err)
+	assert.Empty(t, rows)
+	total, err := testQuery.CountSearchProducts(context.Background(), db.CountSearchProductsParams{Query: word})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+}
+
+func TestListProductsByCategory(t *testing.T) {
+	suffix := utils.RandomString(8)
+	parent, err := testQuery.CreateCategory(context.Background(), db.CreateCategoryParams{
+		Name: "Furniture " + suffix,
+		Slug: "furniture-" + suffix,
+	})
+	assert.NoError(t, err)
+	child, err := testQuery.CreateCategory(context.Background(), db.CreateCategoryParams{
+		Name:     "Chairs " + suffix,
+		Slug:     "chairs-" + suffix,
+		ParentID: sql.NullInt64{Int64: parent.ID, Valid: true},
+	})
+	assert.NoError(t, err)
+
+	product := createRandomProduct(t, 15, 5)
+	categories, err := testStore.SetProductCategoriesTx(context.Background(), db.SetProductCategoriesTxParams{
+		ProductID:   product.ID,
+		CategoryIDs: []int64{child.ID},
+	})
+	assert.NoError(t, err)
+	assert.Len(t, categories, 1)
+
+	// Filtering by the parent includes products in its subcategories.
+	products, err := testQuery.ListProducts(context.Background(), db.ListProductsParams{
+		CategoryID: sql.NullInt64{Int64: parent.ID, Valid: true},
+		PageLimit:  10,
+	})
+	assert.NoError(t, err)
+	assert.Len(t, products, 1)
+	assert.Equal(t, product.ID, products[0].ID)
+
+	descendant, err := testQuery.IsCategoryDescendant(context.Background(), db.IsCategoryDescendantParams{
+		AncestorID: parent.ID,
+		CategoryID: child.ID,
+	})
+	assert.NoError(t, err)
+	assert.True(t, descendant)
+
+	_, err = testStore.SetProductCategoriesTx(context.Background(), db.SetProductCategoriesTxParams{
+		ProductID:   product.ID,
+		CategoryIDs: []int64{-1},
+	})
+	assert.ErrorIs(t, err, db.ErrCategoryNotFound)
+}
+
+func TestImportProductsTx(t *testing.T) {
+	sku := utils.RandomString(12)
+	existing := createRandomProduct(t, 10, 1)
+	rows := []db.ImportProductRow{
+		{Line: 2, Sku: sql.NullString{String: sku, Valid: true}, Name: "imported " + sku, Price: 9.99, SetStock: true, Stock: 4},
+		{Line: 3, Name: existing.Name, Price: 12.5, SetStock: true, Stock: 8},
+	}
+
+	result, err := testStore.ImportProductsTx(context.Background(), db.ImportProductsTxParams{
+		Rows:   rows,
+		DryRun: true,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, result.Created)
+	assert.Equal(t, 1, result.Updated)
+	_, err = testQuery.GetProductBySku(context.Background(), sql.NullString{String: sku, Valid: true})
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+
+	result, err = testStore.ImportProductsTx(context.Background(), db.ImportProductsTxParams{
+		Rows:      rows,
+		BatchSize: 1,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, result.Created)
+	assert.Equal(t, 1, result.Updated)
+	assert.Empty(t, result.Errors)
+
+	created, err := testQuery.GetProductBySku(context.Background(), sql.NullString{String: sku, Valid: true})
+	assert.NoError(t, err)
+	assert.Equal(t, int32(4), created.Stock)
+	updated, err := testQuery.GetProductByID(context.Background(), existing.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "12.50", updated.Price)
+	assert.Equal(t, int32(8), updated.Stock)
+
+	// Rows without description or stock leave them unchanged.
+	result, err = testStore.ImportProductsTx(context.Background(), db.ImportProductsTxParams{
+		Rows: []db.ImportProductRow{{Line: 2, Name: existing.Name, Price: 13}},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, result.Updated)
+	updated, err = testQuery.GetProductByID(context.Background(), existing.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "13.00", updated.Price)
+	assert.Equal(t, int32(8), updated.Stock)
+	assert.Equal(t, existing.Description, updated.Description)
+
+	// Archived products still hold their SKU.
+	_, err = testQuery.ArchiveProduct(context.Background(), created.ID)
+	assert.NoError(t, err)
+	result, err = testStore.ImportProductsTx(context.Background(), db.ImportProductsTxParams{Rows: rows[:1]})
+	assert.NoError(t, err)
+	assert.Len(t, result.Errors, 1)
+	assert.Contains(t, result.Errors[0].Error, "archived")
+}
+
+func TestPatchProductVersion(t *testing.T) {
+	product := createRandomProduct(t, 10, 3)
+
+	patched, err := testQuery.PatchProduct(context.Background(), db.PatchProductParams{
+		Stock:           sql.NullInt32{Int32: 9, Valid: true},
+		ID:              product.ID,
+		ExpectedVersion: sql.NullInt32{Int32: product.Version, Valid: true},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int32(9), patched.Stock)
+	assert.Equal(t, product.Name, patched.Name)
+	assert.Equal(t, product.Description, patched.Description)
+	assert.Equal(t, product.Version+1, patched.Version)
+
+	// A writer still holding the old version no longer matches.
+	_, err = testQuery.PatchProduct(context.Background(), db.PatchProductParams{
+		Name:            sql.NullString{String: "stale", Valid: true},
+		ID:              product.ID,
+		ExpectedVersion: sql.NullInt32{Int32: product.Version, Valid: true},
+	})
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestProductStockCheck(t *testing.T) {
+	product := createRandomProduct(t, 10, 3)
+
+	_, err := testQuery.AddProductStock(context.Background(), db.AddProductStockParams{
+		Amount: -4,
+		ID:     product.ID,
+	})
+	var pgErr *pq.Error
+	if assert.ErrorAs(t, err, &pgErr) {
+		assert.Equal(t, "products_stock_check", pgErr.Constraint)
+	}
+}
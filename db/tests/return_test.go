@@ -0,0 +1,157 @@
+package db_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	db "github.com/adedaryorh/ecommerceapi/db/sqlc"
+	"github.com/adedaryorh/ecommerceapi/payment"
+	"github.com/adedaryorh/ecommerceapi/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+// createDeliveredOrder places a paid and delivered order for quantity units
+// of product.
+func createDeliveredOrder(t *testing.T, user db.User, product db.Product, quantity int32) db.PlaceOrderTxResult {
+	placed, err := testStore.PlaceOrderTx(context.Background(), db.PlaceOrderTxParams{
+		UserID: user.ID,
+		Items:  []db.PlaceOrderItem{{ProductID: product.ID, Quantity: quantity}},
+	})
+	assert.NoError(t, err)
+
+	_, err = testStore.PayOrderTx(context.Background(), db.PayOrderTxParams{
+		OrderID:               placed.Order.ID,
+		Provider:              "mock",
+		ProviderTransactionID: "mock_ch_" + utils.RandomString(12),
+		Amount:                placed.Order.TotalAmount,
+	})
+	assert.NoError(t, err)
+	for _, status := range []db.OrderStatus{db.OrderStatusShipped, db.OrderStatusDelivered} {
+		_, err = testStore.TransitionOrderTx(context.Background(), db.TransitionOrderTxParams{
+			OrderID: placed.Order.ID,
+			Status:  status,
+		})
+		assert.NoError(t, err)
+	}
+	return placed
+}
+
+func TestReturnWorkflow(t *testing.T) {
+	user := createRandomUser(t)
+	product := createRandomProduct(t, 10, 5)
+	placed := createDeliveredOrder(t, user, product, 2)
+	orderItemID := placed.OrderItems[0].ID
+
+	refunds := []int64{}
+	refundFn := func(ctx context.Context, paid db.Payment, amount int64) (string, error) {
+		refunds = append(refunds, amount)
+		return "mock_re_" + utils.RandomString(12), nil
+	}
+
+	_, err := testStore.RequestReturnTx(context.Background(), db.RequestReturnTxParams{
+		OrderID: placed.Order.ID,
+		UserID:  user.ID,
+		Reason:  "Too many",
+		Items:   []db.ReturnItemParams{{OrderItemID: orderItemID, Quantity: 3}},
+	})
+	var quantityErr *db.ReturnQuantityError
+	assert.ErrorAs(t, err, &quantityErr)
+
+	first, err := testStore.RequestReturnTx(context.Background(), db.RequestReturnTxParams{
+		OrderID: placed.Order.ID,
+		UserID:  user.ID,
+		Reason:  "Damaged",
+		Items:   []db.ReturnItemParams{{OrderItemID: orderItemID, Quantity: 1}},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, string(db.ReturnRequested), first.Return.Status)
+	assert.Len(t, first.Items, 1)
+
+	// Goods cannot be received before the return is approved.
+	_, err = testStore.ReceiveReturnTx(context.Background(), db.ReviewReturnTxParams{ReturnID: first.Return.ID})
+	var transitionErr *db.InvalidReturnTransitionError
+	assert.ErrorAs(t, err, &transitionErr)
+
+	approved, err := testStore.ApproveReturnTx(context.Background(), db.ReviewReturnTxParams{
+		ReturnID: first.Return.ID,
+		Note:     sql.NullString{String: "Sorry about that", Valid: true},
+		Refund:   refundFn,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, string(db.ReturnApproved), approved.Return.Status)
+	if assert.NotNil(t, approved.Refund) {
+		assert.Equal(t, "10.00", approved.Refund.Amount)
+	}
+
+	received, err := testStore.ReceiveReturnTx(context.Background(), db.ReviewReturnTxParams{ReturnID: first.Return.ID})
+	assert.NoError(t, err)
+	assert.Equal(t, string(db.ReturnReceived), received.Return.Status)
+
+	restocked, err := testQuery.GetProductByID(context.Background(), product.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(4), restocked.Stock)
+	movements, err := testQuery.ListInventoryMovements(context.Background(), db.ListInventoryMovementsParams{
+		ProductID: product.ID,
+		PageLimit: 1,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, string(db.MovementReturn), movements[0].Kind)
+
+	// Returning the last unit refunds the rest of the payment.
+	second, err := testStore.RequestReturnTx(context.Background(), db.RequestReturnTxParams{
+		OrderID: placed.Order.ID,
+		UserID:  user.ID,
+		Reason:  "Changed my mind",
+		Items:   []db.ReturnItemParams{{OrderItemID: orderItemID, Quantity: 1}},
+	})
+	assert.NoError(t, err)
+	_, err = testStore.ApproveReturnTx(context.Background(), db.ReviewReturnTxParams{
+		ReturnID: second.Return.ID,
+		Refund:   refundFn,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{1000, 1000}, refunds)
+
+	order, err := testQuery.GetOrderByID(context.Background(), placed.Order.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, string(db.OrderStatusRefunded), order.Status)
+	paid, err := testQuery.GetCapturedOrderPayment(context.Background(), placed.Order.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, payment.StatusRefunded, paid.Status)
+}
+
+func TestRejectReturnTx(t *testing.T) {
+	user := createRandomUser(t)
+	product := createRandomProduct(t, 5, 5)
+	placed := createDeliveredOrder(t, user, product, 1)
+	items := []db.ReturnItemParams{{OrderItemID: placed.OrderItems[0].ID, Quantity: 1}}
+
+	requested, err := testStore.RequestReturnTx(context.Background(), db.RequestReturnTxParams{
+		OrderID: placed.Order.ID,
+		UserID:  user.ID,
+		Reason:  "Wrong size",
+		Items:   items,
+	})
+	assert.NoError(t, err)
+
+	rejected, err := testStore.RejectReturnTx(context.Background(), db.ReviewReturnTxParams{ReturnID: requested.Return.ID})
+	assert.NoError(t, err)
+	assert.Equal(t, string(db.ReturnRejected), rejected.Return.Status)
+
+	// Rejected units can be asked for again, but not by another user.
+	_, err = testStore.RequestReturnTx(context.Background(), db.RequestReturnTxParams{
+		OrderID: placed.Order.ID,
+		UserID:  user.ID,
+		Reason:  "Wrong size, really",
+		Items:   items,
+	})
+	assert.NoError(t, err)
+	_, err = testStore.RequestReturnTx(context.Background(), db.RequestReturnTxParams{
+		OrderID: placed.Order.ID,
+		UserID:  createRandomUser(t).ID,
+		Reason:  "Not mine",
+		Items:   items,
+	})
+	assert.ErrorIs(t, err, db.ErrOrderNotFound)
+}
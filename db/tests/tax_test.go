@@ -0,0 +1,106 @@
+package db_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	db "github.com/adedaryorh/ecommerceapi/db/sqlc"
+	"github.com/adedaryorh/ecommerceapi/tax"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlaceOrderTxTax(t *testing.T) {
+	ctx := context.Background()
+	rates := tax.NewTableCalculator([]tax.TableRate{
+		{Country: "US", TaxClass: tax.DefaultClass, Rate: 5000},
+		{Country: "US", State: "NY", TaxClass: tax.DefaultClass, Rate: 8875},
+		{Country: "US", State: "NY", TaxClass: "clothing", Rate: 0},
+	})
+
+	book := createRandomProduct(t, 40, 5)
+	shirt := createRandomProduct(t, 20, 5)
+	shirt, err := testQuery.PatchProduct(ctx, db.PatchProductParams{
+		ID:       shirt.ID,
+		TaxClass: sql.NullString{String: "clothing", Valid: true},
+	})
+	assert.NoError(t, err)
+
+	user := createRandomUser(t)
+	items := []db.PlaceOrderItem{{ProductID: book.ID, Quantity: 1}, {ProductID: shirt.ID, Quantity: 1}}
+
+	// The state rate applies over the country's, and clothing is exempt.
+	placed, err := testStore.PlaceOrderTx(ctx, db.PlaceOrderTxParams{
+		UserID:     user.ID,
+		Items:      items,
+		Tax:        rates,
+		TaxAddress: tax.Address{Country: "us", State: "ny"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "3.55", placed.Order.TaxAmount)
+	assert.Equal(t, "63.55", placed.Order.TotalAmount)
+	assert.Equal(t, "US", placed.Order.TaxCountry.String)
+	assert.Equal(t, "NY", placed.Order.TaxState.String)
+	assert.Len(t, placed.TaxLines, 2)
+
+	stored, err := testQuery.ListOrderTaxLines(ctx, placed.Order.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, placed.TaxLines, stored)
+	assert.Equal(t, "US-NY", stored[0].Jurisdiction)
+	assert.Equal(t, "8.875", stored[0].Rate)
+
+	// Tax is charged on what is paid after the coupon's share of each line.
+	// Texas has no rate of its own and the country has none for clothing.
+	coupon := createRandomCoupon(t, db.CreateCouponParams{
+		Kind:  string(db.CouponFixed),
+		Value: "10.00",
+	}, db.CouponScope{ProductIDs: []int64{book.ID}})
+	placed, err = testStore.PlaceOrderTx(ctx, db.PlaceOrderTxParams{
+		UserID:     user.ID,
+		Items:      items,
+		CouponCode: coupon.Code,
+		Tax:        rates,
+		TaxAddress: tax.Address{Country: "US", State: "TX"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "10.00", placed.Order.DiscountAmount)
+	assert.Equal(t, "1.50", placed.Order.TaxAmount)
+	assert.Equal(t, "51.50", placed.Order.TotalAmount)
+	assert.Len(t, placed.TaxLines, 1)
+	assert.Equal(t, "US", placed.TaxLines[0].Jurisdiction)
+	assert.Equal(t, "30.00", placed.TaxLines[0].TaxableAmount)
+
+	_, err = testStore.PlaceOrderTx(ctx, db.PlaceOrderTxParams{UserID: user.ID, Items: items, Tax: rates})
+	assert.ErrorIs(t, err, tax.ErrAddressRequired)
+}
+
+func TestQuoteOrderTx(t *testing.T) {
+	ctx := context.Background()
+	rates := tax.NewTableCalculator([]tax.TableRate{{Country: "GB", TaxClass: tax.DefaultClass, Rate: 20000}})
+	product := createRandomProduct(t, 15, 2)
+	user := createRandomUser(t)
+
+	quote, err := testStore.QuoteOrderTx(ctx, db.PlaceOrderTxParams{
+		UserID:     user.ID,
+		Items:      []db.PlaceOrderItem{{ProductID: product.ID, Quantity: 2}},
+		Tax:        rates,
+		TaxAddress: tax.Address{Country: "GB"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "6.00", quote.Order.TaxAmount)
+	assert.Equal(t, "36.00", quote.Order.TotalAmount)
+
+	// Nothing was kept, so the whole stock is still available.
+	_, err = testQuery.GetOrderByID(ctx, quote.Order.ID)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+	reserved, err := testQuery.GetReservedProductStock(ctx, product.ID)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 0, reserved)
+
+	_, err = testStore.QuoteOrderTx(ctx, db.PlaceOrderTxParams{
+		UserID: user.ID,
+		Items:  []db.PlaceOrderItem{{ProductID: product.ID, Quantity: 3}},
+	})
+	var noStock *db.InsufficientStockError
+	assert.ErrorAs(t, err, &noStock)
+}
@@ -12,20 +12,27 @@ import (
 	"time"
 )
 
-func clean_up() {
-	err := testQuery.DeleteAllUsers(context.Background())
+// clean_up deletes only the given users, since users created by other
+// tests may have orders that keep them from being deleted.
+func clean_up(users ...db.User) {
+	ids := make([]int64, 0, len(users))
+	for _, user := range users {
+		ids = append(ids, user.ID)
+	}
+	err := testQuery.DeleteUsers(context.Background(), ids)
 	if err != nil {
 		log.Fatal(err)
 	}
 }
 
 func TestDeleteUser(t *testing.T) {
-	defer clean_up()
 	user := createRandomUser(t)
+	defer clean_up(user)
 
-	err := testQuery.DeleteUser(context.Background(), user.ID)
+	archived, err := testQuery.ArchiveUser(context.Background(), user.ID)
 
 	assert.NoError(t, err)
+	assert.Equal(t, int64(1), archived)
 
 	newUser, err := testQuery.GetUserByID(context.Background(), user.ID)
 	assert.Error(t, err)
@@ -34,18 +41,23 @@ func TestDeleteUser(t *testing.T) {
 }
 
 func TestListUser(t *testing.T) {
-	defer clean_up()
 	//go_routine call
 	var wg sync.WaitGroup
+	var mu sync.Mutex
+	created := []db.User{}
 	for i := 0; i < 30; i++ {
 		wg.Add(1)
 		//concurrency
 		go func() {
 			defer wg.Done()
-			createRandomUser(t)
+			user := createRandomUser(t)
+			mu.Lock()
+			created = append(created, user)
+			mu.Unlock()
 		}()
 	}
 	wg.Wait()
+	defer clean_up(created...)
 	arg := db.ListUserParams{
 		Offset: 0,
 		Limit:  30,
@@ -57,8 +69,8 @@ func TestListUser(t *testing.T) {
 }
 
 func TestGetUserByEmail(t *testing.T) {
-	defer clean_up()
 	user := createRandomUser(t)
+	defer clean_up(user)
 
 	newUser, err := testQuery.GetUserByEmail(context.Background(), user.Email)
 	assert.NoError(t, err)
@@ -94,9 +106,8 @@ func createRandomUser(t *testing.T) db.User {
 }
 
 func TestCreateUser(t *testing.T) {
-	defer clean_up()
-
 	user1 := createRandomUser(t)
+	defer clean_up(user1)
 
 	user2, err := testQuery.CreateUser(context.Background(), db.CreateUserParams{
 		Email:          user1.Email,
@@ -108,8 +119,8 @@ func TestCreateUser(t *testing.T) {
 }
 
 func TestUpdateUser(t *testing.T) {
-	defer clean_up()
 	user := createRandomUser(t)
+	defer clean_up(user)
 
 	newPassword, err := utils.GenerateHashedPassword(utils.RandomString(8))
 	if err != nil {
@@ -128,3 +139,20 @@ func TestUpdateUser(t *testing.T) {
 	assert.Equal(t, user.Email, newUser.Email)
 	assert.WithinDuration(t, user.UpdatedAt, time.Now(), 2*time.Second)
 }
+
+func TestRestoreUser(t *testing.T) {
+	user := createRandomUser(t)
+	defer clean_up(user)
+
+	_, err := testQuery.ArchiveUser(context.Background(), user.ID)
+	assert.NoError(t, err)
+	_, err = testQuery.GetUserByEmail(context.Background(), user.Email)
+	assert.Error(t, err)
+
+	restored, err := testQuery.RestoreUser(context.Background(), user.ID)
+	assert.NoError(t, err)
+	assert.False(t, restored.DeletedAt.Valid)
+
+	_, err = testQuery.GetUserByEmail(context.Background(), user.Email)
+	assert.NoError(t, err)
+}
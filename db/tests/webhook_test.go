@@ -0,0 +1,247 @@
+package db_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	db "github.com/adedaryorh/ecommerceapi/db/sqlc"
+	"github.com/adedaryorh/ecommerceapi/payment"
+	"github.com/adedaryorh/ecommerceapi/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func processEvent(t *testing.T, event payment.WebhookEvent) (db.ProcessPaymentEventTxResult, error) {
+	payload, err := json.Marshal(event)
+	assert.NoError(t, err)
+	return testStore.ProcessPaymentEventTx(context.Background(), db.ProcessPaymentEventTxParams{
+		Provider: "mock",
+		Event:    event,
+		Payload:  payload,
+	})
+}
+
+func TestProcessPaymentEventTx(t *testing.T) {
+	user := createRandomUser(t)
+	product := createRandomProduct(t, 3, 5)
+	placed, err := testStore.PlaceOrderTx(context.Background(), db.PlaceOrderTxParams{
+		UserID: user.ID,
+		Items:  []db.PlaceOrderItem{{ProductID: product.ID, Quantity: 1}},
+	})
+	assert.NoError(t, err)
+
+	captured := payment.WebhookEvent{
+		ID:   "evt_" + utils.RandomString(12),
+		Type: payment.EventPaymentCaptured,
+		Data: payment.WebhookEventData{
+			OrderID:       placed.Order.ID,
+			TransactionID: "mock_ch_" + utils.RandomString(12),
+			Amount:        placed.Order.TotalAmount,
+		},
+	}
+	result, err := processEvent(t, captured)
+	assert.NoError(t, err)
+	assert.False(t, result.Duplicate)
+
+	order, err := testQuery.GetOrderByID(context.Background(), placed.Order.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, string(db.OrderStatusPaid), order.Status)
+
+	// A redelivered event is recorded once and not applied again.
+	result, err = processEvent(t, captured)
+	assert.NoError(t, err)
+	assert.True(t, result.Duplicate)
+
+	refunded := payment.WebhookEvent{
+		ID:   "evt_" + utils.RandomString(12),
+		Type: payment.EventPaymentRefunded,
+		Data: payment.WebhookEventData{
+			OrderID:       placed.Order.ID,
+			TransactionID: captured.Data.TransactionID,
+			Amount:        placed.Order.TotalAmount,
+			RefundID:      "mock_re_" + utils.RandomString(12),
+		},
+	}
+	_, err = processEvent(t, refunded)
+	assert.NoError(t, err)
+
+	order, err = testQuery.GetOrderByID(context.Background(), placed.Order.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, string(db.OrderStatusRefunded), order.Status)
+
+	// A refund of a payment we never recorded is refused.
+	unknown := refunded
+	unknown.ID = "evt_" + utils.RandomString(12)
+	unknown.Data.TransactionID = "mock_ch_" + utils.RandomString(12)
+	_, err = processEvent(t, unknown)
+	assert.ErrorIs(t, err, db.ErrPaymentNotFound)
+}
+
+func TestProcessPaymentEventTxLateCapture(t *testing.T) {
+	user := createRandomUser(t)
+	product := createRandomProduct(t, 3, 5)
+	placed, err := testStore.PlaceOrderTx(context.Background(), db.PlaceOrderTxParams{
+		UserID: user.ID,
+		Items:  []db.PlaceOrderItem{{ProductID: product.ID, Quantity: 1}},
+	})
+	assert.NoError(t, err)
+	_, err = testStore.CancelOrderTx(context.Background(), db.CancelOrderTxParams{OrderID: placed.Order.ID})
+	assert.NoError(t, err)
+
+	captured := payment.WebhookEvent{
+		ID:   "evt_" + utils.RandomString(12),
+		Type: payment.EventPaymentCaptured,
+		Data: payment.WebhookEventData{
+			OrderID:       placed.Order.ID,
+			TransactionID: "mock_ch_" + utils.RandomString(12),
+			Amount:        placed.Order.TotalAmount,
+		},
+	}
+	payload, err := json.Marshal(captured)
+	assert.NoError(t, err)
+	refunds := []int64{}
+	_, err = testStore.ProcessPaymentEventTx(context.Background(), db.ProcessPaymentEventTxParams{
+		Provider: "mock",
+		Event:    captured,
+		Payload:  payload,
+		Refund: func(ctx context.Context, paid db.Payment, amount int64) (string, error) {
+			refunds = append(refunds, amount)
+			return "mock_re_" + utils.RandomString(12), nil
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{300}, refunds)
+
+	// The money is recorded and handed back; the order stays cancelled.
+	payments, err := testQuery.ListOrderPayments(context.Background(), placed.Order.ID)
+	assert.NoError(t, err)
+	assert.Len(t, payments, 1)
+	assert.Equal(t, payment.StatusRefunded, payments[0].Status)
+	order, err := testQuery.GetOrderByID(context.Background(), placed.Order.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, string(db.OrderStatusCancelled), order.Status)
+}
+
+func TestProcessPaymentEventTxConflictingCapture(t *testing.T) {
+	user := createRandomUser(t)
+	product := createRandomProduct(t, 3, 5)
+	placed, err := testStore.PlaceOrderTx(context.Background(), db.PlaceOrderTxParams{
+		UserID: user.ID,
+		Items:  []db.PlaceOrderItem{{ProductID: product.ID, Quantity: 1}},
+	})
+	assert.NoError(t, err)
+
+	refunds := []int64{}
+	process := func(event payment.WebhookEvent) error {
+		payload, err := json.Marshal(event)
+		assert.NoError(t, err)
+		_, err = testStore.ProcessPaymentEventTx(context.Background(), db.ProcessPaymentEventTxParams{
+			Provider: "mock",
+			Event:    event,
+			Payload:  payload,
+			Refund: func(ctx context.Context, paid db.Payment, amount int64) (string, error) {
+				refunds = append(refunds, amount)
+				return "mock_re_" + utils.RandomString(12), nil
+			},
+		})
+		return err
+	}
+	capture := func(amount string) payment.WebhookEvent {
+		return payment.WebhookEvent{
+			ID:   "evt_" + utils.RandomString(12),
+			Type: payment.EventPaymentCaptured,
+			Data: payment.WebhookEventData{
+				OrderID:       placed.Order.ID,
+				TransactionID: "mock_ch_" + utils.RandomString(12),
+				Amount:        amount,
+			},
+		}
+	}
+
+	// A capture for the wrong amount is handed back; the order stays pending.
+	assert.NoError(t, process(capture("1.00")))
+	assert.Equal(t, []int64{100}, refunds)
+	order, err := testQuery.GetOrderByID(context.Background(), placed.Order.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, string(db.OrderStatusPending), order.Status)
+
+	first := capture(placed.Order.TotalAmount)
+	assert.NoError(t, process(first))
+
+	// A second capture for an order already paid is handed back too.
+	assert.NoError(t, process(capture(placed.Order.TotalAmount)))
+	assert.Equal(t, []int64{100, 300}, refunds)
+
+	order, err = testQuery.GetOrderByID(context.Background(), placed.Order.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, string(db.OrderStatusPaid), order.Status)
+	payments, err := testQuery.ListOrderPayments(context.Background(), placed.Order.ID)
+	assert.NoError(t, err)
+	assert.Len(t, payments, 3)
+	for _, paid := range payments {
+		if paid.ProviderTransactionID == first.Data.TransactionID {
+			assert.Equal(t, payment.StatusCaptured, paid.Status)
+		} else {
+			assert.Equal(t, payment.StatusRefunded, paid.Status)
+		}
+	}
+}
+
+func TestProcessPaymentEventTxRefundLimits(t *testing.T) {
+	user := createRandomUser(t)
+	product := createRandomProduct(t, 3, 5)
+	placed, err := testStore.PlaceOrderTx(context.Background(), db.PlaceOrderTxParams{
+		UserID: user.ID,
+		Items:  []db.PlaceOrderItem{{ProductID: product.ID, Quantity: 1}},
+	})
+	assert.NoError(t, err)
+	transactionID := "mock_ch_" + utils.RandomString(12)
+	_, err = processEvent(t, payment.WebhookEvent{
+		ID:   "evt_" + utils.RandomString(12),
+		Type: payment.EventPaymentCaptured,
+		Data: payment.WebhookEventData{
+			OrderID:       placed.Order.ID,
+			TransactionID: transactionID,
+			Amount:        placed.Order.TotalAmount,
+		},
+	})
+	assert.NoError(t, err)
+
+	refund := func(amount string) payment.WebhookEvent {
+		return payment.WebhookEvent{
+			ID:   "evt_" + utils.RandomString(12),
+			Type: payment.EventPaymentRefunded,
+			Data: payment.WebhookEventData{
+				OrderID:       placed.Order.ID,
+				TransactionID: transactionID,
+				Amount:        amount,
+				RefundID:      "mock_re_" + utils.RandomString(12),
+			},
+		}
+	}
+
+	for _, amount := range []string{"0.00", "-1.00"} {
+		_, err = processEvent(t, refund(amount))
+		assert.ErrorIs(t, err, db.ErrInvalidEventAmount, amount)
+	}
+	_, err = processEvent(t, refund("3.01"))
+	assert.ErrorIs(t, err, db.ErrRefundExceedsPayment)
+
+	// Partial refunds add up to the amount captured and no further.
+	partial := refund("2.00")
+	_, err = processEvent(t, partial)
+	assert.NoError(t, err)
+	_, err = processEvent(t, refund("1.01"))
+	assert.ErrorIs(t, err, db.ErrRefundExceedsPayment)
+
+	// The same refund reported again under a new event is not counted twice.
+	again := partial
+	again.ID = "evt_" + utils.RandomString(12)
+	_, err = processEvent(t, again)
+	assert.NoError(t, err)
+	payments, err := testQuery.ListOrderPayments(context.Background(), placed.Order.ID)
+	assert.NoError(t, err)
+	refunded, err := testQuery.GetRefundedAmount(context.Background(), payments[0].ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "2.00", refunded)
+}
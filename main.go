@@ -1,19 +1,182 @@
 package main
 
 import (
+	"context"
+	"database/sql"
+	"flag"
 	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+
 	api "github.com/adedaryorh/ecommerceapi/api"
+	"github.com/adedaryorh/ecommerceapi/db/migrations"
 	_ "github.com/adedaryorh/ecommerceapi/docs"
+	"github.com/adedaryorh/ecommerceapi/payment"
+	"github.com/adedaryorh/ecommerceapi/utils"
 	_ "github.com/swaggo/files"
 	_ "github.com/swaggo/gin-swagger"
 )
 
+const migrateUsage = `usage: ecommerceapi migrate [-database url] <command>
+
+commands:
+  up         apply all pending migrations
+  down [N]   revert the last N migrations (default 1)
+  status     show the applied version and pending migrations
+  force V    record version V as applied without running SQL (-1 for none)`
+
+const signWebhookUsage = `usage: ecommerceapi sign-webhook [-secret s] [file]
+
+Prints the signature header for the payment event in file, or on stdin,
+signed now with PAYMENT_WEBHOOK_SECRET unless -secret is given.`
+
 // @title Ecommerca Backend Application
 // @version 1.0
 // @description This is my first version API for an ecommerce simple model.
 // @BasePath /
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrate(os.Args[2:]); err != nil {
+			fmt.Fprintln(os.Stderr, "migrate:", err)
+			os.Exit(1)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "sign-webhook" {
+		if err := runSignWebhook(os.Args[2:]); err != nil {
+			fmt.Fprintln(os.Stderr, "sign-webhook:", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	fmt.Println("Hello welcome to world of ecommerce ")
 	server := api.NewServer(".")
 	server.Start(8000)
 }
+
+// runMigrate applies the embedded migrations to the database the server
+// uses, or to the one given with -database.
+func runMigrate(args []string) error {
+	flags := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	flags.Usage = func() { fmt.Fprintln(flags.Output(), migrateUsage) }
+	database := flags.String("database", "", "database URL (defaults to DB_SOURCE_LIVE)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return fmt.Errorf("missing command")
+	}
+
+	config, err := utils.LoadConfig(".")
+	if err != nil {
+		return fmt.Errorf("loading config: %w", err)
+	}
+	if *database == "" {
+		*database = config.DB_source_live
+	}
+	conn, err := sql.Open(config.DBdriver, *database)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	migrator, err := migrations.NewMigrator(conn)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	command, rest := flags.Arg(0), flags.Args()[1:]
+	switch {
+	case command == "up" && len(rest) == 0:
+		applied, err := migrator.Up(ctx)
+		fmt.Printf("applied %d migration(s)\n", applied)
+		return err
+	case command == "down" && len(rest) <= 1:
+		steps := 1
+		if len(rest) == 1 {
+			if steps, err = strconv.Atoi(rest[0]); err != nil || steps < 1 {
+				return fmt.Errorf("down expects a positive number of steps")
+			}
+		}
+		reverted, err := migrator.Down(ctx, steps)
+		fmt.Printf("reverted %d migration(s)\n", reverted)
+		return err
+	case command == "force" && len(rest) == 1:
+		version, err := strconv.ParseInt(rest[0], 10, 64)
+		if err != nil {
+			return fmt.Errorf("force expects a migration version")
+		}
+		return migrator.Force(ctx, version)
+	case command == "status" && len(rest) == 0:
+		status, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		if status.Version == migrations.NoVersion {
+			fmt.Println("version: none")
+		} else if status.Dirty {
+			fmt.Printf("version: %d (dirty)\n", status.Version)
+		} else {
+			fmt.Printf("version: %d\n", status.Version)
+		}
+		for _, migration := range migrator.Migrations() {
+			state := "pending"
+			if migration.Version <= status.Version {
+				state = "applied"
+			}
+			fmt.Printf("  %-8s %d_%s\n", state, migration.Version, migration.Name)
+		}
+		return nil
+	}
+
+	flags.Usage()
+	return fmt.Errorf("invalid command %q", command)
+}
+
+// runSignWebhook signs a payment event the way the provider would, so the
+// webhook endpoint can be exercised locally with curl.
+func runSignWebhook(args []string) error {
+	flags := flag.NewFlagSet("sign-webhook", flag.ContinueOnError)
+	flags.Usage = func() { fmt.Fprintln(flags.Output(), signWebhookUsage) }
+	secret := flags.String("secret", "", "webhook secret (defaults to PAYMENT_WEBHOOK_SECRET)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() > 1 {
+		flags.Usage()
+		return fmt.Errorf("too many arguments")
+	}
+
+	if *secret == "" {
+		config, err := utils.LoadConfig(".")
+		if err != nil {
+			return fmt.Errorf("loading config: %w", err)
+		}
+		*secret = config.Payment_webhook_secret
+	}
+	if *secret == "" {
+		return fmt.Errorf("no webhook secret configured")
+	}
+
+	input := io.Reader(os.Stdin)
+	if flags.NArg() == 1 {
+		file, err := os.Open(flags.Arg(0))
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		input = file
+	}
+	body, err := io.ReadAll(input)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%s: %s\n", payment.SignatureHeader, payment.SignWebhook(*secret, time.Now(), body))
+	return nil
+}
@@ -0,0 +1,55 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"os"
+	"sync"
+)
+
+// LogNotifier writes events to the standard logger.
+type LogNotifier struct{}
+
+func NewLogNotifier() *LogNotifier {
+	return &LogNotifier{}
+}
+
+func (n *LogNotifier) Notify(ctx context.Context, event Event) error {
+	data, err := json.Marshal(event.Data)
+	if err != nil {
+		return err
+	}
+	log.Printf("[%s] %s %s", event.Type, event.Message, data)
+	return nil
+}
+
+// FileNotifier appends events to a file as JSON lines, for collection by a
+// log shipper or another process.
+type FileNotifier struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+func NewFileNotifier(path string) (*FileNotifier, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	return &FileNotifier{file: file}, nil
+}
+
+func (n *FileNotifier) Notify(ctx context.Context, event Event) error {
+	line, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	_, err = n.file.Write(append(line, '\n'))
+	return err
+}
+
+func (n *FileNotifier) Close() error {
+	return n.file.Close()
+}
@@ -0,0 +1,24 @@
+package notify
+
+import (
+	"context"
+	"time"
+)
+
+// EventLowStock is emitted when a sale drops a product's stock below its
+// reorder threshold.
+const EventLowStock = "inventory.low_stock"
+
+// Event is something operators should hear about, such as a product
+// running low on stock.
+type Event struct {
+	Type    string                 `json:"type"`
+	Time    time.Time              `json:"time"`
+	Message string                 `json:"message"`
+	Data    map[string]interface{} `json:"data,omitempty"`
+}
+
+// Notifier delivers events to operators.
+type Notifier interface {
+	Notify(ctx context.Context, event Event) error
+}
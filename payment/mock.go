@@ -0,0 +1,77 @@
+package payment
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// Tokens the mock provider declines; every other token is approved.
+const (
+	TokenDeclined          = "tok_declined"
+	TokenInsufficientFunds = "tok_insufficient_funds"
+)
+
+const (
+	mockChargePrefix = "mock_ch_"
+	mockRefundPrefix = "mock_re_"
+)
+
+// MockProvider is an in-process gateway for development and tests. It keeps
+// no state: transaction IDs are derived from the request, so the same
+// request always yields the same transaction and outcome.
+type MockProvider struct{}
+
+func NewMockProvider() *MockProvider {
+	return &MockProvider{}
+}
+
+func (m *MockProvider) Name() string {
+	return "mock"
+}
+
+func (m *MockProvider) Authorize(ctx context.Context, req AuthorizeRequest) (Transaction, error) {
+	if req.Amount <= 0 {
+		return Transaction{}, ErrInvalidAmount
+	}
+
+	id := mockID(mockChargePrefix, req.OrderID, req.Amount, req.Token)
+	switch req.Token {
+	case TokenDeclined:
+		return Transaction{}, &DeclinedError{TransactionID: id, Reason: "card_declined"}
+	case TokenInsufficientFunds:
+		return Transaction{}, &DeclinedError{TransactionID: id, Reason: "insufficient_funds"}
+	}
+	return Transaction{ID: id, Status: StatusAuthorized, Amount: req.Amount}, nil
+}
+
+func (m *MockProvider) Capture(ctx context.Context, transactionID string, amount int64) (Transaction, error) {
+	if !strings.HasPrefix(transactionID, mockChargePrefix) {
+		return Transaction{}, ErrUnknownTransaction
+	}
+	if amount <= 0 {
+		return Transaction{}, ErrInvalidAmount
+	}
+	return Transaction{ID: transactionID, Status: StatusCaptured, Amount: amount}, nil
+}
+
+func (m *MockProvider) Refund(ctx context.Context, req RefundRequest) (Transaction, error) {
+	if !strings.HasPrefix(req.TransactionID, mockChargePrefix) {
+		return Transaction{}, ErrUnknownTransaction
+	}
+	if req.Amount <= 0 {
+		return Transaction{}, ErrInvalidAmount
+	}
+	id := mockID(mockRefundPrefix, req.TransactionID, req.Amount, req.Reference)
+	return Transaction{ID: id, Status: StatusRefunded, Amount: req.Amount}, nil
+}
+
+func mockID(prefix string, parts ...interface{}) string {
+	h := sha256.New()
+	for _, part := range parts {
+		fmt.Fprintf(h, "%v\x00", part)
+	}
+	return prefix + hex.EncodeToString(h.Sum(nil)[:12])
+}
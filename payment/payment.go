@@ -0,0 +1,102 @@
+// Package payment talks to payment gateways. Amounts are in cents.
+package payment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Transaction statuses reported by providers and stored in payments.status.
+const (
+	StatusAuthorized = "authorized"
+	StatusCaptured   = "captured"
+	StatusRefunded   = "refunded"
+	StatusFailed     = "failed"
+)
+
+var (
+	ErrInvalidAmount      = errors.New("payment amount must be positive")
+	ErrUnknownTransaction = errors.New("unknown payment transaction")
+)
+
+// DeclinedError is returned when the provider refuses a payment. The
+// declined attempt still has a transaction ID so it can be recorded.
+type DeclinedError struct {
+	TransactionID string
+	Reason        string
+}
+
+func (e *DeclinedError) Error() string {
+	return fmt.Sprintf("payment declined: %s", e.Reason)
+}
+
+// AuthorizeRequest asks the provider to hold Amount on the payment method
+// identified by Token for an order.
+type AuthorizeRequest struct {
+	OrderID int64
+	Amount  int64
+	Token   string
+}
+
+// RefundRequest returns Amount of a captured transaction. Reference
+// identifies the refund on our side, so retrying the same refund is not
+// paid out twice.
+type RefundRequest struct {
+	TransactionID string
+	Amount        int64
+	Reference     string
+}
+
+// Transaction is the provider's view of a payment or refund.
+type Transaction struct {
+	ID     string
+	Status string
+	Amount int64
+}
+
+// PaymentProvider authorizes, captures and refunds payments. Providers must
+// return the same transaction for a repeated request so retries are safe.
+type PaymentProvider interface {
+	Name() string
+	Authorize(ctx context.Context, req AuthorizeRequest) (Transaction, error)
+	Capture(ctx context.Context, transactionID string, amount int64) (Transaction, error)
+	Refund(ctx context.Context, req RefundRequest) (Transaction, error)
+}
+
+// RequestTimeout bounds each call to a provider made through NewProvider.
+const RequestTimeout = 30 * time.Second
+
+// NewProvider returns the provider configured by name.
+func NewProvider(name string) (PaymentProvider, error) {
+	switch name {
+	case "", "mock":
+		return timeoutProvider{NewMockProvider()}, nil
+	}
+	return nil, fmt.Errorf("unknown payment provider %q", name)
+}
+
+// timeoutProvider gives up on a provider call after RequestTimeout, so a
+// hanging gateway cannot hold a request open indefinitely.
+type timeoutProvider struct {
+	PaymentProvider
+}
+
+func (p timeoutProvider) Authorize(ctx context.Context, req AuthorizeRequest) (Transaction, error) {
+	ctx, cancel := context.WithTimeout(ctx, RequestTimeout)
+	defer cancel()
+	return p.PaymentProvider.Authorize(ctx, req)
+}
+
+func (p timeoutProvider) Capture(ctx context.Context, transactionID string, amount int64) (Transaction, error) {
+	ctx, cancel := context.WithTimeout(ctx, RequestTimeout)
+	defer cancel()
+	return p.PaymentProvider.Capture(ctx, transactionID, amount)
+}
+
+func (p timeoutProvider) Refund(ctx context.Context, req RefundRequest) (Transaction, error) {
+	ctx, cancel := context.WithTimeout(ctx, RequestTimeout)
+	defer cancel()
+	return p.PaymentProvider.Refund(ctx, req)
+}
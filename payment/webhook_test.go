@@ -0,0 +1,53 @@
+package payment
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerifyWebhook(t *testing.T) {
+	secret := "whsec_test"
+	now := time.Unix(1_700_000_000, 0)
+	tolerance := 5 * time.Minute
+	body := []byte(`{"id":"evt_1","type":"payment.captured","data":{"order_id":7,"transaction_id":"mock_ch_1","amount":"12.50"}}`)
+
+	event, err := VerifyWebhook(secret, SignWebhook(secret, now, body), body, tolerance, now)
+	assert.NoError(t, err)
+	assert.Equal(t, "evt_1", event.ID)
+	assert.Equal(t, EventPaymentCaptured, event.Type)
+	assert.Equal(t, int64(7), event.Data.OrderID)
+	assert.Equal(t, "12.50", event.Data.Amount)
+
+	// A signature made with another secret or over another body is refused.
+	_, err = VerifyWebhook(secret, SignWebhook("whsec_other", now, body), body, tolerance, now)
+	assert.ErrorIs(t, err, ErrInvalidSignature)
+	_, err = VerifyWebhook(secret, SignWebhook(secret, now, body), append(body, ' '), tolerance, now)
+	assert.ErrorIs(t, err, ErrInvalidSignature)
+
+	// During a secret rotation any one matching v1 value is enough.
+	old := SignWebhook("whsec_old", now, body)
+	current := SignWebhook(secret, now, body)
+	header := old + "," + current[len("t=1700000000,"):]
+	_, err = VerifyWebhook(secret, header, body, tolerance, now)
+	assert.NoError(t, err)
+
+	// Signatures too far in the past or the future are refused.
+	_, err = VerifyWebhook(secret, SignWebhook(secret, now.Add(-tolerance-time.Second), body), body, tolerance, now)
+	assert.ErrorIs(t, err, ErrStaleWebhook)
+	_, err = VerifyWebhook(secret, SignWebhook(secret, now.Add(tolerance+time.Second), body), body, tolerance, now)
+	assert.ErrorIs(t, err, ErrStaleWebhook)
+	_, err = VerifyWebhook(secret, SignWebhook(secret, now.Add(-tolerance), body), body, tolerance, now)
+	assert.NoError(t, err)
+
+	// Without a configured secret nothing verifies.
+	_, err = VerifyWebhook("", SignWebhook("", now, body), body, tolerance, now)
+	assert.ErrorIs(t, err, ErrInvalidSignature)
+
+	// Headers without a timestamp or signature are refused.
+	_, err = VerifyWebhook(secret, "v1=abc", body, tolerance, now)
+	assert.ErrorIs(t, err, ErrInvalidSignature)
+	_, err = VerifyWebhook(secret, "t=1700000000", body, tolerance, now)
+	assert.ErrorIs(t, err, ErrInvalidSignature)
+}
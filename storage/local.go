@@ -0,0 +1,79 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// LocalStorage keeps files on the local filesystem under root and expects
+// them to be served from baseURL.
+type LocalStorage struct {
+	root    string
+	baseURL string
+}
+
+func NewLocalStorage(root, baseURL string) (*LocalStorage, error) {
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return nil, err
+	}
+	return &LocalStorage{root: root, baseURL: strings.TrimSuffix(baseURL, "/")}, nil
+}
+
+// path resolves key inside root, rejecting keys that would escape it.
+func (s *LocalStorage) path(key string) (string, error) {
+	clean := path.Clean("/" + key)
+	if clean == "/" || clean != "/"+key {
+		return "", ErrInvalidKey
+	}
+	return filepath.Join(s.root, filepath.FromSlash(clean)), nil
+}
+
+// Save writes to a temporary file first so readers never see a partial upload.
+func (s *LocalStorage) Save(ctx context.Context, key string, r io.Reader) error {
+	dest, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(dest), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), dest)
+}
+
+func (s *LocalStorage) Delete(ctx context.Context, key string) error {
+	dest, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(dest); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func (s *LocalStorage) URL(key string) string {
+	return s.baseURL + "/" + key
+}
+
+// Root returns the directory files are stored in.
+func (s *LocalStorage) Root() string {
+	return s.root
+}
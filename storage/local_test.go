@@ -0,0 +1,50 @@
+package storage
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocalStorageKeys(t *testing.T) {
+	dir := t.TempDir()
+	root := filepath.Join(dir, "uploads")
+	s, err := NewLocalStorage(root, "/uploads/")
+	assert.NoError(t, err)
+
+	ctx := context.Background()
+	err = s.Save(ctx, "products/1/a.png", strings.NewReader("image"))
+	assert.NoError(t, err)
+	data, err := os.ReadFile(filepath.Join(root, "products", "1", "a.png"))
+	assert.NoError(t, err)
+	assert.Equal(t, "image", string(data))
+	assert.Equal(t, "/uploads/products/1/a.png", s.URL("products/1/a.png"))
+
+	// Keys that would leave the root, or are not in canonical form, are refused.
+	for _, key := range []string{
+		"",
+		"/",
+		"../outside.png",
+		"products/../../outside.png",
+		"products/../a.png",
+		"/products/a.png",
+		"products//a.png",
+		"products/./a.png",
+		"products/",
+	} {
+		assert.ErrorIs(t, s.Save(ctx, key, strings.NewReader("x")), ErrInvalidKey, key)
+		assert.ErrorIs(t, s.Delete(ctx, key), ErrInvalidKey, key)
+	}
+	_, err = os.Stat(filepath.Join(dir, "outside.png"))
+	assert.True(t, os.IsNotExist(err))
+
+	assert.NoError(t, s.Delete(ctx, "products/1/a.png"))
+	_, err = os.Stat(filepath.Join(root, "products", "1", "a.png"))
+	assert.True(t, os.IsNotExist(err))
+	// Deleting a missing file is not an error.
+	assert.NoError(t, s.Delete(ctx, "products/1/a.png"))
+}
@@ -0,0 +1,17 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+var ErrInvalidKey = errors.New("invalid storage key")
+
+// Storage persists uploaded files under slash-separated keys and resolves
+// the public URL they are served from.
+type Storage interface {
+	Save(ctx context.Context, key string, r io.Reader) error
+	Delete(ctx context.Context, key string) error
+	URL(key string) string
+}
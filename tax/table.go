@@ -0,0 +1,157 @@
+package tax
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// TableRate is the rate charged on a tax class in a country, or in one of
+// its states when State is set.
+type TableRate struct {
+	Country  string
+	State    string
+	TaxClass string
+	Rate     Rate
+}
+
+type tableKey struct {
+	country  string
+	state    string
+	taxClass string
+}
+
+// TableCalculator charges rates looked up by country, state and tax class.
+// A state's own rate takes precedence over its country's; a tax class with
+// no rate for the address is not taxed.
+type TableCalculator struct {
+	rates map[tableKey]Rate
+}
+
+func NewTableCalculator(rates []TableRate) *TableCalculator {
+	t := &TableCalculator{rates: make(map[tableKey]Rate, len(rates))}
+	for _, rate := range rates {
+		address := Address{Country: rate.Country, State: rate.State}.Normalize()
+		t.rates[tableKey{address.Country, address.State, normalizeClass(rate.TaxClass)}] = rate.Rate
+	}
+	return t
+}
+
+// LoadTable reads rates from a CSV file with the columns country, state,
+// tax_class and rate. The rate is a percentage; state may be left empty for
+// a country-wide rate.
+func LoadTable(path string) (*TableCalculator, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	rates, err := readRates(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return NewTableCalculator(rates), nil
+}
+
+func readRates(r io.Reader) ([]TableRate, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV header: %v", err)
+	}
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, required := range []string{"country", "state", "tax_class", "rate"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("CSV header is missing the %s column", required)
+		}
+	}
+
+	rates := []TableRate{}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("invalid CSV: %v", err)
+		}
+		line, _ := reader.FieldPos(0)
+		field := func(name string) string {
+			return strings.TrimSpace(record[columns[name]])
+		}
+
+		rate, err := ParseRate(field("rate"))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+		if field("country") == "" {
+			return nil, fmt.Errorf("line %d: country is required", line)
+		}
+		rates = append(rates, TableRate{
+			Country:  field("country"),
+			State:    field("state"),
+			TaxClass: field("tax_class"),
+			Rate:     rate,
+		})
+	}
+	return rates, nil
+}
+
+func normalizeClass(taxClass string) string {
+	taxClass = strings.ToLower(strings.TrimSpace(taxClass))
+	if taxClass == "" {
+		return DefaultClass
+	}
+	return taxClass
+}
+
+// lookup returns the rate for a tax class at address and the jurisdiction
+// that sets it, such as "US-CA" or "GB".
+func (t *TableCalculator) lookup(address Address, taxClass string) (Rate, string, bool) {
+	if address.State != "" {
+		if rate, ok := t.rates[tableKey{address.Country, address.State, taxClass}]; ok {
+			return rate, address.Country + "-" + address.State, true
+		}
+	}
+	rate, ok := t.rates[tableKey{address.Country, "", taxClass}]
+	return rate, address.Country, ok
+}
+
+func (t *TableCalculator) Calculate(ctx context.Context, address Address, lines []Line) (Result, error) {
+	result := Result{Lines: []TaxLine{}}
+	if len(t.rates) == 0 {
+		return result, nil
+	}
+	address = address.Normalize()
+	if address.Country == "" {
+		return result, ErrAddressRequired
+	}
+
+	// Tax is rounded per line, as it is shown on the order.
+	for _, line := range lines {
+		taxClass := normalizeClass(line.TaxClass)
+		rate, jurisdiction, ok := t.lookup(address, taxClass)
+		if !ok || line.Amount <= 0 {
+			continue
+		}
+		taxLine := TaxLine{
+			ProductID:    line.ProductID,
+			TaxClass:     taxClass,
+			Jurisdiction: jurisdiction,
+			Rate:         rate,
+			Taxable:      line.Amount,
+			Amount:       rate.Apply(line.Amount),
+		}
+		result.Lines = append(result.Lines, taxLine)
+		result.Total += taxLine.Amount
+	}
+	return result, nil
+}
@@ -0,0 +1,99 @@
+// Package tax works out the sales tax owed on an order. Amounts are in cents.
+package tax
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// DefaultClass is the tax class of products that have not been given one.
+const DefaultClass = "standard"
+
+var ErrAddressRequired = errors.New("a country is required to calculate tax")
+
+// Rate is a tax rate in thousandths of a percent, so 8.875% is 8875.
+type Rate int64
+
+// ParseRate reads a percentage with up to three decimal places, such as
+// "20" or "8.875".
+func ParseRate(s string) (Rate, error) {
+	s = strings.TrimSpace(s)
+	whole, frac, _ := strings.Cut(s, ".")
+	if whole == "" || len(frac) > 3 {
+		return 0, fmt.Errorf("invalid tax rate %q", s)
+	}
+	for len(frac) < 3 {
+		frac += "0"
+	}
+	n, err := strconv.ParseInt(whole+frac, 10, 64)
+	if err != nil || n < 0 || n > 100000 {
+		return 0, fmt.Errorf("invalid tax rate %q", s)
+	}
+	return Rate(n), nil
+}
+
+// String formats the rate as a percentage with three decimal places.
+func (r Rate) String() string {
+	return fmt.Sprintf("%d.%03d", r/1000, r%1000)
+}
+
+// Apply returns the tax on cents, rounded to the nearest cent.
+func (r Rate) Apply(cents int64) int64 {
+	return (cents*int64(r) + 50000) / 100000
+}
+
+// Address is where an order is taxed. Country is an ISO 3166-1 alpha-2
+// code and State, when set, the subdivision code within it.
+type Address struct {
+	Country string
+	State   string
+}
+
+// Normalize upper-cases the address codes so they match rate tables.
+func (a Address) Normalize() Address {
+	return Address{
+		Country: strings.ToUpper(strings.TrimSpace(a.Country)),
+		State:   strings.ToUpper(strings.TrimSpace(a.State)),
+	}
+}
+
+// Line is the taxable amount of one product in an order, after discounts.
+type Line struct {
+	ProductID int64
+	TaxClass  string
+	Amount    int64
+}
+
+// TaxLine is the tax charged on one Line.
+type TaxLine struct {
+	ProductID    int64
+	TaxClass     string
+	Jurisdiction string
+	Rate         Rate
+	Taxable      int64
+	Amount       int64
+}
+
+// Result is the tax on a set of lines. Lines that are not taxed have no
+// TaxLine.
+type Result struct {
+	Lines []TaxLine
+	Total int64
+}
+
+// Calculator works out the tax on an order's lines for an address.
+type Calculator interface {
+	Calculate(ctx context.Context, address Address, lines []Line) (Result, error)
+}
+
+// NewCalculator returns a table calculator using the rates in ratesFile.
+// An empty ratesFile charges no tax.
+func NewCalculator(ratesFile string) (Calculator, error) {
+	if ratesFile == "" {
+		return NewTableCalculator(nil), nil
+	}
+	return LoadTable(ratesFile)
+}
@@ -0,0 +1,103 @@
+package tax
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseRate(t *testing.T) {
+	valid := map[string]Rate{
+		"20":      20000,
+		"8.875":   8875,
+		"7.5":     7500,
+		" 0.25 ":  250,
+		"0":       0,
+		"100.000": 100000,
+	}
+	for s, want := range valid {
+		rate, err := ParseRate(s)
+		assert.NoError(t, err, s)
+		assert.Equal(t, want, rate, s)
+	}
+	assert.Equal(t, "8.875", Rate(8875).String())
+	assert.Equal(t, "20.000", Rate(20000).String())
+
+	for _, s := range []string{"", ".5", "8.8751", "-5", "100.001", "abc", "5%"} {
+		_, err := ParseRate(s)
+		assert.Error(t, err, s)
+	}
+}
+
+func TestRateApply(t *testing.T) {
+	// Tax is rounded to the nearest cent, halves up.
+	assert.Equal(t, int64(89), Rate(8875).Apply(1000))
+	assert.Equal(t, int64(1), Rate(7500).Apply(10))
+	assert.Equal(t, int64(0), Rate(20000).Apply(2))
+	assert.Equal(t, int64(1), Rate(20000).Apply(3))
+	assert.Equal(t, int64(0), Rate(0).Apply(1000))
+}
+
+func TestReadRates(t *testing.T) {
+	rates, err := readRates(strings.NewReader("Country,State,Tax_Class,Rate\nUS, CA, standard, 7.25\nGB,,,20\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, []TableRate{
+		{Country: "US", State: "CA", TaxClass: "standard", Rate: 7250},
+		{Country: "GB", Rate: 20000},
+	}, rates)
+
+	_, err = readRates(strings.NewReader(""))
+	assert.ErrorContains(t, err, "invalid CSV header")
+
+	_, err = readRates(strings.NewReader("country,state,rate\nUS,CA,7\n"))
+	assert.ErrorContains(t, err, "missing the tax_class column")
+
+	_, err = readRates(strings.NewReader("country,state,tax_class,rate\nUS,CA,standard,7\nUS,NY,standard,lots\n"))
+	assert.ErrorContains(t, err, "line 3")
+	assert.ErrorContains(t, err, `invalid tax rate "lots"`)
+
+	_, err = readRates(strings.NewReader("country,state,tax_class,rate\n,CA,standard,7\n"))
+	assert.ErrorContains(t, err, "line 2: country is required")
+
+	_, err = readRates(strings.NewReader("country,state,tax_class,rate\nUS,CA\n"))
+	assert.ErrorContains(t, err, "invalid CSV")
+}
+
+func TestTableCalculator(t *testing.T) {
+	calculator := NewTableCalculator([]TableRate{
+		{Country: "us", TaxClass: "standard", Rate: 5000},
+		{Country: "US", State: "ca", TaxClass: "Standard", Rate: 7250},
+		{Country: "US", TaxClass: "food", Rate: 1000},
+	})
+	lines := []Line{
+		{ProductID: 1, Amount: 1000},
+		{ProductID: 2, TaxClass: "food", Amount: 2000},
+		{ProductID: 3, TaxClass: "exempt", Amount: 3000},
+	}
+
+	// A state's own rate wins over its country's; classes it does not set
+	// fall back to the country.
+	result, err := calculator.Calculate(context.Background(), Address{Country: "us", State: "Ca"}, lines)
+	assert.NoError(t, err)
+	assert.Equal(t, []TaxLine{
+		{ProductID: 1, TaxClass: "standard", Jurisdiction: "US-CA", Rate: 7250, Taxable: 1000, Amount: 73},
+		{ProductID: 2, TaxClass: "food", Jurisdiction: "US", Rate: 1000, Taxable: 2000, Amount: 20},
+	}, result.Lines)
+	assert.Equal(t, int64(93), result.Total)
+
+	// States without a rate of their own are taxed at the country's.
+	result, err = calculator.Calculate(context.Background(), Address{Country: "US", State: "TX"}, lines)
+	assert.NoError(t, err)
+	assert.Equal(t, "US", result.Lines[0].Jurisdiction)
+	assert.Equal(t, int64(70), result.Total)
+
+	_, err = calculator.Calculate(context.Background(), Address{}, lines)
+	assert.ErrorIs(t, err, ErrAddressRequired)
+
+	// Without any rates nothing is taxed and no address is needed.
+	result, err = NewTableCalculator(nil).Calculate(context.Background(), Address{}, lines)
+	assert.NoError(t, err)
+	assert.Empty(t, result.Lines)
+}
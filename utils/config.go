@@ -1,14 +1,27 @@
 package utils
 
 import (
+	"time"
+
 	"github.com/spf13/viper"
 )
 
 type Config struct {
-	DBdriver       string `mapstructure:"DB_DRIVER"`
-	DB_source      string `mapstructure:"DB_SOURCE"`
-	DB_source_live string `mapstructure:"DB_SOURCE_LIVE"`
-	Signing_key    string `mapstructure:"SIGNING_KEY"`
+	DBdriver                   string        `mapstructure:"DB_DRIVER"`
+	DB_source                  string        `mapstructure:"DB_SOURCE"`
+	DB_source_live             string        `mapstructure:"DB_SOURCE_LIVE"`
+	Signing_key                string        `mapstructure:"SIGNING_KEY"`
+	Storage_dir                string        `mapstructure:"STORAGE_DIR"`
+	Storage_url                string        `mapstructure:"STORAGE_URL"`
+	Auto_migrate               bool          `mapstructure:"AUTO_MIGRATE"`
+	Notify_file                string        `mapstructure:"NOTIFY_FILE"`
+	Low_stock_interval         time.Duration `mapstructure:"LOW_STOCK_INTERVAL"`
+	Reservation_ttl            time.Duration `mapstructure:"RESERVATION_TTL"`
+	Reservation_sweep_interval time.Duration `mapstructure:"RESERVATION_SWEEP_INTERVAL"`
+	Payment_provider           string        `mapstructure:"PAYMENT_PROVIDER"`
+	Payment_webhook_secret     string        `mapstructure:"PAYMENT_WEBHOOK_SECRET"`
+	Payment_webhook_tolerance  time.Duration `mapstructure:"PAYMENT_WEBHOOK_TOLERANCE"`
+	Tax_rates_file             string        `mapstructure:"TAX_RATES_FILE"`
 }
 
 func LoadConfig(path string) (config *Config, err error) {
@@ -0,0 +1,50 @@
+package utils
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestThumbnail(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 400, 200))
+	for y := 0; y < 200; y++ {
+		for x := 0; x < 400; x++ {
+			// Left half black, right half white.
+			if x >= 200 {
+				src.Set(x, y, color.White)
+			} else {
+				src.Set(x, y, color.Black)
+			}
+		}
+	}
+
+	thumb := Thumbnail(src, 100)
+	assert.Equal(t, image.Rect(0, 0, 100, 50), thumb.Bounds())
+	r, _, _, a := thumb.At(10, 10).RGBA()
+	assert.Equal(t, uint32(0), r)
+	assert.Equal(t, uint32(0xffff), a)
+	r, _, _, _ = thumb.At(90, 10).RGBA()
+	assert.Equal(t, uint32(0xffff), r)
+
+	// Portrait images are bounded by their height.
+	tall := Thumbnail(image.NewRGBA(image.Rect(0, 0, 30, 600)), 100)
+	assert.Equal(t, image.Rect(0, 0, 5, 100), tall.Bounds())
+
+	// Extreme aspect ratios keep at least one pixel.
+	thin := Thumbnail(image.NewRGBA(image.Rect(0, 0, 1000, 2)), 100)
+	assert.Equal(t, image.Rect(0, 0, 100, 1), thin.Bounds())
+
+	// Images that already fit are returned as they are.
+	small := image.NewRGBA(image.Rect(0, 0, 80, 60))
+	assert.Same(t, small, Thumbnail(small, 100))
+
+	// Bounds that do not start at the origin are handled.
+	offset := src.SubImage(image.Rect(200, 0, 400, 200))
+	thumb = Thumbnail(offset, 50)
+	assert.Equal(t, image.Rect(0, 0, 50, 50), thumb.Bounds())
+	r, _, _, _ = thumb.At(0, 0).RGBA()
+	assert.Equal(t, uint32(0xffff), r)
+}
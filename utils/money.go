@@ -0,0 +1,58 @@
+package utils
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ParseCents converts a decimal amount such as "12.50" (the format Postgres
+// returns for numeric(10,2) columns) into an integer number of cents.
+func ParseCents(amount string) (int64, error) {
+	amount = strings.TrimSpace(amount)
+	if amount == "" {
+		return 0, fmt.Errorf("invalid amount %q", amount)
+	}
+
+	negative := false
+	if strings.HasPrefix(amount, "-") {
+		negative = true
+		amount = amount[1:]
+	}
+
+	whole, frac, _ := strings.Cut(amount, ".")
+	if len(frac) > 2 {
+		return 0, fmt.Errorf("invalid amount %q: more than two decimal places", amount)
+	}
+	for len(frac) < 2 {
+		frac += "0"
+	}
+	if whole == "" {
+		whole = "0"
+	}
+
+	units, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount %q", amount)
+	}
+	cents, err := strconv.ParseInt(frac, 10, 64)
+	if err != nil || units < 0 || cents < 0 {
+		return 0, fmt.Errorf("invalid amount %q", amount)
+	}
+
+	total := units*100 + cents
+	if negative {
+		total = -total
+	}
+	return total, nil
+}
+
+// FormatCents converts an integer number of cents back into a decimal string.
+func FormatCents(cents int64) string {
+	sign := ""
+	if cents < 0 {
+		sign = "-"
+		cents = -cents
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, cents/100, cents%100)
+}